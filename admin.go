@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/richardteaman/gator/internal/database"
+)
+
+const (
+	roleUser  = "user"
+	roleAdmin = "admin"
+)
+
+var errAdminRequired = kindErrorf(kindUnauthenticated, "this command is only available to admins")
+
+func middlewareAdmin(
+	handler func(s *state, cmd command, user database.User) error,
+) func(s *state, cmd command) error {
+	return middlewareLoggedIn(func(s *state, cmd command, user database.User) error {
+		if user.Role != roleAdmin {
+			return errAdminRequired
+		}
+		return handler(s, cmd, user)
+	})
+}
+
+// promoteIfFirstUser makes the very first account an admin so a fresh
+// install always has someone who can manage it.
+func promoteIfFirstUser(ctx context.Context, s *state, user *database.User) error {
+	admins, err := s.db.CountAdmins(ctx)
+	if err != nil {
+		return fmt.Errorf("could not count admins: %w", err)
+	}
+	if admins > 0 {
+		return nil
+	}
+	if err := s.db.SetUserRole(ctx, database.SetUserRoleParams{ID: user.ID, Role: roleAdmin}); err != nil {
+		return fmt.Errorf("could not make first user admin: %w", err)
+	}
+	user.Role = roleAdmin
+	return nil
+}
+
+// confirm asks a yes/no question on the input unless yes is already set.
+// Anything but an explicit yes, including end of input, means no.
+func confirm(s *state, prompt string, yes bool) bool {
+	if yes {
+		return true
+	}
+	fmt.Fprintf(s.out, "%s [y/N]: ", prompt)
+	line, _ := s.in.ReadString('\n')
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes"
+}
+
+var userCommand = &commandSpec{
+	Name:    "user",
+	Summary: "manages other users (admin only)",
+	Subcommands: []*commandSpec{
+		{
+			Name:    "delete",
+			Summary: "deletes a user and the feeds they added",
+			Args:    []argSpec{{Name: "name", Complete: completeUserNames}},
+			Flags:   []flagSpec{boolFlag("yes", "do not ask for confirmation")},
+			Handler: middlewareAdmin(handlerUserDelete),
+		},
+		{
+			Name:    "role",
+			Summary: "changes a user's role to user or admin",
+			Args: []argSpec{
+				{Name: "name", Complete: completeUserNames},
+				{Name: "role", Complete: completeValues(roleUser, roleAdmin)},
+			},
+			Handler: middlewareAdmin(handlerUserRole),
+		},
+		{
+			Name:    "reset-password",
+			Summary: "issues a one-time token that lets a user set a new password",
+			Args:    []argSpec{{Name: "name", Complete: completeUserNames}},
+			Handler: middlewareAdmin(handlerUserResetPassword),
+		},
+	},
+}
+
+func handlerUserDelete(s *state, cmd command, admin database.User) error {
+	name := cmd.Args[0]
+
+	ctx := context.Background()
+	target, err := s.db.GetUser(ctx, name)
+	if err != nil {
+		return fmt.Errorf("user %s not found: %w", name, err)
+	}
+	if err := checkNotLastAdmin(ctx, s, target); err != nil {
+		return err
+	}
+
+	if !confirm(s, fmt.Sprintf("Delete %s along with the feeds they added and everything that depends on them?", name), cmd.Bool("yes")) {
+		fmt.Fprintln(s.out, "Aborted")
+		return nil
+	}
+
+	if _, err := s.db.DeleteUser(ctx, name); err != nil {
+		return fmt.Errorf("could not delete user: %w", err)
+	}
+	fmt.Fprintf(s.out, "User %s deleted\n", name)
+	return nil
+}
+
+func handlerUserRole(s *state, cmd command, admin database.User) error {
+	name, role := cmd.Args[0], cmd.Args[1]
+	if role != roleUser && role != roleAdmin {
+		return cmd.usageErrorf("unknown role %q, expected user or admin", role)
+	}
+
+	ctx := context.Background()
+	target, err := s.db.GetUser(ctx, name)
+	if err != nil {
+		return fmt.Errorf("user %s not found: %w", name, err)
+	}
+	if role == roleUser {
+		if err := checkNotLastAdmin(ctx, s, target); err != nil {
+			return err
+		}
+	}
+
+	if err := s.db.SetUserRole(ctx, database.SetUserRoleParams{ID: target.ID, Role: role}); err != nil {
+		return fmt.Errorf("could not set role: %w", err)
+	}
+	fmt.Fprintf(s.out, "%s is now %s\n", name, role)
+	return nil
+}
+
+// handlerUserResetPassword prints a reset token for the admin to hand to
+// the user. Their current password keeps working until the token is used.
+func handlerUserResetPassword(s *state, cmd command, admin database.User) error {
+	name := cmd.Args[0]
+
+	ctx := context.Background()
+	target, err := s.db.GetUser(ctx, name)
+	if err != nil {
+		return fmt.Errorf("user %s not found: %w", name, err)
+	}
+	token, expires, err := issuePasswordReset(ctx, s, target)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(s.out, "Reset token for %s, valid until %s:\n%s\n", name, expires.Format(time.RFC1123), token)
+	fmt.Fprintf(s.out, "They set a new password with: gator login --reset-token <token> %s\n", name)
+	return nil
+}
+
+// checkNotLastAdmin refuses to remove the only admin, which would leave
+// nobody able to run admin commands.
+func checkNotLastAdmin(ctx context.Context, s *state, user database.User) error {
+	if user.Role != roleAdmin {
+		return nil
+	}
+	admins, err := s.db.CountAdmins(ctx)
+	if err != nil {
+		return fmt.Errorf("could not count admins: %w", err)
+	}
+	if admins <= 1 {
+		return fmt.Errorf("%s is the last admin", user.Name)
+	}
+	return nil
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richardteaman/gator/internal/database"
+	"github.com/richardteaman/gator/internal/storage"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/term"
+)
+
+const (
+	sessionLifetime    = 30 * 24 * time.Hour
+	minPasswordLength  = 8
+	resetTokenPrefix   = "gpr_"
+	resetTokenLifetime = 7 * 24 * time.Hour
+)
+
+var (
+	errInvalidCredentials = kindErrorf(kindUnauthenticated, "invalid user name or password")
+	errNoPassword         = kindErrorf(kindUnauthenticated, "user has no password yet. ask an admin for a reset token and run gator login --reset-token <token> <username>")
+	errInvalidResetToken  = kindErrorf(kindUnauthenticated, "invalid or expired reset token")
+	errNotLoggedIn        = kindErrorf(kindUnauthenticated, "not logged in. run gator login <username> first")
+	errSessionExpired     = kindErrorf(kindUnauthenticated, "session expired or revoked. run gator login <username> again")
+)
+
+// dummyHash is compared against for unknown user names.
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("gator"), bcrypt.DefaultCost)
+	return hash
+})
+
+func hashPassword(password string) (string, error) {
+	if len(password) < minPasswordLength {
+		return "", kindErrorf(kindInvalidInput, "password must be at least %d characters", minPasswordLength)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("could not hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// authenticate checks a user's password. Users created before passwords
+// existed have none and cannot authenticate until they set one.
+func authenticate(ctx context.Context, s *state, name, password string) (database.User, error) {
+	user, err := s.db.GetUser(ctx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		// compare anyway so unknown names take as long as wrong passwords
+		bcrypt.CompareHashAndPassword(dummyHash(), []byte(password))
+		return database.User{}, errInvalidCredentials
+	}
+	if err != nil {
+		return database.User{}, fmt.Errorf("could not fetch user: %w", err)
+	}
+	if !user.PasswordHash.Valid {
+		return database.User{}, errNoPassword
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash.String), []byte(password)) != nil {
+		return database.User{}, errInvalidCredentials
+	}
+	return user, nil
+}
+
+// hashToken is how session tokens are stored, so a leaked database does
+// not hand out working sessions.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func startSession(ctx context.Context, s *state, user database.User) (string, time.Time, error) {
+	token, err := randomHex(32)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("could not generate session token: %w", err)
+	}
+
+	now := time.Now()
+	session, err := s.db.CreateSession(ctx, database.CreateSessionParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: now.Add(sessionLifetime),
+	})
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("could not create session: %w", err)
+	}
+	return token, session.ExpiresAt, nil
+}
+
+// issuePasswordReset creates a one-time token that lets user set a new
+// password, replacing any token issued before.
+func issuePasswordReset(ctx context.Context, s *state, user database.User) (string, time.Time, error) {
+	secret, err := randomHex(32)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("could not generate reset token: %w", err)
+	}
+	token := resetTokenPrefix + secret
+
+	now := time.Now()
+	expires := now.Add(resetTokenLifetime)
+	err = s.db.SetPasswordReset(ctx, database.SetPasswordResetParams{
+		UserID:    user.ID,
+		CreatedAt: now,
+		TokenHash: hashToken(token),
+		ExpiresAt: expires,
+	})
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("could not store reset token: %w", err)
+	}
+	return token, expires, nil
+}
+
+// checkResetToken checks that token is user's current, unexpired reset token.
+func checkResetToken(ctx context.Context, s *state, user database.User, token string) error {
+	reset, err := s.db.GetPasswordReset(ctx, user.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errInvalidResetToken
+	}
+	if err != nil {
+		return fmt.Errorf("could not fetch reset token: %w", err)
+	}
+	if subtle.ConstantTimeCompare([]byte(hashToken(token)), []byte(reset.TokenHash)) != 1 || !reset.ExpiresAt.After(time.Now()) {
+		return errInvalidResetToken
+	}
+	return nil
+}
+
+// resetPassword sets user's password with a token from issuePasswordReset.
+// The token is used up and every session of the user is ended, since
+// whoever held them may be why the password is being reset.
+func resetPassword(ctx context.Context, s *state, user database.User, token, password string) error {
+	if err := checkResetToken(ctx, s, user, token); err != nil {
+		return err
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	return inTx(ctx, s, func(db storage.Store) error {
+		err := db.SetUserPassword(ctx, database.SetUserPasswordParams{
+			ID:           user.ID,
+			PasswordHash: sql.NullString{String: hash, Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("could not set password: %w", err)
+		}
+		if err := db.DeletePasswordReset(ctx, user.ID); err != nil {
+			return fmt.Errorf("could not use up reset token: %w", err)
+		}
+		if err := db.DeleteUserSessions(ctx, user.ID); err != nil {
+			return fmt.Errorf("could not end old sessions: %w", err)
+		}
+		return nil
+	})
+}
+
+func userForSession(ctx context.Context, s *state, token string) (database.User, error) {
+	if token == "" {
+		return database.User{}, errNotLoggedIn
+	}
+	user, err := s.db.GetUserBySessionToken(ctx, hashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.User{}, errSessionExpired
+	}
+	if err != nil {
+		return database.User{}, fmt.Errorf("could not fetch current user: %w", err)
+	}
+	return user, nil
+}
+
+// readPassword prompts for a password without echo when attached to a
+// terminal and reads a plain line otherwise, so scripts can pipe it in.
+func readPassword(s *state, prompt string) (string, error) {
+	if s.tty != nil {
+		fmt.Fprint(s.errOut, prompt)
+		pw, err := term.ReadPassword(int(s.tty.Fd()))
+		fmt.Fprintln(s.errOut)
+		if err != nil {
+			return "", fmt.Errorf("could not read password: %w", err)
+		}
+		return string(pw), nil
+	}
+
+	line, err := s.in.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return "", kindErrorf(kindInvalidInput, "no password provided on stdin")
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// readNewPassword asks for a new password, twice when a person is typing it.
+func readNewPassword(s *state, prompt string) (string, error) {
+	password, err := readPassword(s, prompt)
+	if err != nil {
+		return "", err
+	}
+	if s.tty != nil {
+		confirm, err := readPassword(s, "Repeat password: ")
+		if err != nil {
+			return "", err
+		}
+		if confirm != password {
+			return "", kindErrorf(kindInvalidInput, "passwords do not match")
+		}
+	}
+	return password, nil
+}
+
+var logoutCommand = &commandSpec{
+	Name:    "logout",
+	Summary: "ends the current session",
+	Handler: handlerLogout,
+}
+
+func handlerLogout(s *state, cmd command) error {
+	if s.Config.SessionToken() == "" {
+		fmt.Fprintln(s.out, "Not logged in")
+		return nil
+	}
+
+	if err := s.db.DeleteSession(context.Background(), hashToken(s.Config.SessionToken())); err != nil {
+		return fmt.Errorf("could not end session: %w", err)
+	}
+	if err := s.Config.SetSession(s.Config.CurrentUserName, ""); err != nil {
+		return fmt.Errorf("could not update config file: %w", err)
+	}
+	fmt.Fprintln(s.out, "Logged out")
+	return nil
+}
+
+var passwdCommand = &commandSpec{
+	Name:    "passwd",
+	Summary: "changes your password and logs out your other sessions",
+	Handler: middlewareLoggedIn(handlerPasswd),
+}
+
+// handlerPasswd changes the current user's password and signs out every
+// other session.
+func handlerPasswd(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+
+	current, err := readPassword(s, "Current password: ")
+	if err != nil {
+		return err
+	}
+	if _, err := authenticate(ctx, s, user.Name, current); err != nil {
+		return err
+	}
+
+	password, err := readNewPassword(s, "New password: ")
+	if err != nil {
+		return err
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.SetUserPassword(ctx, database.SetUserPasswordParams{
+		ID:           user.ID,
+		PasswordHash: sql.NullString{String: hash, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("could not set password: %w", err)
+	}
+	if err := s.db.DeleteUserSessions(ctx, user.ID); err != nil {
+		return fmt.Errorf("could not end old sessions: %w", err)
+	}
+
+	token, _, err := startSession(ctx, s, user)
+	if err != nil {
+		return err
+	}
+	if err := s.Config.SetSession(user.Name, token); err != nil {
+		return fmt.Errorf("could not update config file: %w", err)
+	}
+
+	fmt.Fprintln(s.out, "Password changed. Other sessions have been logged out.")
+	return nil
+}
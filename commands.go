@@ -0,0 +1,410 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// command is one run of a command: its full name such as "feed delete",
+// the positional arguments left after the flags and the parsed flags.
+type command struct {
+	Name  string
+	Args  []string
+	Flags *flag.FlagSet
+
+	spec *commandSpec
+}
+
+// commandSpec declares a command for the registry. help, validation and
+// flag parsing are all driven from it, so handlers only see arguments
+// that already match Args and Flags.
+type commandSpec struct {
+	Name    string
+	Summary string
+	// Details is printed under the summary by gator help <command>.
+	Details string
+	Args    []argSpec
+	Flags   []flagSpec
+	// Subcommands make this a group such as "feed"; groups have no
+	// Handler of their own.
+	Subcommands []*commandSpec
+	Handler     func(*state, command) error
+	// Offline commands run without loading the config or opening the
+	// database.
+	Offline bool
+	// Hidden commands are left out of help and completion.
+	Hidden bool
+
+	// path is the full name, filled in by register.
+	path string
+}
+
+// argSpec is a positional argument. Only the last one may be Variadic,
+// taking any extra arguments as well; Optional ones may be left out.
+type argSpec struct {
+	Name     string
+	Optional bool
+	Variadic bool
+	// Complete lists the values shell completion offers, such as the
+	// feed URLs for follow. args are the positional arguments before it.
+	Complete func(s *state, args []string) ([]completion, error)
+}
+
+// flagSpec defines one flag on a command's flag set.
+type flagSpec func(fs *flag.FlagSet)
+
+func boolFlag(name, usage string) flagSpec {
+	return func(fs *flag.FlagSet) { fs.Bool(name, false, usage) }
+}
+
+func stringFlag(name, value, usage string) flagSpec {
+	return func(fs *flag.FlagSet) { fs.String(name, value, usage) }
+}
+
+func intFlag(name string, value int, usage string) flagSpec {
+	return func(fs *flag.FlagSet) { fs.Int(name, value, usage) }
+}
+
+func int64Flag(name string, value int64, usage string) flagSpec {
+	return func(fs *flag.FlagSet) { fs.Int64(name, value, usage) }
+}
+
+func durationFlag(name string, value time.Duration, usage string) flagSpec {
+	return func(fs *flag.FlagSet) { fs.Duration(name, value, usage) }
+}
+
+// flagValue returns the parsed value of a flag the command declared.
+// Asking for an undeclared flag is a bug in the handler.
+func (c command) flagValue(name string) any {
+	f := c.Flags.Lookup(name)
+	if f == nil {
+		panic(fmt.Sprintf("command %s has no --%s flag", c.Name, name))
+	}
+	return f.Value.(flag.Getter).Get()
+}
+
+func (c command) Bool(name string) bool              { return c.flagValue(name).(bool) }
+func (c command) String(name string) string          { return c.flagValue(name).(string) }
+func (c command) Int(name string) int                { return c.flagValue(name).(int) }
+func (c command) Int64(name string) int64            { return c.flagValue(name).(int64) }
+func (c command) Duration(name string) time.Duration { return c.flagValue(name).(time.Duration) }
+
+// usageError is a command line that does not match the command's spec.
+type usageError struct {
+	msg  string
+	spec *commandSpec
+}
+
+func (e *usageError) Error() string {
+	if e.spec == nil {
+		return e.msg + "\nRun `gator help` for a list of commands."
+	}
+	return fmt.Sprintf("%s\nusage: %s\nRun `gator help %s` for details.", e.msg, e.spec.synopsis(), e.spec.path)
+}
+
+func usageErrorf(spec *commandSpec, format string, args ...any) error {
+	return &usageError{msg: fmt.Sprintf(format, args...), spec: spec}
+}
+
+// usageErrorf is for arguments the handler finds invalid, so they are
+// reported the same way as a missing one.
+func (c command) usageErrorf(format string, args ...any) error {
+	return usageErrorf(c.spec, format, args...)
+}
+
+type commands struct {
+	list  map[string]*commandSpec
+	order []*commandSpec
+}
+
+func (c *commands) register(spec *commandSpec) {
+	if c.list == nil {
+		c.list = make(map[string]*commandSpec)
+	}
+	setPaths(spec, spec.Name)
+	c.list[spec.Name] = spec
+	c.order = append(c.order, spec)
+}
+
+func setPaths(spec *commandSpec, path string) {
+	spec.path = path
+	for _, sub := range spec.Subcommands {
+		setPaths(sub, path+" "+sub.Name)
+	}
+}
+
+// visible is every top level command that is not Hidden.
+func (c *commands) visible() []*commandSpec {
+	var specs []*commandSpec
+	for _, spec := range c.order {
+		if !spec.Hidden {
+			specs = append(specs, spec)
+		}
+	}
+	return specs
+}
+
+// lookup finds a top level command by name.
+func (c *commands) lookup(name string) (*commandSpec, error) {
+	spec, ok := c.list[name]
+	if !ok {
+		return nil, unknownCommand(nil, name, c.visible())
+	}
+	return spec, nil
+}
+
+// run dispatches args, a command name followed by its arguments.
+func (c *commands) run(s *state, args []string) error {
+	if len(args) == 0 {
+		return usageErrorf(nil, "no command given")
+	}
+	spec, err := c.lookup(args[0])
+	if err != nil {
+		return err
+	}
+	return spec.run(s, args[1:])
+}
+
+func (spec *commandSpec) run(s *state, args []string) error {
+	if len(spec.Subcommands) > 0 {
+		if len(args) == 0 {
+			return usageErrorf(spec, "%s needs a subcommand", spec.path)
+		}
+		if isHelpFlag(args[0]) {
+			return writeCommandHelp(s.out, spec)
+		}
+		sub := spec.subcommand(args[0])
+		if sub == nil {
+			return unknownCommand(spec, args[0], spec.Subcommands)
+		}
+		return sub.run(s, args[1:])
+	}
+
+	fs := spec.flagSet()
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return writeCommandHelp(s.out, spec)
+		}
+		return usageErrorf(spec, "%v", err)
+	}
+	if err := spec.checkArgs(fs.Args()); err != nil {
+		return err
+	}
+	return spec.Handler(s, command{Name: spec.path, Args: fs.Args(), Flags: fs, spec: spec})
+}
+
+func isHelpFlag(arg string) bool {
+	return arg == "-h" || arg == "-help" || arg == "--help"
+}
+
+func (spec *commandSpec) subcommand(name string) *commandSpec {
+	for _, sub := range spec.Subcommands {
+		if sub.Name == name {
+			return sub
+		}
+	}
+	return nil
+}
+
+func (spec *commandSpec) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(spec.path, flag.ContinueOnError)
+	// errors and -h are reported by run, not printed by the flag package
+	fs.SetOutput(io.Discard)
+	for _, define := range spec.Flags {
+		define(fs)
+	}
+	return fs
+}
+
+func (spec *commandSpec) checkArgs(args []string) error {
+	for i, arg := range spec.Args {
+		if i >= len(args) && !arg.Optional {
+			return usageErrorf(spec, "missing %s", arg.synopsis())
+		}
+	}
+	variadic := len(spec.Args) > 0 && spec.Args[len(spec.Args)-1].Variadic
+	if len(args) > len(spec.Args) && !variadic {
+		return usageErrorf(spec, "unexpected argument %q", args[len(spec.Args)])
+	}
+	return nil
+}
+
+func (arg argSpec) synopsis() string {
+	name := "<" + arg.Name + ">"
+	if arg.Optional {
+		name = "[" + arg.Name + "]"
+	}
+	if arg.Variadic {
+		name += "..."
+	}
+	return name
+}
+
+// synopsis is the one line usage, e.g. "gator feed delete [flags] <url>"
+// or "gator feed <command> [arguments]" for a group.
+func (spec *commandSpec) synopsis() string {
+	parts := []string{"gator", spec.path}
+	if len(spec.Subcommands) > 0 {
+		return strings.Join(append(parts, "<command>", "[arguments]"), " ")
+	}
+	if len(spec.Flags) > 0 {
+		parts = append(parts, "[flags]")
+	}
+	for _, arg := range spec.Args {
+		parts = append(parts, arg.synopsis())
+	}
+	return strings.Join(parts, " ")
+}
+
+// unknownCommand reports name as not being one of candidates, suggesting
+// the closest ones in case of a typo.
+func unknownCommand(group *commandSpec, name string, candidates []*commandSpec) error {
+	msg := fmt.Sprintf("unknown command %q", name)
+	if group != nil {
+		msg = fmt.Sprintf("unknown %s command %q", group.path, name)
+	}
+	var names []string
+	for _, spec := range candidates {
+		names = append(names, spec.Name)
+	}
+	if similar := suggest(name, names); len(similar) > 0 {
+		msg += fmt.Sprintf(", did you mean %s?", strings.Join(similar, " or "))
+	}
+	return usageErrorf(group, "%s", msg)
+}
+
+// suggest returns the names within a couple of typos of name, or that
+// name is a prefix of, closest first.
+func suggest(name string, names []string) []string {
+	type match struct {
+		name     string
+		distance int
+	}
+	var matches []match
+	for _, candidate := range names {
+		d := editDistance(name, candidate)
+		if d <= max(1, len(name)/3) || strings.HasPrefix(candidate, name) {
+			matches = append(matches, match{candidate, d})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b match) int { return a.distance - b.distance })
+	var out []string
+	for _, m := range matches[:min(len(matches), 3)] {
+		out = append(out, m.name)
+	}
+	return out
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+var helpCommand = &commandSpec{
+	Name:    "help",
+	Summary: "shows the commands, or the usage and flags of one",
+	Args:    []argSpec{{Name: "command", Optional: true, Variadic: true, Complete: completeHelpTopics}},
+	Handler: handlerHelp,
+	Offline: true,
+}
+
+func handlerHelp(s *state, cmd command) error {
+	if len(cmd.Args) == 0 {
+		return writeHelp(s.out, s.commands)
+	}
+	spec, err := s.commands.lookup(cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	for _, name := range cmd.Args[1:] {
+		sub := spec.subcommand(name)
+		if sub == nil {
+			return unknownCommand(spec, name, spec.Subcommands)
+		}
+		spec = sub
+	}
+	return writeCommandHelp(s.out, spec)
+}
+
+// writeHelp lists every command.
+func writeHelp(w io.Writer, c *commands) error {
+	fmt.Fprintln(w, "gator is a command line RSS aggregator.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Usage: gator [--config FILE] [--profile NAME] <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, spec := range c.visible() {
+		fmt.Fprintf(tw, "  %s\t%s\n", spec.Name, spec.Summary)
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run `gator help <command>` for details about a command.")
+	return nil
+}
+
+// writeCommandHelp shows the usage of one command, its flags with their
+// defaults, or the subcommands of a group.
+func writeCommandHelp(w io.Writer, spec *commandSpec) error {
+	fmt.Fprintf(w, "Usage: %s\n\n", spec.synopsis())
+	fmt.Fprintln(w, capitalize(spec.Summary)+".")
+	if spec.Details != "" {
+		fmt.Fprintf(w, "\n%s\n", spec.Details)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if len(spec.Subcommands) > 0 {
+		fmt.Fprintln(w, "\nCommands:")
+		for _, sub := range spec.Subcommands {
+			fmt.Fprintf(tw, "  %s\t%s\n", sub.Name, sub.Summary)
+		}
+	}
+	if len(spec.Flags) > 0 {
+		fmt.Fprintln(w, "\nFlags:")
+		spec.flagSet().VisitAll(func(f *flag.Flag) {
+			kind, usage := flag.UnquoteUsage(f)
+			name := "--" + f.Name
+			if kind != "" {
+				name += " " + kind
+			}
+			if !isZeroDefault(f.DefValue) {
+				usage += fmt.Sprintf(" (default %s)", f.DefValue)
+			}
+			fmt.Fprintf(tw, "  %s\t%s\n", name, usage)
+		})
+	}
+	return tw.Flush()
+}
+
+func isZeroDefault(value string) bool {
+	return value == "" || value == "0" || value == "false" || value == "0s"
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,823 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richardteaman/gator/internal/config"
+	"github.com/richardteaman/gator/internal/database"
+)
+
+// The tests below run commands against a fresh config and database and
+// compare everything they print with testdata/golden/<test>.golden. Run
+//
+//	go test -run TestCommands -update
+//
+// to rewrite the golden files after changing what a command prints, and
+// review the diff.
+var update = flag.Bool("update", false, "rewrite the golden files with the current output")
+
+const testPassword = "secretpw1"
+
+// session is one gator install: a config file in a temporary directory,
+// a database, and a transcript of the commands run so far with their
+// output.
+type session struct {
+	t          *testing.T
+	s          *state
+	dir        string
+	output     bytes.Buffer
+	transcript strings.Builder
+	// replacer hides the parts of the output that change between runs
+	// but are not random, like the temporary directory.
+	replacer *strings.Replacer
+}
+
+// newSession sets up an install using dbURL, where {dir} stands for the
+// session's temporary directory.
+func newSession(t *testing.T, dbURL string) *session {
+	t.Helper()
+	ts := &session{t: t, dir: t.TempDir()}
+	ts.replacer = strings.NewReplacer(ts.dir, "<dir>")
+	dbURL = strings.ReplaceAll(dbURL, "{dir}", ts.dir)
+
+	opts := config.Options{Path: filepath.Join(ts.dir, "config.json")}
+	if _, err := config.Create(opts, dbURL, false); err != nil {
+		t.Fatal(err)
+	}
+	ts.s = &state{
+		commands: newCommands(),
+		in:       bufio.NewReader(strings.NewReader("")),
+		out:      &ts.output,
+		errOut:   &ts.output,
+	}
+	if err := connect(ts.s, opts); err != nil {
+		t.Fatal(err)
+	}
+	if ts.s.conn != nil {
+		t.Cleanup(func() { ts.s.conn.Close() })
+	}
+	return ts
+}
+
+// newOfflineSession is a session without a config yet, the way offline
+// commands like init start.
+func newOfflineSession(t *testing.T) *session {
+	ts := &session{t: t, dir: t.TempDir()}
+	ts.replacer = strings.NewReplacer(ts.dir, "<dir>")
+	ts.s = &state{
+		Config:   &config.Config{Path: filepath.Join(ts.dir, "config.json")},
+		commands: newCommands(),
+		in:       bufio.NewReader(strings.NewReader("")),
+		out:      &ts.output,
+		errOut:   &ts.output,
+	}
+	return ts
+}
+
+// run runs line as the arguments to gator, with input as what is typed
+// at its prompts, and records it in the transcript. {dir} in line is the
+// temporary directory.
+func (ts *session) run(line, input string) error {
+	ts.t.Helper()
+	args, err := splitWords(strings.ReplaceAll(line, "{dir}", ts.dir))
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	ts.s.in = bufio.NewReader(strings.NewReader(input))
+	err = ts.s.commands.run(ts.s, args)
+	ts.record("$ gator "+line, err)
+	return err
+}
+
+// call records what fn prints, for steps that are not a command of
+// their own.
+func (ts *session) call(name string, fn func(*state)) {
+	fn(ts.s)
+	ts.record("# "+name, nil)
+}
+
+func (ts *session) record(heading string, err error) {
+	ts.transcript.WriteString(heading + "\n")
+	ts.transcript.Write(ts.output.Bytes())
+	if ts.output.Len() > 0 && !bytes.HasSuffix(ts.output.Bytes(), []byte("\n")) {
+		ts.transcript.WriteString("\n")
+	}
+	ts.output.Reset()
+	if err != nil {
+		fmt.Fprintf(&ts.transcript, "error: %v\nexit status %d\n", err, exitCode(err))
+	}
+	ts.transcript.WriteString("\n")
+}
+
+// registerUser creates a user, leaving them logged in.
+func (ts *session) registerUser(name string) {
+	ts.t.Helper()
+	if err := ts.run("register "+name, testPassword+"\n"); err != nil {
+		ts.t.Fatal(err)
+	}
+}
+
+// resetToken is the last reset token printed for the user name.
+func (ts *session) resetToken(name string) string {
+	ts.t.Helper()
+	pattern := regexp.MustCompile(`(?m)(?:^  ` + name + ` +|^Reset token for ` + name + `, .*\n)(` + resetTokenPrefix + `[0-9a-f]+)`)
+	matches := pattern.FindAllStringSubmatch(ts.transcript.String(), -1)
+	if len(matches) == 0 {
+		ts.t.Fatalf("no reset token printed for %s yet", name)
+	}
+	return matches[len(matches)-1][1]
+}
+
+var lastIDPattern = regexp.MustCompile(`[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`)
+
+// lastID is the last id printed, for commands that take one.
+func (ts *session) lastID() string {
+	ids := lastIDPattern.FindAllString(ts.transcript.String(), -1)
+	if len(ids) == 0 {
+		ts.t.Fatal("no id printed yet")
+	}
+	return ids[len(ids)-1]
+}
+
+// normalizers replace what differs from run to run: ids, times, tokens
+// and secrets.
+var normalizers = []struct {
+	pattern *regexp.Regexp
+	repl    string
+}{
+	{lastIDPattern, "<id>"},
+	{regexp.MustCompile(`\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}(\.\d+)?( ?(Z|[+-]\d{2}:?\d{2}))?( [A-Z]{3,5})?( m=[+-][\d.]+)?`), "<time>"},
+	{regexp.MustCompile(`\d{4}-\d{2}-\d{2}`), "<date>"},
+	{regexp.MustCompile(`[A-Z][a-z]{2}, \d{2} [A-Z][a-z]{2} \d{4} \d{2}:\d{2}:\d{2} [A-Z+\-0-9]{3,5}`), "<time>"},
+	{regexp.MustCompile(`\((\d+(\.\d+)?(ns|µs|ms|s))+\)`), "(<duration>)"},
+	{regexp.MustCompile(apiTokenPrefix + `[0-9a-f]+`), apiTokenPrefix + "<token>"},
+	{regexp.MustCompile(resetTokenPrefix + `[0-9a-f]+`), resetTokenPrefix + "<token>"},
+	{regexp.MustCompile(`Secret: [0-9a-f]+`), "Secret: <secret>"},
+	{regexp.MustCompile(`127\.0\.0\.1:\d+`), "<feed-server>"},
+}
+
+// check compares the transcript with the test's golden file.
+func (ts *session) check() {
+	ts.t.Helper()
+	got := ts.replacer.Replace(ts.transcript.String())
+	for _, n := range normalizers {
+		got = n.pattern.ReplaceAllString(got, n.repl)
+	}
+
+	path := filepath.Join("testdata", "golden", strings.ReplaceAll(ts.t.Name(), "/", "_")+".golden")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			ts.t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			ts.t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		ts.t.Fatalf("%v, run with -update to create it", err)
+	}
+	if got != string(want) {
+		ts.t.Errorf("output differs from %s, run with -update to accept it\n--- got\n%s--- want\n%s", path, got, want)
+	}
+}
+
+const testFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<channel>
+  <title>Test Feed</title>
+  <link>https://example.com/</link>
+  <description>Posts for the tests</description>
+  <item>
+    <title>Hello gophers</title>
+    <link>https://example.com/hello</link>
+    <description>&lt;p&gt;A &lt;b&gt;first&lt;/b&gt; post with &lt;a href="https://go.dev/"&gt;a link&lt;/a&gt;.&lt;/p&gt;</description>
+    <pubDate>Mon, 05 Oct 2026 10:00:00 +0000</pubDate>
+    <dc:creator>Ada</dc:creator>
+    <category>go</category>
+  </item>
+  <item>
+    <title>Sponsored: buy things</title>
+    <link>https://example.com/ad</link>
+    <description>Things for sale.</description>
+    <pubDate>Tue, 06 Oct 2026 10:00:00 +0000</pubDate>
+    <category>sponsored</category>
+  </item>
+</channel>
+</rss>`
+
+// feedServer serves testFeed at /feed.xml, and at /mirror.xml with its
+// links on http://, like a blog that can be reached both ways.
+func feedServer(t *testing.T) string {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		feed := testFeed
+		switch r.URL.Path {
+		case "/feed.xml":
+		case "/mirror.xml":
+			feed = strings.ReplaceAll(feed, "<link>https://", "<link>http://")
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/rss+xml")
+		fmt.Fprint(w, feed)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL + "/feed.xml"
+}
+
+// withPosts is a session with a user following testFeed, fetched once.
+func withPosts(t *testing.T) (*session, string) {
+	ts := newSession(t, "memory:")
+	ts.registerUser("alice")
+	url := feedServer(t)
+	ts.run(`addfeed "Test Feed" `+url, "")
+	ts.call("fetch the feed", scrapeFeeds)
+	return ts, url
+}
+
+func TestCommands(t *testing.T) {
+	t.Run("help", func(t *testing.T) {
+		ts := newOfflineSession(t)
+		ts.run("help", "")
+		ts.run("help feed", "")
+		ts.run("help feed delete", "")
+		ts.run("help nope", "")
+		ts.run("browse --help", "")
+		ts.run("folow", "")
+		ts.run("feed", "")
+		ts.run("feed remove", "")
+		ts.run("follow", "")
+		ts.run("browse --bogus", "")
+		ts.check()
+	})
+
+	t.Run("init", func(t *testing.T) {
+		ts := newOfflineSession(t)
+		ts.run("init --db-url memory: --no-check", "")
+		ts.run("init --db-url memory: --no-check", "")
+		ts.run("init --db-url sqlite://{dir}/gator.db --no-check --force", "")
+		ts.run("init --create-db --db-url sqlite://{dir}/gator.db", "")
+		ts.run("init --no-check --force", "memory:\n")
+		ts.check()
+	})
+
+	t.Run("migrate", func(t *testing.T) {
+		ts := newSession(t, "sqlite://{dir}/gator.db")
+		ts.run("migrate status", "")
+		ts.run("migrate up --to 2", "")
+		ts.run("migrate up --to 2", "")
+		ts.run("migrate down --to x", "")
+		ts.run("migrate down", "n\n")
+		ts.run("migrate down --yes", "")
+		ts.run("migrate up", "")
+		ts.run("migrate status", "")
+		ts.check()
+	})
+
+	t.Run("migrate_memory", func(t *testing.T) {
+		ts := newSession(t, "memory:")
+		ts.run("migrate status", "")
+		ts.check()
+	})
+
+	t.Run("users", func(t *testing.T) {
+		ts := newSession(t, "memory:")
+		ts.run("users", "")
+		ts.run("register alice", testPassword+"\n")
+		ts.run("register alice", testPassword+"\n")
+		ts.run("register bob", "short\n")
+		ts.run("register bob", "")
+		ts.run("register bob", testPassword+"\n")
+		ts.run("users", "")
+		ts.run("user role bob admin", "")
+		ts.run("login alice", "wrong password\n")
+		ts.run("login nobody", testPassword+"\n")
+		ts.run("login alice", testPassword+"\n")
+		ts.run("user role bob superuser", "")
+		ts.run("user role bob admin", "")
+		ts.run("user role nobody admin", "")
+		ts.run("passwd", "wrong password\nnewpassword\n")
+		ts.run("passwd", testPassword+"\nnewpassword\n")
+		ts.run("user delete bob", "n\n")
+		ts.run("user delete bob", "y\n")
+		ts.run("user delete --yes nobody", "")
+		ts.run("users", "")
+		ts.run("logout", "")
+		ts.run("logout", "")
+		ts.run("following", "")
+		ts.run("login alice", "newpassword\n")
+		ts.check()
+	})
+
+	t.Run("legacy_accounts", func(t *testing.T) {
+		ts := newSession(t, "sqlite://{dir}/gator.db")
+		ts.run("migrate up --to 11", "")
+		ts.call("add users as versions without passwords stored them", func(s *state) {
+			start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+			for i, name := range []string{"old", "older"} {
+				created := start.Add(time.Duration(-i) * time.Hour)
+				if _, err := s.conn.ExecContext(context.Background(),
+					"insert into users (id, created_at, updated_at, name) values (?, ?, ?, ?)",
+					uuid.New(), created, created, name); err != nil {
+					t.Fatal(err)
+				}
+			}
+		})
+		ts.run("migrate up", "")
+		older, old := ts.resetToken("older"), ts.resetToken("old")
+
+		// nobody can claim an account by logging in first
+		ts.run("login older", testPassword+"\n")
+		ts.run("login --reset-token "+resetTokenPrefix+"0000 older", testPassword+"\n")
+		ts.run("login --reset-token "+old+" older", testPassword+"\n")
+		ts.run("login --reset-token "+older+" older", "short\n")
+		ts.run("login --reset-token "+older+" older", testPassword+"\n")
+		ts.run("login --reset-token "+older+" older", testPassword+"\n")
+		ts.run("login older", testPassword+"\n")
+
+		// the oldest account became the admin, who can hand out new tokens
+		ts.run("users", "")
+		ts.run("user reset-password old", "")
+		ts.run("login --reset-token "+old+" old", testPassword+"\n")
+		ts.run("login --reset-token "+ts.resetToken("old")+" old", testPassword+"\n")
+		ts.run("user reset-password older", "")
+		ts.run("migrate up", "")
+		ts.check()
+	})
+
+	t.Run("feeds", func(t *testing.T) {
+		ts := newSession(t, "memory:")
+		ts.registerUser("alice")
+		ts.run("feeds", "")
+		ts.run("following", "")
+		ts.run(`addfeed "Test Feed" https://example.com/rss`, "")
+		ts.run(`addfeed "Test Feed" https://example.com/rss`, "")
+		ts.run(`addfeed Other https://example.org/atom`, "")
+		ts.run("feeds", "")
+		ts.run("following", "")
+		ts.run("unfollow http://Example.org/atom/", "")
+		ts.run("unfollow https://example.org/atom", "")
+		ts.run("unfollow https://example.net/missing", "")
+		ts.run("following", "")
+		ts.run("follow https://example.org/atom", "")
+		ts.run("follow https://example.org/atom", "")
+		ts.run("follow https://example.net/missing", "")
+		ts.registerUser("bob")
+		ts.run("follow https://example.com/rss", "")
+		ts.run("feed delete https://example.com/rss", "")
+		ts.run("login alice", testPassword+"\n")
+		ts.run("feed delete https://example.com/rss", "n\n")
+		ts.run("feed delete https://example.com/rss", "yes\n")
+		ts.run("feed delete --yes https://example.net/missing", "")
+		ts.run("feeds", "")
+		ts.check()
+	})
+
+	// the merge runs in a transaction everywhere but memory:, so both take
+	// the same script and should print the same
+	for name, dbURL := range map[string]string{"feed_edit": "memory:", "feed_edit_sqlite": "sqlite://{dir}/gator.db"} {
+		t.Run(name, func(t *testing.T) {
+			ts := newSession(t, dbURL)
+			if dbURL != "memory:" {
+				ts.run("migrate up", "")
+			}
+			ts.registerUser("alice")
+			url := feedServer(t)
+			ts.run(`addfeed "Test Feed" `+url, "")
+			ts.call("fetch the feed", scrapeFeeds)
+			ts.run("addfeed Mirror https://example.org/mirror", "")
+			ts.run(`feed rename `+url+` "Renamed Feed"`, "")
+			ts.run(`feed rename `+url+` " "`, "")
+			ts.run("feed rename https://example.net/missing Nope", "")
+			ts.registerUser("bob")
+			ts.run("follow "+url, "")
+			ts.run("feed rename "+url+" Mine", "")
+			ts.run("feed delete --yes "+url, "")
+			ts.run("addfeed Bob https://example.com/bob", "")
+			ts.run(`feed rename https://example.com/bob "Bob's Feed"`, "")
+			ts.run("feed set-url https://example.com/bob ftp://example.com/bob", "")
+			ts.run("feed set-url https://example.com/bob https://example.com/bob2", "")
+			ts.run("feed set-url https://example.com/bob2 https://example.com/bob2", "")
+			ts.run("feed set-url --yes https://example.com/bob2 https://example.org/mirror", "")
+			ts.run("login alice", testPassword+"\n")
+			ts.run("feed set-url "+url+" https://example.org/mirror", "n\n")
+			ts.run("feed set-url "+url+" https://example.org/mirror", "y\n")
+			ts.run("feeds", "")
+			ts.run("following", "")
+			ts.run("browse --plain 2", "")
+			ts.run("feed delete --yes https://example.com/bob2", "")
+			ts.run("login bob", testPassword+"\n")
+			ts.run("following", "")
+			ts.check()
+		})
+	}
+
+	for name, dbURL := range map[string]string{"feed_merge": "memory:", "feed_merge_sqlite": "sqlite://{dir}/gator.db"} {
+		t.Run(name, func(t *testing.T) {
+			ts := newSession(t, dbURL)
+			if dbURL != "memory:" {
+				ts.run("migrate up", "")
+			}
+			ts.registerUser("alice")
+			url := feedServer(t)
+			mirror := strings.TrimSuffix(url, "feed.xml") + "mirror.xml"
+			ts.run(`addfeed "Test Feed" `+url, "")
+			ts.run("addfeed Mirror "+mirror, "")
+			ts.call("fetch the feed", scrapeFeeds)
+			ts.call("fetch the mirror", scrapeFeeds)
+			ts.registerUser("bob")
+			ts.run("follow "+mirror, "")
+			ts.run("feed merge --yes "+mirror+" "+url, "")
+			ts.run("addfeed Bob https://example.com/bob", "")
+			ts.run("feed merge --yes https://example.com/bob "+url, "")
+			ts.run("feed delete --yes https://example.com/bob", "")
+			ts.run("login alice", testPassword+"\n")
+			ts.run("feed merge "+url+" "+url, "")
+			ts.run("feed merge "+mirror+" https://example.net/missing", "")
+			ts.run("feed merge "+mirror+" "+url, "n\n")
+			ts.run("feed merge "+mirror+" "+url, "y\n")
+			ts.run("feeds", "")
+			ts.run("addfeed Again "+mirror, "")
+			ts.run("feed set-url "+url+" https://example.org/moved", "")
+			ts.run("addfeed Again "+url, "")
+			ts.run("addfeed Third https://example.org/third", "")
+			ts.run("feed merge --yes https://example.org/moved https://example.org/third", "")
+			ts.run("addfeed Again "+mirror, "")
+			ts.run("feed set-url https://example.org/third "+url, "")
+			ts.run("feeds", "")
+			ts.run("login bob", testPassword+"\n")
+			ts.run("follow "+mirror, "")
+			ts.run("following", "")
+			ts.run("browse --plain 5", "")
+			ts.check()
+		})
+	}
+
+	t.Run("feed_urls", func(t *testing.T) {
+		ts := newSession(t, "memory:")
+		ts.registerUser("alice")
+		ts.run("addfeed Blog HTTPS://Example.COM:443/feed/#latest", "")
+		ts.run("addfeed Again https://example.com/feed", "")
+		ts.run("addfeed Plain http://example.com/feed", "")
+		ts.run("addfeed Books https://bücher.example/rss", "")
+		ts.run("addfeed Bad ftp://example.com/feed", "")
+		ts.run(`addfeed Bad "https://exa mple.com/feed"`, "")
+		ts.run("feeds", "")
+		ts.registerUser("bob")
+		ts.run("follow https://BÜCHER.example/rss/", "")
+		ts.run("follow https://xn--bcher-kva.example/rss#top", "")
+		ts.run("login alice", testPassword+"\n")
+		ts.run(`feed rename https://example.com/feed/ "Example Blog"`, "")
+		ts.run("feed set-url --yes http://example.com/feed https://EXAMPLE.com/feed", "")
+		ts.run("feed set-url https://bücher.example/rss https://bücher.example/rss/", "")
+		ts.run("addfeed Insecure http://insecure.example/feed", "")
+		ts.run("feed set-url http://insecure.example/feed https://insecure.example/feed", "")
+		ts.run("addfeed Again http://insecure.example/feed/", "")
+		ts.run("feeds", "")
+		ts.check()
+	})
+
+	// before migration 16 URLs were stored as typed, so an install may have
+	// feeds that are the same once canonical; migration 17 lists them
+	t.Run("canonical_urls_migration", func(t *testing.T) {
+		ts := newSession(t, "sqlite://{dir}/gator.db")
+		ts.run("migrate up", "")
+		ts.registerUser("alice")
+		ts.run("migrate down --yes --to 15", "")
+		ts.call("add feeds and a redirect as older versions stored them", func(s *state) {
+			ctx := context.Background()
+			user, err := s.db.GetUser(ctx, "alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+			feeds := []struct{ name, url string }{
+				{"Blog", "http://Example.com/feed/"},
+				{"Blog Again", "http://example.com/feed"},
+				{"Blog Secure", "https://example.com/feed"},
+				{"Books", "https://bücher.example/rss#top"},
+				{"Books Again", "https://xn--bcher-kva.example:443/rss"},
+				{"Old Typo", "htp://example.com"},
+			}
+			start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+			var blogID uuid.UUID
+			for i, f := range feeds {
+				id := uuid.New()
+				if i == 0 {
+					blogID = id
+				}
+				created := start.Add(time.Duration(i) * time.Hour)
+				if _, err := s.conn.ExecContext(ctx,
+					"insert into feeds (id, created_at, updated_at, name, url, user_id, serial_id) values (?, ?, ?, ?, ?, ?, ?)",
+					id, created, created, f.name, f.url, user.ID, i+1); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := s.conn.ExecContext(ctx,
+				"insert into feed_redirects (url, created_at, feed_id) values (?, ?, ?)",
+				"HTTP://OLD.example.com/feed/", start, blogID); err != nil {
+				t.Fatal(err)
+			}
+		})
+		ts.run("migrate up", "")
+		ts.run("feeds", "")
+		ts.run("addfeed Blog http://example.com/feed", "")
+		ts.run("addfeed Blog http://old.example.com/feed", "")
+		ts.run("feed merge --yes 'http://example.com/feed' 'http://Example.com/feed/'", "")
+		ts.run("feed merge --yes 'https://xn--bcher-kva.example:443/rss' 'https://bücher.example/rss#top'", "")
+		ts.run("feeds", "")
+		ts.run("addfeed Blog http://example.com/feed", "")
+		ts.run("addfeed Blog http://old.example.com/feed", "")
+		ts.run("following", "")
+		ts.check()
+	})
+
+	t.Run("canonical_url_scheme_migration", func(t *testing.T) {
+		ts := newSession(t, "sqlite://{dir}/gator.db")
+		ts.run("migrate up", "")
+		ts.registerUser("alice")
+		ts.run("migrate down --yes --to 19", "")
+		ts.call("add feeds as version 19 stored them", func(s *state) {
+			ctx := context.Background()
+			user, err := s.db.GetUser(ctx, "alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+			feeds := []struct{ name, url string }{
+				{"Blog", "http://example.com/feed"},
+				{"Blog Secure", "https://example.com/feed/"},
+				{"Other", "http://example.org/feed"},
+			}
+			start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+			for i, f := range feeds {
+				created := start.Add(time.Duration(i) * time.Hour)
+				if _, err := s.conn.ExecContext(ctx,
+					"insert into feeds (id, created_at, updated_at, name, url, user_id, serial_id, canonical_url) values (?, ?, ?, ?, ?, ?, ?, ?)",
+					uuid.New(), created, created, f.name, f.url, user.ID, i+1, strings.TrimSuffix(f.url, "/")); err != nil {
+					t.Fatal(err)
+				}
+			}
+		})
+		ts.run("migrate up", "")
+		ts.run("addfeed Other https://example.org/feed", "")
+		ts.run("feed merge --yes https://example.com/feed/ http://example.com/feed", "")
+		ts.run("feeds", "")
+		ts.check()
+	})
+
+	t.Run("agg", func(t *testing.T) {
+		ts := newSession(t, "memory:")
+		ts.registerUser("alice")
+		ts.run("agg soon", "")
+		ts.run("agg 0s", "")
+		ts.call("fetch with no feeds", scrapeFeeds)
+		url := feedServer(t)
+		ts.run(`addfeed "Test Feed" `+url, "")
+		ts.call("fetch the feed", scrapeFeeds)
+		ts.run(`addfeed Broken `+strings.TrimSuffix(url, "feed.xml")+"missing.xml", "")
+		ts.call("fetch a feed that is not a feed", scrapeFeeds)
+		ts.check()
+	})
+
+	t.Run("browse", func(t *testing.T) {
+		ts, _ := withPosts(t)
+		ts.run("browse", "")
+		ts.run("browse 1", "")
+		ts.run("browse --plain 5", "")
+		ts.run("browse --width 30 1", "")
+		ts.run("browse 0", "")
+		ts.run("browse many", "")
+		ts.check()
+	})
+
+	t.Run("rules", func(t *testing.T) {
+		ts, url := withPosts(t)
+		ts.run("rules", "")
+		ts.run("rule add mute category sponsored", "")
+		ts.run("rule add --feed "+url+" highlight author ada", "")
+		ts.run("rule add highlight regex (", "")
+		ts.run("rule add hide keyword x", "")
+		ts.run("rule add mute color red", "")
+		ts.run(`rule add mute keyword "  "`, "")
+		ts.run("rule add --feed https://example.net/missing mute keyword x", "")
+		ts.run("rules", "")
+		ts.run("browse --plain", "")
+		ts.run("browse --plain --all", "")
+		ts.run("browse --plain --highlighted", "")
+		ts.run("rule remove "+ts.lastID(), "")
+		ts.run("rule remove "+ts.lastID(), "")
+		ts.run("rule remove 42", "")
+		ts.run("rules", "")
+		ts.check()
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		ts, _ := withPosts(t)
+		ts.run("reset --follows", "n\n")
+		ts.run("reset --follows --yes", "")
+		ts.run("following", "")
+		ts.run("reset --posts-older-than soon --yes", "")
+		ts.run("reset --posts-older-than 1h --yes", "")
+		ts.run("reset --yes", "")
+		ts.run("users", "")
+		ts.check()
+	})
+
+	t.Run("publish", func(t *testing.T) {
+		ts, _ := withPosts(t)
+		ts.run("publish -", "")
+		ts.run("publish --format rss --limit 1 --link https://example.com/me -", "")
+		ts.run("publish --format json -", "")
+		ts.run("publish {dir}/feed.xml", "")
+		ts.run("publish --unread -", "")
+		ts.run("rule add mute category sponsored", "")
+		ts.run("rule add highlight author ada", "")
+		ts.run("publish --format rss -", "")
+		ts.run("publish --format rss --all -", "")
+		ts.run("publish --format rss --all --highlighted -", "")
+		ts.check()
+	})
+
+	t.Run("digest", func(t *testing.T) {
+		ts, _ := withPosts(t)
+		ts.run("digest", "")
+		ts.run("digest --eml {dir}/digest.eml", "")
+		ts.run("digest --eml {dir}/digest.eml", "")
+		ts.run("digest --since 10000h --limit 1 --eml {dir}/digest.eml", "")
+		ts.run("digest --eml {dir}/digest.eml", "")
+		ts.run("digest --eml {dir}/digest.eml", "")
+		ts.check()
+	})
+
+	t.Run("tokens", func(t *testing.T) {
+		ts := newSession(t, "memory:")
+		ts.registerUser("alice")
+		ts.run("tokens", "")
+		ts.run("token create ci", "")
+		ts.run("token create --scope read,follows --expires 30d deploy", "")
+		ts.run("token create ci", "")
+		ts.run("token create --scope root x", "")
+		ts.run("token create --expires soon x", "")
+		ts.run("tokens", "")
+		ts.run("token revoke ci", "")
+		ts.run("token revoke ci", "")
+		ts.run("tokens", "")
+		ts.check()
+	})
+
+	t.Run("webhooks", func(t *testing.T) {
+		ts, url := withPosts(t)
+		ts.run("webhooks", "")
+		ts.run("webhook add ftp://example.com/hook", "")
+		ts.run("webhook add --feed https://example.net/missing https://hooks.example.com/a", "")
+		ts.run("webhook add --feed "+url+" --keyword gophers --secret s3cret https://hooks.example.com/a", "")
+		ts.run("webhooks", "")
+		ts.run("webhook log "+ts.lastID(), "")
+		ts.run("webhook log nope", "")
+		ts.run("webhook add https://hooks.example.com/b", "")
+		ts.run("webhook remove "+ts.lastID(), "")
+		ts.run("webhook remove "+ts.lastID(), "")
+		ts.run("webhooks", "")
+		ts.check()
+	})
+
+	t.Run("fever_key", func(t *testing.T) {
+		ts := newSession(t, "memory:")
+		ts.run("fever-key", "hunter2\n")
+		ts.registerUser("alice")
+		ts.run("fever-key", "\n")
+		ts.run("fever-key", "hunter2\n")
+		ts.check()
+	})
+
+	t.Run("serve", func(t *testing.T) {
+		ts := newSession(t, "memory:")
+		ts.run("serve --addr 127.0.0.1:-1", "")
+		ts.check()
+	})
+
+	t.Run("completion", func(t *testing.T) {
+		ts := newSession(t, "memory:")
+		ts.registerUser("alice")
+		ts.run(`addfeed "Test Feed" https://example.com/rss`, "")
+		ts.run("completion bash", "")
+		ts.run("completion ksh", "")
+		ts.run(`__complete -- fo`, "")
+		ts.run(`__complete -- feed ""`, "")
+		ts.run(`__complete -- follow https://`, "")
+		ts.run(`__complete -- browse --`, "")
+		ts.run(`__complete -- user role alice ""`, "")
+		ts.check()
+	})
+
+	t.Run("shell", func(t *testing.T) {
+		ts := newSession(t, "memory:")
+		script := filepath.Join(ts.dir, "script.gator")
+		err := os.WriteFile(script, []byte("# set up\nregister alice\n\naddfeed 'Test Feed' https://example.com/rss\nfeeds\n"), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ts.run("shell {dir}/script.gator", testPassword+"\n")
+		ts.run("shell {dir}/missing.gator", "")
+		ts.run("shell -", "following\nfollow https://example.net/missing\nusers\n")
+		ts.run("shell --keep-going -", "following\nfollow https://example.net/missing\nusers\n")
+		ts.run("shell -", "shell\n")
+		ts.run("shell -", "users\nexit\nfeeds\n")
+		ts.run("shell -", "addfeed 'Unterminated\n")
+		ts.check()
+	})
+}
+
+// TestExitCodes checks that the kind of each error reaches the exit code,
+// however it is wrapped.
+func TestExitCodes(t *testing.T) {
+	ts := newSession(t, "memory:")
+	ts.registerUser("alice")
+	user, err := ts.s.db.GetUser(context.Background(), "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, dupErr := ts.s.db.CreateUser(context.Background(), database.CreateUserParams{
+		ID:   uuid.New(),
+		Name: user.Name,
+	})
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, 0},
+		{"other", fmt.Errorf("something broke"), exitFailure},
+		{"usage", usageErrorf(nil, "no command given"), exitInvalidInput},
+		{"not found", kindErrorf(kindNotFound, "rule %s not found", "x"), exitNotFound},
+		{"no rows", fmt.Errorf("feed not found: %w", sql.ErrNoRows), exitNotFound},
+		{"unique violation", fmt.Errorf("could not create user: %w", dupErr), exitAlreadyExists},
+		{"unauthenticated", fmt.Errorf("command failed: %w", errNotLoggedIn), exitUnauthenticated},
+		{"admin", errAdminRequired, exitUnauthenticated},
+		{"backend", kindErrorf(kindBackend, "database down"), exitBackend},
+		{"outermost kind wins", kindErrorf(kindInvalidInput, "bad: %w", errNotLoggedIn), exitInvalidInput},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("%s: exitCode(%v) = %d, want %d", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestFeedURLs(t *testing.T) {
+	tests := []struct {
+		raw, normalized, canonical string
+	}{
+		{"https://example.com/feed.xml", "https://example.com/feed.xml", "https://example.com/feed.xml"},
+		{"  HTTPS://Example.COM/Feed.xml  ", "https://example.com/Feed.xml", "https://example.com/Feed.xml"},
+		{"http://example.com:80/feed", "http://example.com/feed", "https://example.com/feed"},
+		{"http://Example.com/feed/", "http://example.com/feed/", "https://example.com/feed"},
+		{"https://example.com:443/feed", "https://example.com/feed", "https://example.com/feed"},
+		{"http://example.com:443/feed", "http://example.com:443/feed", "https://example.com:443/feed"},
+		{"https://example.com:80/feed", "https://example.com:80/feed", "https://example.com:80/feed"},
+		{"https://example.com:8443/feed", "https://example.com:8443/feed", "https://example.com:8443/feed"},
+		{"https://example.com/feed/", "https://example.com/feed/", "https://example.com/feed"},
+		{"https://example.com/", "https://example.com/", "https://example.com"},
+		{"https://example.com", "https://example.com", "https://example.com"},
+		{"https://example.com/feed#latest", "https://example.com/feed", "https://example.com/feed"},
+		{"https://example.com/feed/?format=rss", "https://example.com/feed/?format=rss", "https://example.com/feed?format=rss"},
+		{"https://bücher.example/rss", "https://xn--bcher-kva.example/rss", "https://xn--bcher-kva.example/rss"},
+		{"https://BÜCHER.example/rss", "https://xn--bcher-kva.example/rss", "https://xn--bcher-kva.example/rss"},
+		{"https://xn--bcher-kva.example/rss", "https://xn--bcher-kva.example/rss", "https://xn--bcher-kva.example/rss"},
+		{"http://[::1]:80/feed", "http://[::1]/feed", "https://[::1]/feed"},
+		{"http://[::1]:8080/feed", "http://[::1]:8080/feed", "https://[::1]:8080/feed"},
+		{"https://Example.com./feed", "https://example.com/feed", "https://example.com/feed"},
+		{"https://my_blog.example.com/feed", "https://my_blog.example.com/feed", "https://my_blog.example.com/feed"},
+		{"http://127.0.0.1:8080/feed.xml", "http://127.0.0.1:8080/feed.xml", "https://127.0.0.1:8080/feed.xml"},
+	}
+	for _, tt := range tests {
+		if got, err := normalizeFeedURL(tt.raw); err != nil || got != tt.normalized {
+			t.Errorf("normalizeFeedURL(%q) = %q, %v, want %q", tt.raw, got, err, tt.normalized)
+		}
+		if got, err := canonicalFeedURL(tt.raw); err != nil || got != tt.canonical {
+			t.Errorf("canonicalFeedURL(%q) = %q, %v, want %q", tt.raw, got, err, tt.canonical)
+		}
+	}
+
+	for _, raw := range []string{"", "example.com/feed", "ftp://example.com/feed", "https://", "https:///feed", "https://exa mple.com/feed", "https://a..b/feed", "https://./feed", "https://-bad.example/feed", "https://" + strings.Repeat("a", 64) + ".example/feed"} {
+		if got, err := normalizeFeedURL(raw); err == nil {
+			t.Errorf("normalizeFeedURL(%q) = %q, want an error", raw, got)
+		}
+	}
+}
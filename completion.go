@@ -0,0 +1,349 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/richardteaman/gator/internal/config"
+)
+
+// completion is one candidate offered by shell completion. Shells that can
+// show the Description next to it do.
+type completion struct {
+	Value       string
+	Description string
+}
+
+var completionCommand = &commandSpec{
+	Name:    "completion",
+	Summary: "prints the shell completion script for bash, zsh or fish",
+	Details: `Load it into the running shell with
+
+  source <(gator completion bash)
+
+and add that line to ~/.bashrc, or ~/.zshrc for zsh, to keep it. For fish run
+
+  gator completion fish > ~/.config/fish/completions/gator.fish`,
+	Args:    []argSpec{{Name: "shell", Complete: completeValues("bash", "zsh", "fish")}},
+	Handler: handlerCompletion,
+	Offline: true,
+}
+
+func handlerCompletion(s *state, cmd command) error {
+	script, ok := completionScripts[cmd.Args[0]]
+	if !ok {
+		return cmd.usageErrorf("unsupported shell %q, expected bash, zsh or fish", cmd.Args[0])
+	}
+	_, err := fmt.Fprint(s.out, script)
+	return err
+}
+
+// completeCommand is what the completion scripts call. They pass "--" and
+// then the words after "gator" up to the cursor, the last being the one
+// to complete. It prints one candidate per line, with a tab before the
+// description if there is one.
+var completeCommand = &commandSpec{
+	Name:    "__complete",
+	Summary: "lists the completions for a command line",
+	Args:    []argSpec{{Name: "word", Optional: true, Variadic: true}},
+	Handler: handlerComplete,
+	Offline: true,
+	Hidden:  true,
+}
+
+func handlerComplete(s *state, cmd command) error {
+	words := cmd.Args
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	for _, c := range complete(s, words) {
+		if c.Description != "" {
+			fmt.Fprintf(s.out, "%s\t%s\n", c.Value, c.Description)
+		} else {
+			fmt.Fprintln(s.out, c.Value)
+		}
+	}
+	return nil
+}
+
+// complete returns the candidates for the last of words that start with
+// it. Values of arguments come from the database, which is only opened
+// when one is needed; any error there just means no candidates.
+func complete(s *state, words []string) []completion {
+	current := words[len(words)-1]
+	words = words[:len(words)-1]
+
+	// global flags come before the command, and say which config to use
+	opts := config.Options{Path: s.Config.Path, Profile: s.Config.Profile}
+	for len(words) > 0 && strings.HasPrefix(words[0], "-") {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(words[0], "-"), "=")
+		words = words[1:]
+		if !hasValue {
+			if len(words) == 0 {
+				return nil
+			}
+			value, words = words[0], words[1:]
+		}
+		switch name {
+		case "config":
+			opts.Path = value
+		case "profile":
+			opts.Profile = value
+		}
+	}
+
+	if len(words) == 0 {
+		if strings.HasPrefix(current, "-") {
+			return matching(current, []completion{
+				{"--config", "config file to use instead of the default locations"},
+				{"--profile", "config profile to use"},
+			})
+		}
+		return matching(current, commandCompletions(s.commands.visible()))
+	}
+
+	spec, ok := s.commands.list[words[0]]
+	if !ok || spec.Hidden {
+		return nil
+	}
+	words = words[1:]
+	for len(spec.Subcommands) > 0 {
+		if len(words) == 0 {
+			return matching(current, commandCompletions(spec.Subcommands))
+		}
+		if spec = spec.subcommand(words[0]); spec == nil {
+			return nil
+		}
+		words = words[1:]
+	}
+
+	// the rest are flags, their values and then positional arguments, as
+	// the flag package parses them
+	fs := spec.flagSet()
+	var args []string
+	flagsDone := false
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		if flagsDone || len(args) > 0 || !strings.HasPrefix(word, "-") || word == "-" {
+			args = append(args, word)
+			continue
+		}
+		if word == "--" {
+			flagsDone = true
+			continue
+		}
+		if takesValue(fs, word) {
+			if i == len(words)-1 {
+				// the cursor is on this flag's value
+				return nil
+			}
+			i++
+		}
+	}
+
+	if !flagsDone && len(args) == 0 && strings.HasPrefix(current, "-") {
+		var flags []completion
+		fs.VisitAll(func(f *flag.Flag) {
+			flags = append(flags, completion{"--" + f.Name, f.Usage})
+		})
+		return matching(current, flags)
+	}
+
+	if len(spec.Args) == 0 {
+		return nil
+	}
+	arg := spec.Args[min(len(args), len(spec.Args)-1)]
+	if len(args) >= len(spec.Args) && !arg.Variadic || arg.Complete == nil {
+		return nil
+	}
+	if s.db == nil && !spec.Offline {
+		if err := connect(s, opts); err != nil {
+			return nil
+		}
+		if s.conn != nil {
+			defer s.conn.Close()
+		}
+	}
+	values, err := arg.Complete(s, args)
+	if err != nil {
+		return nil
+	}
+	return matching(current, values)
+}
+
+// takesValue reports whether word is a flag that reads the next word as
+// its value, as opposed to a bool flag or --name=value.
+func takesValue(fs *flag.FlagSet, word string) bool {
+	name, _, hasValue := strings.Cut(strings.TrimLeft(word, "-"), "=")
+	f := fs.Lookup(name)
+	if f == nil || hasValue {
+		return false
+	}
+	if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+		return false
+	}
+	return true
+}
+
+func matching(prefix string, candidates []completion) []completion {
+	var out []completion
+	for _, c := range candidates {
+		if strings.HasPrefix(c.Value, prefix) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func commandCompletions(specs []*commandSpec) []completion {
+	var out []completion
+	for _, spec := range specs {
+		out = append(out, completion{spec.Name, spec.Summary})
+	}
+	return out
+}
+
+// completeValues offers a fixed set of values.
+func completeValues(values ...string) func(*state, []string) ([]completion, error) {
+	return func(*state, []string) ([]completion, error) {
+		var out []completion
+		for _, value := range values {
+			out = append(out, completion{Value: value})
+		}
+		return out, nil
+	}
+}
+
+// completeHelpTopics offers the commands, or the subcommands of the group
+// named so far.
+func completeHelpTopics(s *state, args []string) ([]completion, error) {
+	specs := s.commands.visible()
+	for _, name := range args {
+		var group *commandSpec
+		for _, spec := range specs {
+			if spec.Name == name {
+				group = spec
+			}
+		}
+		if group == nil {
+			return nil, nil
+		}
+		specs = group.Subcommands
+	}
+	return commandCompletions(specs), nil
+}
+
+func completeFeedURLs(s *state, args []string) ([]completion, error) {
+	feeds, err := s.db.GetFeeds(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	var out []completion
+	for _, feed := range feeds {
+		out = append(out, completion{feed.Url, feed.Name})
+	}
+	return out, nil
+}
+
+// completeFollowedFeedURLs offers the feeds the logged in user follows.
+func completeFollowedFeedURLs(s *state, args []string) ([]completion, error) {
+	ctx := context.Background()
+	user, err := userForSession(ctx, s, s.Config.SessionToken())
+	if err != nil {
+		return nil, err
+	}
+	feeds, err := s.db.GetFollowedFeeds(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	var out []completion
+	for _, feed := range feeds {
+		out = append(out, completion{feed.Url, feed.Name})
+	}
+	return out, nil
+}
+
+func completeUserNames(s *state, args []string) ([]completion, error) {
+	users, err := s.db.GetUsers(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	var out []completion
+	for _, user := range users {
+		out = append(out, completion{Value: user.Name})
+	}
+	return out, nil
+}
+
+var completionScripts = map[string]string{
+	"bash": bashCompletion,
+	"zsh":  zshCompletion,
+	"fish": fishCompletion,
+}
+
+// bash splits words at colons, which would break URLs, so the script
+// splits the line itself and strips what bash sees as earlier words from
+// the candidates.
+const bashCompletion = `# bash completion for gator
+_gator() {
+    local line=${COMP_LINE:0:COMP_POINT}
+    local -a words
+    read -ra words <<< "$line"
+    [[ $line == *[[:space:]] || ${#words[@]} -eq 0 ]] && words+=("")
+    local cur=${words[${#words[@]}-1]}
+
+    local IFS=$'\n'
+    COMPREPLY=($(gator __complete -- "${words[@]:1}" 2>/dev/null))
+    COMPREPLY=("${COMPREPLY[@]%%$'\t'*}")
+
+    if [[ $cur == *:* && $COMP_WORDBREAKS == *:* ]]; then
+        local colon_prefix=${cur%"${cur##*:}"}
+        local i=${#COMPREPLY[@]}
+        while ((i-- > 0)); do
+            COMPREPLY[i]=${COMPREPLY[i]#"$colon_prefix"}
+        done
+    fi
+}
+complete -o default -F _gator gator
+`
+
+const zshCompletion = `#compdef gator
+# zsh completion for gator
+_gator() {
+    local -a lines completions
+    local line value
+    lines=("${(@f)$(gator __complete -- "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    for line in $lines; do
+        [[ -n $line ]] || continue
+        value=${line%%$'\t'*}
+        value=${value//:/\\:}
+        if [[ $line == *$'\t'* ]]; then
+            completions+=("$value:${line#*$'\t'}")
+        else
+            completions+=("$value")
+        fi
+    done
+    if (( ${#completions} )); then
+        _describe -t gator gator completions
+    else
+        _files
+    fi
+}
+
+if [[ $funcstack[1] == _gator ]]; then
+    _gator "$@"
+else
+    compdef _gator gator
+fi
+`
+
+const fishCompletion = `# fish completion for gator
+function __gator_complete
+    set -l words (commandline -opc)
+    set -l current (commandline -ct)
+    gator __complete -- $words[2..-1] "$current" 2>/dev/null
+end
+complete -c gator -f -a '(__gator_complete)'
+`
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+	"github.com/pressly/goose/v3"
+	"github.com/richardteaman/gator/internal/database"
+	"github.com/richardteaman/gator/internal/database/sqlite"
+	"github.com/richardteaman/gator/internal/storage"
+	"github.com/richardteaman/gator/sql/schema"
+	sqliteschema "github.com/richardteaman/gator/sql/sqlite/schema"
+)
+
+// backend is the kind of database db_url points at. All of them run the
+// same queries through storage.Store; only the migrations differ.
+type backend struct {
+	name       string
+	dialect    goose.Dialect
+	migrations fs.FS
+	// store runs the queries inside tx.
+	store func(tx *sql.Tx) storage.Store
+}
+
+var (
+	postgresBackend = backend{
+		name:       "postgres",
+		dialect:    goose.DialectPostgres,
+		migrations: schema.FS,
+		store:      func(tx *sql.Tx) storage.Store { return database.New(tx) },
+	}
+	sqliteBackend = backend{
+		name:       "sqlite",
+		dialect:    goose.DialectSQLite3,
+		migrations: sqliteschema.FS,
+		store:      func(tx *sql.Tx) storage.Store { return sqlite.NewQuerier(tx) },
+	}
+	memoryBackend = backend{name: "memory"}
+)
+
+// inTx runs fn on a store whose queries all happen in one transaction,
+// committed if fn returns nil and rolled back otherwise. memory: has no
+// transactions, so there fn runs on the store itself.
+func inTx(ctx context.Context, s *state, fn func(db storage.Store) error) error {
+	if s.conn == nil {
+		return fn(s.db)
+	}
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer tx.Rollback()
+	if err := fn(s.backend.store(tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit: %w", err)
+	}
+	return nil
+}
+
+// openDatabase connects to db_url. postgres:// URLs go to a Postgres
+// server; sqlite: URLs name a local database file, which is created on
+// first use. memory: keeps everything in the process and has no
+// connection, so the returned *sql.DB is nil.
+func openDatabase(dbURL string) (*sql.DB, storage.Store, backend, error) {
+	if dbURL == "memory:" {
+		return nil, storage.NewMemory(), memoryBackend, nil
+	}
+	if path, ok := sqlitePath(dbURL); ok {
+		if path == "" {
+			return nil, nil, backend{}, kindErrorf(kindInvalidInput, "sqlite db_url has no file path, e.g. sqlite:///home/me/gator.db")
+		}
+		db, err := sqlite.Open(path)
+		if err != nil {
+			return nil, nil, backend{}, withKind(kindBackend, err)
+		}
+		return db, sqlite.NewQuerier(db), sqliteBackend, nil
+	}
+
+	scheme, _, _ := strings.Cut(dbURL, ":")
+	if scheme != "postgres" && scheme != "postgresql" {
+		return nil, nil, backend{}, kindErrorf(kindInvalidInput, "unsupported db_url %q, expected postgres://, sqlite: or memory:", redactURL(dbURL))
+	}
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		return nil, nil, backend{}, withKind(kindBackend, err)
+	}
+	return db, database.New(db), postgresBackend, nil
+}
+
+// sqlitePath extracts the file from sqlite:path, sqlite://relative/path or
+// sqlite:///absolute/path. A leading ~/ is the home directory.
+func sqlitePath(dbURL string) (string, bool) {
+	var path string
+	var ok bool
+	for _, scheme := range []string{"sqlite:", "sqlite3:"} {
+		if path, ok = strings.CutPrefix(dbURL, scheme); ok {
+			break
+		}
+	}
+	if !ok {
+		return "", false
+	}
+	path = strings.TrimPrefix(path, "//")
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, rest)
+		}
+	}
+	return path, true
+}
+
+// pqUniqueViolation is the Postgres error code for a duplicate key.
+const pqUniqueViolation = "23505"
+
+// isUniqueViolation reports whether err is a unique or primary key
+// constraint failing, whichever backend raised it.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == pqUniqueViolation
+	}
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique ||
+			sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey
+	}
+	return errors.Is(err, storage.ErrUniqueViolation)
+}
+
+// isBackendError reports whether err came from the database rather than
+// from what the user asked for: errors raised by the server or library,
+// and connections that could not be made or dropped.
+func isBackendError(err error) bool {
+	var pqErr *pq.Error
+	var sqliteErr sqlite3.Error
+	var opErr *net.OpError
+	return errors.As(err, &pqErr) ||
+		errors.As(err, &sqliteErr) ||
+		errors.As(err, &opErr) && opErr.Op != "listen" ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, storage.ErrForeignKeyViolation) ||
+		errors.Is(err, storage.ErrCheckViolation)
+}
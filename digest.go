@@ -0,0 +1,340 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"html/template"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richardteaman/gator/internal/config"
+	"github.com/richardteaman/gator/internal/database"
+)
+
+const (
+	defaultDigestPeriod = 24 * time.Hour
+	defaultDigestSize   = 200
+	digestSummaryLength = 280
+	defaultSMTPPort     = 25
+)
+
+// digest is a user's unread posts since their previous digest, grouped by feed.
+type digest struct {
+	User      string
+	Since     time.Time
+	Generated time.Time
+	Total     int
+	Feeds     []digestFeed
+}
+
+type digestFeed struct {
+	Name  string
+	URL   string
+	Posts []digestPost
+}
+
+type digestPost struct {
+	Title   string
+	URL     string
+	Summary string
+	Date    time.Time
+}
+
+var digestCommand = &commandSpec{
+	Name:    "digest",
+	Summary: "emails your unread posts since the previous digest",
+	Details: "The first digest covers the last 24h. Posts are grouped by feed in an HTML and plain-text message.",
+	Flags: []flagSpec{
+		stringFlag("to", "", "recipient address, defaults to smtp.to from the config"),
+		stringFlag("eml", "", "write the message to this .eml file instead of sending it"),
+		durationFlag("since", 0, "include posts from this far back instead of since the last digest"),
+		intFlag("limit", defaultDigestSize, "maximum number of posts to include"),
+	},
+	Handler: middlewareLoggedIn(handlerDigest),
+}
+
+func handlerDigest(s *state, cmd command, user database.User) error {
+	to := cmd.String("to")
+	eml := cmd.String("eml")
+	since := cmd.Duration("since")
+
+	smtpCfg := s.Config.SMTP
+	if smtpCfg == nil {
+		smtpCfg = &config.SMTPConfig{}
+	}
+	if to == "" {
+		to = smtpCfg.To
+	}
+	if eml == "" && (smtpCfg.Host == "" || to == "") {
+		return kindErrorf(kindInvalidInput, "digest needs smtp.host and a recipient in the config (or --to), or --eml to write a file")
+	}
+
+	now := time.Now()
+	start := now.Add(-defaultDigestPeriod)
+	if since > 0 {
+		start = now.Add(-since)
+	} else if user.LastDigestAt.Valid {
+		start = user.LastDigestAt.Time
+	}
+
+	// posts come oldest first and one more than fits is asked for, so a
+	// digest cut short by --limit knows to leave the rest for the next one
+	limit := cmd.Int("limit")
+	ctx := context.Background()
+	rows, err := s.db.GetDigestPosts(ctx, database.GetDigestPostsParams{
+		UserID:   user.ID,
+		Since:    start,
+		MaxPosts: int32(limit + 1),
+	})
+	if err != nil {
+		return fmt.Errorf("could not fetch digest posts: %w", err)
+	}
+	if len(rows) == 0 {
+		fmt.Fprintf(s.out, "Nothing new since %s\n", start.Format(time.RFC1123))
+		return nil
+	}
+	more := len(rows) > limit
+	if more {
+		rows = rows[:limit]
+	}
+
+	d := buildDigest(user.Name, start, now, rows)
+
+	from := smtpCfg.From
+	if from == "" {
+		from = "gator@localhost"
+	}
+	recipient := to
+	if recipient == "" {
+		recipient = user.Name + "@localhost"
+	}
+
+	msg, err := composeDigest(d, from, recipient)
+	if err != nil {
+		return fmt.Errorf("could not compose digest: %w", err)
+	}
+
+	if eml != "" {
+		if err := os.WriteFile(eml, msg, 0600); err != nil {
+			return fmt.Errorf("could not write digest: %w", err)
+		}
+		fmt.Fprintf(s.out, "Digest with %d posts written to %s\n", d.Total, eml)
+	} else {
+		if err := sendMail(smtpCfg, from, recipient, msg); err != nil {
+			return fmt.Errorf("could not send digest: %w", err)
+		}
+		fmt.Fprintf(s.out, "Digest with %d posts sent to %s\n", d.Total, recipient)
+	}
+	if more {
+		fmt.Fprintln(s.out, "More unread posts are left for the next digest")
+	}
+
+	// the next digest starts after the newest post sent rather than now,
+	// so posts past --limit aren't skipped
+	err = s.db.SetLastDigestAt(ctx, database.SetLastDigestAtParams{
+		ID:           user.ID,
+		LastDigestAt: sql.NullTime{Time: rows[len(rows)-1].CreatedAt, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("could not record digest time: %w", err)
+	}
+	return nil
+}
+
+// buildDigest groups rows into feeds ordered by name, newest posts first.
+func buildDigest(username string, since, now time.Time, rows []database.GetDigestPostsRow) digest {
+	d := digest{User: username, Since: since, Generated: now, Total: len(rows)}
+
+	rows = slices.Clone(rows)
+	slices.SortStableFunc(rows, func(a, b database.GetDigestPostsRow) int {
+		if c := cmp.Or(strings.Compare(a.FeedName, b.FeedName), strings.Compare(a.FeedUrl, b.FeedUrl)); c != 0 {
+			return c
+		}
+		if a.PublishedAt.Valid != b.PublishedAt.Valid {
+			if a.PublishedAt.Valid {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Or(b.PublishedAt.Time.Compare(a.PublishedAt.Time), b.CreatedAt.Compare(a.CreatedAt))
+	})
+	for _, row := range rows {
+		if len(d.Feeds) == 0 || d.Feeds[len(d.Feeds)-1].URL != row.FeedUrl {
+			d.Feeds = append(d.Feeds, digestFeed{Name: row.FeedName, URL: row.FeedUrl})
+		}
+		feed := &d.Feeds[len(d.Feeds)-1]
+
+		title := row.Title.String
+		if title == "" {
+			title = row.Url
+		}
+		date := row.CreatedAt
+		if row.PublishedAt.Valid {
+			date = row.PublishedAt.Time
+		}
+		feed.Posts = append(feed.Posts, digestPost{
+			Title:   title,
+			URL:     row.Url,
+			Summary: summarize(stripHTML(row.Description.String), digestSummaryLength),
+			Date:    date,
+		})
+	}
+	return d
+}
+
+// summarize collapses whitespace and cuts text to at most n runes, breaking
+// on a word boundary where possible.
+func summarize(text string, n int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	cut := string(runes[:n])
+	if i := strings.LastIndexByte(cut, ' '); i > n/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " .,;:") + "…"
+}
+
+func (d digest) subject() string {
+	return fmt.Sprintf("gator digest: %d new posts from %d feeds", d.Total, len(d.Feeds))
+}
+
+func renderDigestText(w io.Writer, d digest) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Unread posts for %s since %s\n", d.User, d.Since.Format(time.RFC1123))
+
+	for _, feed := range d.Feeds {
+		fmt.Fprintf(&b, "\n%s\n%s\n", feed.Name, strings.Repeat("=", len([]rune(feed.Name))))
+		for _, post := range feed.Posts {
+			fmt.Fprintf(&b, "\n* %s\n  %s\n  %s\n", post.Title, post.URL, post.Date.Format("2006-01-02 15:04"))
+			if post.Summary != "" {
+				for _, line := range wrapWords(strings.Fields(post.Summary), 70) {
+					fmt.Fprintf(&b, "  %s\n", line)
+				}
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var digestHTML = template.Must(template.New("digest").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Subject}}</title></head>
+<body style="font-family: sans-serif; max-width: 40em;">
+<p>Unread posts for <strong>{{.User}}</strong> since {{.Since.Format "Mon, 02 Jan 2006 15:04"}}</p>
+{{range .Feeds}}
+<h2 style="border-bottom: 1px solid #ccc;"><a href="{{.URL}}">{{.Name}}</a></h2>
+{{range .Posts}}
+<div style="margin-bottom: 1em;">
+<a href="{{.URL}}"><strong>{{.Title}}</strong></a>
+<div style="color: #666; font-size: small;">{{.Date.Format "2006-01-02 15:04"}}</div>
+{{if .Summary}}<p style="margin: 0.3em 0;">{{.Summary}}</p>{{end}}
+</div>
+{{end}}
+{{end}}
+</body>
+</html>
+`))
+
+func renderDigestHTML(w io.Writer, d digest) error {
+	return digestHTML.Execute(w, struct {
+		digest
+		Subject string
+	}{d, d.subject()})
+}
+
+// composeDigest builds a multipart/alternative message carrying both the
+// plain-text and HTML renderings of d.
+func composeDigest(d digest, from, to string) ([]byte, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+
+	parts := []struct {
+		contentType string
+		render      func(io.Writer, digest) error
+	}{
+		{"text/plain; charset=utf-8", renderDigestText},
+		{"text/html; charset=utf-8", renderDigestHTML},
+	}
+	for _, part := range parts {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", part.contentType)
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+		pw, err := mw.CreatePart(header)
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(pw)
+		if err := part.render(qp, d); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	headers := []struct{ key, value string }{
+		{"From", from},
+		{"To", to},
+		{"Subject", mime.QEncoding.Encode("utf-8", d.subject())},
+		{"Date", d.Generated.Format(time.RFC1123Z)},
+		{"Message-ID", "<" + uuid.NewString() + "@gator>"},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=" + strconv.Quote(mw.Boundary())},
+	}
+	for _, h := range headers {
+		fmt.Fprintf(&msg, "%s: %s\r\n", h.key, h.value)
+	}
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+// sendMail delivers msg through the configured server. Authentication is
+// only attempted when a user name is set, which keeps local SMTP sinks
+// working without credentials.
+func sendMail(cfg *config.SMTPConfig, from, to string, msg []byte) error {
+	port := cfg.Port
+	if port == 0 {
+		port = defaultSMTPPort
+	}
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(port))
+
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return kindErrorf(kindInvalidInput, "invalid from address %q: %w", from, err)
+	}
+	recipient, err := mail.ParseAddress(to)
+	if err != nil {
+		return kindErrorf(kindInvalidInput, "invalid recipient %q: %w", to, err)
+	}
+
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	return smtp.SendMail(addr, auth, sender.Address, []string{recipient.Address}, msg)
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// digestParts parses msg as composeDigest writes it and returns its
+// headers and the decoded bodies of its parts by content type.
+func digestParts(t *testing.T, msg []byte) (mail.Header, map[string]string) {
+	t.Helper()
+	m, err := mail.ReadMessage(bytes.NewReader(msg))
+	if err != nil {
+		t.Fatalf("not a valid message: %v\n%s", err, msg)
+	}
+	mediaType, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type %q: %v", m.Header.Get("Content-Type"), err)
+	}
+
+	parts := map[string]string{}
+	mr := multipart.NewReader(m.Body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the reader undoes the quoted-printable encoding, which leaves
+		// CRLF line endings
+		body, err := io.ReadAll(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[p.Header.Get("Content-Type")] = strings.ReplaceAll(string(body), "\r\n", "\n")
+	}
+	return m.Header, parts
+}
+
+func TestComposeDigest(t *testing.T) {
+	since := time.Date(2026, 10, 4, 8, 0, 0, 0, time.UTC)
+	long := strings.Repeat("Gophers dig tunnels all day long. ", 12)
+	d := digest{
+		User:      "alice",
+		Since:     since,
+		Generated: since.Add(24 * time.Hour),
+		Total:     2,
+		Feeds: []digestFeed{
+			{Name: "Bücher", URL: "https://xn--bcher-kva.example/rss", Posts: []digestPost{
+				{Title: "<script>alert(1)</script> & more", URL: "https://xn--bcher-kva.example/1", Summary: summarize(long, digestSummaryLength), Date: since.Add(time.Hour)},
+			}},
+			{Name: "Go", URL: "https://go.dev/blog/feed.atom", Posts: []digestPost{
+				{Title: "Go 1.30", URL: "https://go.dev/blog/go1.30", Date: since.Add(2 * time.Hour)},
+			}},
+		},
+	}
+
+	msg, err := composeDigest(d, "gator@example.com", "alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, body, _ := strings.Cut(string(msg), "\r\n\r\n")
+	for _, line := range strings.Split(body, "\r\n") {
+		if len(line) > 76 {
+			t.Errorf("body line longer than quoted-printable allows: %q", line)
+		}
+	}
+
+	header, parts := digestParts(t, msg)
+	subject, err := new(mime.WordDecoder).DecodeHeader(header.Get("Subject"))
+	if err != nil || subject != "gator digest: 2 new posts from 2 feeds" {
+		t.Errorf("Subject %q (%v)", subject, err)
+	}
+	if header.Get("From") != "gator@example.com" || header.Get("To") != "alice@example.com" || header.Get("MIME-Version") != "1.0" {
+		t.Errorf("headers %v", header)
+	}
+	if date, err := header.Date(); err != nil || !date.Equal(d.Generated) {
+		t.Errorf("Date %q, want %v", header.Get("Date"), d.Generated)
+	}
+	if len(parts) != 2 {
+		t.Fatalf("parts %v, want text and HTML", parts)
+	}
+
+	text := parts["text/plain; charset=utf-8"]
+	for _, want := range []string{
+		"Unread posts for alice since Sun, 04 Oct 2026 08:00:00 UTC\n",
+		"\nBücher\n======\n",
+		"\n* <script>alert(1)</script> & more\n  https://xn--bcher-kva.example/1\n  2026-10-04 09:00\n  Gophers dig",
+		"\nGo\n==\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text part lacks %q:\n%s", want, text)
+		}
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if len([]rune(line)) > 72 {
+			t.Errorf("text line not wrapped: %q", line)
+		}
+	}
+
+	html := parts["text/html; charset=utf-8"]
+	if strings.Contains(html, "<script>") || !strings.Contains(html, "&lt;script&gt;alert(1)&lt;/script&gt; &amp; more") {
+		t.Errorf("HTML part doesn't escape the post title:\n%s", html)
+	}
+	if !strings.Contains(html, `<a href="https://go.dev/blog/go1.30"><strong>Go 1.30</strong></a>`) {
+		t.Errorf("HTML part lacks the link to the post:\n%s", html)
+	}
+	if strings.Index(html, "Bücher") > strings.Index(html, ">Go<") {
+		t.Errorf("feeds out of order in the HTML part:\n%s", html)
+	}
+}
+
+func TestDigestLimit(t *testing.T) {
+	for name, dbURL := range map[string]string{"memory": "memory:", "sqlite": "sqlite://{dir}/gator.db"} {
+		t.Run(name, func(t *testing.T) {
+			ts := newSession(t, dbURL)
+			if dbURL != "memory:" {
+				ts.run("migrate up", "")
+			}
+			ts.registerUser("alice")
+			ts.run(`addfeed "Test Feed" `+feedServer(t), "")
+			ts.call("fetch the feed", scrapeFeeds)
+
+			eml := filepath.Join(t.TempDir(), "digest.eml")
+			digestText := func() string {
+				t.Helper()
+				msg, err := os.ReadFile(eml)
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, parts := digestParts(t, msg)
+				return parts["text/plain; charset=utf-8"]
+			}
+
+			// the posts past the limit come in the next digest, oldest first
+			ts.run("digest --limit 1 --eml "+eml, "")
+			if text := digestText(); !strings.Contains(text, "Hello gophers") || strings.Contains(text, "Sponsored") {
+				t.Errorf("first digest:\n%s", text)
+			}
+			ts.run("digest --limit 1 --eml "+eml, "")
+			if text := digestText(); strings.Contains(text, "Hello gophers") || !strings.Contains(text, "Sponsored") {
+				t.Errorf("second digest:\n%s", text)
+			}
+			os.Remove(eml)
+			ts.run("digest --limit 1 --eml "+eml, "")
+			if _, err := os.Stat(eml); err == nil {
+				t.Errorf("third digest written with nothing left to send")
+			}
+		})
+	}
+}
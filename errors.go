@@ -0,0 +1,124 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// errorKind says why a command failed. Each kind exits with its own code,
+// so scripts can tell a missing feed from a database that is down; the
+// codes are listed in the README.
+type errorKind int
+
+const (
+	kindNotFound errorKind = iota + 1
+	kindAlreadyExists
+	kindInvalidInput
+	kindUnauthenticated
+	kindBackend
+)
+
+// Exit codes. Anything that isn't one of the kinds above exits with
+// exitFailure.
+const (
+	exitFailure         = 1
+	exitInvalidInput    = 2
+	exitNotFound        = 3
+	exitAlreadyExists   = 4
+	exitUnauthenticated = 5
+	exitBackend         = 6
+)
+
+// Error lets a kind be the target of errors.Is.
+func (k errorKind) Error() string {
+	switch k {
+	case kindNotFound:
+		return "not found"
+	case kindAlreadyExists:
+		return "already exists"
+	case kindInvalidInput:
+		return "invalid input"
+	case kindUnauthenticated:
+		return "unauthenticated"
+	case kindBackend:
+		return "backend failure"
+	}
+	return "failure"
+}
+
+func (k errorKind) exitCode() int {
+	switch k {
+	case kindNotFound:
+		return exitNotFound
+	case kindAlreadyExists:
+		return exitAlreadyExists
+	case kindInvalidInput:
+		return exitInvalidInput
+	case kindUnauthenticated:
+		return exitUnauthenticated
+	case kindBackend:
+		return exitBackend
+	}
+	return exitFailure
+}
+
+// kindError marks err with a kind without changing its message.
+type kindError struct {
+	kind errorKind
+	err  error
+}
+
+func (e *kindError) Error() string { return e.err.Error() }
+func (e *kindError) Unwrap() error { return e.err }
+
+func (e *kindError) Is(target error) bool {
+	kind, ok := target.(errorKind)
+	return ok && kind == e.kind
+}
+
+func withKind(kind errorKind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &kindError{kind: kind, err: err}
+}
+
+func kindErrorf(kind errorKind, format string, args ...any) error {
+	return withKind(kind, fmt.Errorf(format, args...))
+}
+
+// kindOf finds out why err happened. A kind set with withKind wins, the
+// outermost if there are several; otherwise it is worked out from what is
+// wrapped: usage errors, rows that were not there, unique constraints and
+// errors from the database itself. ok is false for anything else.
+func kindOf(err error) (kind errorKind, ok bool) {
+	var ke *kindError
+	var ue *usageError
+	switch {
+	case err == nil:
+		return 0, false
+	case errors.As(err, &ke):
+		return ke.kind, true
+	case errors.As(err, &ue):
+		return kindInvalidInput, true
+	case errors.Is(err, sql.ErrNoRows):
+		return kindNotFound, true
+	case isUniqueViolation(err):
+		return kindAlreadyExists, true
+	case isBackendError(err):
+		return kindBackend, true
+	}
+	return 0, false
+}
+
+// exitCode is what gator exits with after a command returned err.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if kind, ok := kindOf(err); ok {
+		return kind.exitCode()
+	}
+	return exitFailure
+}
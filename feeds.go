@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/richardteaman/gator/internal/database"
+	"github.com/richardteaman/gator/internal/storage"
+)
+
+var errFeedOwnerRequired = kindErrorf(kindUnauthenticated, "only the user who added a feed or an admin can change it")
+
+var feedCommand = &commandSpec{
+	Name:    "feed",
+	Summary: "manages feeds",
+	Subcommands: []*commandSpec{
+		{
+			Name:    "rename",
+			Summary: "changes the name a feed is shown with",
+			Args:    []argSpec{{Name: "url", Complete: completeFeedURLs}, {Name: "name"}},
+			Handler: middlewareLoggedIn(handlerFeedRename),
+		},
+		{
+			Name:    "set-url",
+			Summary: "moves a feed to a new URL, merging it into a feed already there",
+			Details: `Use this when a feed has moved. It is fetched from the new URL on the
+next agg, and adding or following the old URL finds it. If another feed
+already has the new URL, the feed is merged into it instead, as feed
+merge does.`,
+			Args: []argSpec{{Name: "url", Complete: completeFeedURLs}, {Name: "new-url"}},
+			Flags: []flagSpec{
+				boolFlag("yes", "do not ask for confirmation before merging"),
+			},
+			Handler: middlewareLoggedIn(handlerFeedSetURL),
+		},
+		{
+			Name:    "merge",
+			Summary: "merges a duplicate feed into another one",
+			Details: `Moves the follows, posts, rules and webhooks of the first feed to the
+second and deletes the first. Posts the second feed already has, with the
+same title and publication time, are dropped, keeping who read or
+starred them. Adding or following the first URL afterwards finds the
+second feed.`,
+			Args: []argSpec{
+				{Name: "from", Complete: completeFeedURLs},
+				{Name: "into", Complete: completeFeedURLs},
+			},
+			Flags:   []flagSpec{boolFlag("yes", "do not ask for confirmation")},
+			Handler: middlewareLoggedIn(handlerFeedMerge),
+		},
+		{
+			Name:    "delete",
+			Summary: "deletes a feed with its posts and follows",
+			Args:    []argSpec{{Name: "url", Complete: completeFeedURLs}},
+			Flags:   []flagSpec{boolFlag("yes", "do not ask for confirmation")},
+			Handler: middlewareLoggedIn(handlerFeedDelete),
+		},
+	},
+}
+
+// lookupFeed finds the feed at url, comparing canonical URLs. A feed
+// stored with exactly url comes first: feeds that duplicated an older one
+// when canonical URLs came in have no canonical URL until they are
+// merged, and can only be told apart from that one by their own.
+func lookupFeed(ctx context.Context, db storage.Store, url string) (database.Feed, error) {
+	feed, err := db.GetFeedByURL(ctx, url)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return feed, err
+	}
+	return db.GetFeedByCanonicalURL(ctx, feedURLKey(url))
+}
+
+// feedByURL finds the feed at url, or the one it was merged into or moved
+// to.
+func feedByURL(ctx context.Context, db storage.Store, url string) (database.Feed, error) {
+	feed, err := lookupFeed(ctx, db, url)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return feed, err
+	}
+	redirect, redirectErr := db.GetFeedRedirect(ctx, feedURLKey(url))
+	if errors.Is(redirectErr, sql.ErrNoRows) {
+		return feed, err
+	}
+	if redirectErr != nil {
+		return database.Feed{}, redirectErr
+	}
+	return db.GetFeedByID(ctx, redirect.FeedID)
+}
+
+// ownedFeed looks up the feed at url and checks that user may change it:
+// they added it or they are an admin.
+func ownedFeed(ctx context.Context, s *state, user database.User, url string) (database.Feed, error) {
+	feed, err := lookupFeed(ctx, s.db, url)
+	if err != nil {
+		return database.Feed{}, fmt.Errorf("feed %s not found: %w", url, err)
+	}
+	if !mayChangeFeed(user, feed) {
+		return database.Feed{}, errFeedOwnerRequired
+	}
+	return feed, nil
+}
+
+// mayChangeFeed reports whether user added feed or is an admin.
+func mayChangeFeed(user database.User, feed database.Feed) bool {
+	return feed.UserID == user.ID || user.Role == roleAdmin
+}
+
+func handlerFeedRename(s *state, cmd command, user database.User) error {
+	name := strings.TrimSpace(cmd.Args[1])
+	if name == "" {
+		return cmd.usageErrorf("feed name can't be empty")
+	}
+
+	ctx := context.Background()
+	feed, err := ownedFeed(ctx, s, user, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	renamed, err := s.db.RenameFeed(ctx, database.RenameFeedParams{ID: feed.ID, Name: name})
+	if err != nil {
+		return fmt.Errorf("could not rename feed: %w", err)
+	}
+	fmt.Fprintf(s.out, "Feed %s renamed to %s\n", feed.Name, renamed.Name)
+	return nil
+}
+
+func handlerFeedSetURL(s *state, cmd command, user database.User) error {
+	newURL, err := normalizeFeedURL(cmd.Args[1])
+	if err != nil {
+		return cmd.usageErrorf("%v", err)
+	}
+	canonical := feedURLKey(newURL)
+
+	ctx := context.Background()
+	feed, err := ownedFeed(ctx, s, user, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	if feed.Url == newURL {
+		fmt.Fprintf(s.out, "Feed %s is already at %s\n", feed.Name, newURL)
+		return nil
+	}
+
+	existing, err := feedByURL(ctx, s.db, newURL)
+	if err == nil && existing.ID != feed.ID {
+		// merging moves the feed's posts, rules and webhooks into existing
+		if !mayChangeFeed(user, existing) {
+			return errFeedOwnerRequired
+		}
+		if !confirm(s, fmt.Sprintf("%s is already the URL of %s. Merge %s into it?", newURL, existing.Name, feed.Name), cmd.Bool("yes")) {
+			fmt.Fprintln(s.out, "Aborted")
+			return nil
+		}
+		return mergeFeeds(ctx, s, feed, existing)
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("could not look up feed: %w", err)
+	}
+
+	err = inTx(ctx, s, func(db storage.Store) error {
+		// the feed may be moving back to a URL it had before
+		if err := db.DeleteFeedRedirect(ctx, canonical); err != nil {
+			return fmt.Errorf("could not drop redirect: %w", err)
+		}
+		if _, err := db.SetFeedURL(ctx, database.SetFeedURLParams{
+			ID:           feed.ID,
+			Url:          newURL,
+			CanonicalUrl: sql.NullString{String: canonical, Valid: true},
+		}); err != nil {
+			return fmt.Errorf("could not change feed URL: %w", err)
+		}
+		if old := feedURLKey(feed.Url); old != canonical {
+			if err := db.CreateFeedRedirect(ctx, database.CreateFeedRedirectParams{
+				Url:       old,
+				CreatedAt: time.Now(),
+				FeedID:    feed.ID,
+			}); err != nil {
+				return fmt.Errorf("could not redirect old URL: %w", err)
+			}
+		}
+		// the hub pushed the old URL; agg subscribes again for the new one
+		if err := db.DeleteWebSubSubscriptionForFeed(ctx, feed.ID); err != nil {
+			return fmt.Errorf("could not drop WebSub subscription: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(s.out, "Feed %s moved to %s\n", feed.Name, newURL)
+	return nil
+}
+
+func handlerFeedMerge(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+	from, err := ownedFeed(ctx, s, user, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	into, err := feedByURL(ctx, s.db, cmd.Args[1])
+	if err != nil {
+		return fmt.Errorf("feed %s not found: %w", cmd.Args[1], err)
+	}
+	// into takes over from's posts, rules and webhooks, so it has to be
+	// the user's as well
+	if !mayChangeFeed(user, into) {
+		return errFeedOwnerRequired
+	}
+	if into.ID == from.ID {
+		return cmd.usageErrorf("can't merge %s into itself", from.Name)
+	}
+
+	if !confirm(s, fmt.Sprintf("Merge %s into %s and delete it?", from.Name, into.Name), cmd.Bool("yes")) {
+		fmt.Fprintln(s.out, "Aborted")
+		return nil
+	}
+	return mergeFeeds(ctx, s, from, into)
+}
+
+// mergeFeeds moves everything that hangs off from over to into, deletes
+// from and leaves its URL pointing at into, all in one transaction.
+// Follows of users who already follow into go with the feed, and so do
+// posts into already has, after their reads and stars are moved to
+// into's copy.
+func mergeFeeds(ctx context.Context, s *state, from, into database.Feed) error {
+	var follows, posts, duplicates int64
+	err := inTx(ctx, s, func(db storage.Store) error {
+		move := database.MoveFeedFollowsParams{FromFeedID: from.ID, IntoFeedID: into.ID}
+		dups, err := db.GetDuplicatePosts(ctx, database.GetDuplicatePostsParams(move))
+		if err != nil {
+			return fmt.Errorf("could not look for duplicate posts: %w", err)
+		}
+		for _, dup := range dups {
+			movePost := database.MovePostReadsParams{FromPostID: dup.FromPostID, IntoPostID: dup.IntoPostID}
+			if err := db.MovePostReads(ctx, movePost); err != nil {
+				return fmt.Errorf("could not move reads: %w", err)
+			}
+			if err := db.MovePostStars(ctx, database.MovePostStarsParams(movePost)); err != nil {
+				return fmt.Errorf("could not move stars: %w", err)
+			}
+			if err := db.DeletePost(ctx, dup.FromPostID); err != nil {
+				return fmt.Errorf("could not delete duplicate post: %w", err)
+			}
+		}
+		duplicates = int64(len(dups))
+
+		if follows, err = db.MoveFeedFollows(ctx, move); err != nil {
+			return fmt.Errorf("could not move follows: %w", err)
+		}
+		if posts, err = db.MoveFeedPosts(ctx, database.MoveFeedPostsParams(move)); err != nil {
+			return fmt.Errorf("could not move posts: %w", err)
+		}
+		if _, err := db.MoveFeedPostRules(ctx, database.MoveFeedPostRulesParams(move)); err != nil {
+			return fmt.Errorf("could not move rules: %w", err)
+		}
+		if _, err := db.MoveFeedWebhooks(ctx, database.MoveFeedWebhooksParams(move)); err != nil {
+			return fmt.Errorf("could not move webhooks: %w", err)
+		}
+		if _, err := db.MoveFeedRedirects(ctx, database.MoveFeedRedirectsParams(move)); err != nil {
+			return fmt.Errorf("could not move redirects: %w", err)
+		}
+		if _, err := db.DeleteFeed(ctx, from.ID); err != nil {
+			return fmt.Errorf("could not delete feed: %w", err)
+		}
+		// lookups already find into by a URL that canonicalizes to its own
+		if old := feedURLKey(from.Url); old != feedURLKey(into.Url) {
+			if err := db.CreateFeedRedirect(ctx, database.CreateFeedRedirectParams{
+				Url:       old,
+				CreatedAt: time.Now(),
+				FeedID:    into.ID,
+			}); err != nil {
+				return fmt.Errorf("could not redirect %s: %w", from.Url, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(s.out, "Feed %s merged into %s: moved %d follow(s) and %d post(s), dropped %d duplicate post(s)\n",
+		from.Name, into.Name, follows, posts, duplicates)
+	return nil
+}
+
+func handlerFeedDelete(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+	feed, err := ownedFeed(ctx, s, user, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+
+	if !confirm(s, fmt.Sprintf("Delete %s with all its posts and follows?", feed.Name), cmd.Bool("yes")) {
+		fmt.Fprintln(s.out, "Aborted")
+		return nil
+	}
+
+	if _, err := s.db.DeleteFeed(ctx, feed.ID); err != nil {
+		return fmt.Errorf("could not delete feed: %w", err)
+	}
+	fmt.Fprintf(s.out, "Feed %s deleted\n", feed.Name)
+	return nil
+}
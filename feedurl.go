@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pressly/goose/v3"
+	"golang.org/x/net/idna"
+)
+
+// feedHosts maps host names the way a lookup would, but lets through
+// the underscores some hosts have, and rejects empty or overlong labels.
+var feedHosts = idna.New(
+	idna.MapForLookup(),
+	idna.StrictDomainName(false),
+	idna.VerifyDNSLength(true),
+	idna.BidiRule(),
+)
+
+// parseFeedURL checks that raw is an http or https URL and brings it to
+// the form gator stores: scheme and host lowercased, international
+// domain names in their ASCII form, no trailing dot on the host, no
+// default port and no fragment. None of that changes what a server is
+// asked for.
+func parseFeedURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid feed URL %q, expected an http or https URL", raw)
+	}
+
+	host := u.Hostname()
+	if !strings.Contains(host, ":") {
+		// IPv6 addresses are left alone
+		host, err = feedHosts.ToASCII(strings.TrimSuffix(host, "."))
+		if err == nil && host == "" {
+			err = fmt.Errorf("no host name")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid host in feed URL %q: %w", raw, err)
+		}
+	}
+	host = strings.ToLower(host)
+	port := u.Port()
+	if u.Scheme == "http" && port == "80" || u.Scheme == "https" && port == "443" {
+		port = ""
+	}
+	if port != "" {
+		u.Host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		u.Host = "[" + host + "]"
+	} else {
+		u.Host = host
+	}
+
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u, nil
+}
+
+// normalizeFeedURL is the URL gator stores for raw.
+func normalizeFeedURL(raw string) (string, error) {
+	u, err := parseFeedURL(raw)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+// canonicalFeedURL is what feed URLs are compared by: the stored form
+// with an https scheme and without trailing slashes, neither of which
+// hardly any site serves different feeds for. Two URLs with the same
+// canonical form are the same feed.
+func canonicalFeedURL(raw string) (string, error) {
+	u, err := parseFeedURL(raw)
+	if err != nil {
+		return "", err
+	}
+	// an explicit port is kept, as http on 443 is not the https server
+	u.Scheme = "https"
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = strings.TrimRight(u.RawPath, "/")
+	return u.String(), nil
+}
+
+// feedURLKey is the canonical form of url, or url itself for the odd
+// stored URL that doesn't parse.
+func feedURLKey(url string) string {
+	if canonical, err := canonicalFeedURL(url); err == nil {
+		return canonical
+	}
+	return url
+}
+
+// canonicalURLsVersion is the migration that works out the canonical
+// URLs of feeds added before gator kept them, and canonicalSchemeVersion
+// the one that works them out again once they ignored the scheme.
+const (
+	canonicalURLsVersion   = 17
+	canonicalSchemeVersion = 20
+)
+
+// canonicalURLsMigration fills in feeds.canonical_url, added empty by
+// migration 16, and brings the URLs of redirects to the same form. It
+// starts over from no canonical URLs, so it can run again at version
+// whenever their form changes, listing only feeds that newly turn out to
+// be duplicates.
+//
+// Feeds added before URLs were normalized, or by another scheme, may
+// turn out to be the same feed. The oldest keeps the canonical URL; the
+// others are reported on out, with the feed merge command that folds
+// them into it, so they can be merged by hand. Until then they are found
+// by their URL as stored. Failing the migration instead would leave the
+// schema too old for gator to run the merge.
+func canonicalURLsMigration(s *state, version int64) *goose.Migration {
+	up := func(ctx context.Context, tx *sql.Tx) error {
+		param := func(n int) string {
+			if s.backend.dialect == goose.DialectPostgres {
+				return fmt.Sprintf("$%d", n)
+			}
+			return "?"
+		}
+		type feed struct {
+			id, name, url string
+			canonical     sql.NullString
+		}
+		var feeds []feed
+		rows, err := tx.QueryContext(ctx, "select id, name, url, canonical_url from feeds order by created_at, serial_id")
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var f feed
+			if err := rows.Scan(&f.id, &f.name, &f.url, &f.canonical); err != nil {
+				rows.Close()
+				return err
+			}
+			feeds = append(feeds, f)
+		}
+		if err := rows.Close(); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "update feeds set canonical_url = null"); err != nil {
+			return err
+		}
+
+		claimed := map[string]feed{}
+		var duplicates []string
+		for _, f := range feeds {
+			canonical := feedURLKey(f.url)
+			if first, ok := claimed[canonical]; ok {
+				// a feed left without a canonical URL by an earlier run was
+				// listed then
+				if version != canonicalURLsVersion && !f.canonical.Valid {
+					continue
+				}
+				duplicates = append(duplicates, fmt.Sprintf("  %s is the same feed as %s:\n    gator feed merge '%s' '%s'", f.name, first.name, f.url, first.url))
+				continue
+			}
+			claimed[canonical] = f
+			if _, err := tx.ExecContext(ctx, "update feeds set canonical_url = "+param(1)+" where id = "+param(2), canonical, f.id); err != nil {
+				return fmt.Errorf("could not set canonical URL of %s: %w", f.url, err)
+			}
+		}
+
+		// redirects are looked up by canonical URL too. the newest of those
+		// that end up the same wins, and none may shadow a feed
+		type redirect struct {
+			url       string
+			createdAt time.Time
+			feedID    string
+		}
+		var redirects []redirect
+		rows, err = tx.QueryContext(ctx, "select url, created_at, feed_id from feed_redirects order by created_at desc")
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var r redirect
+			if err := rows.Scan(&r.url, &r.createdAt, &r.feedID); err != nil {
+				rows.Close()
+				return err
+			}
+			redirects = append(redirects, r)
+		}
+		if err := rows.Close(); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "delete from feed_redirects"); err != nil {
+			return err
+		}
+		seen := map[string]bool{}
+		for _, r := range redirects {
+			canonical := feedURLKey(r.url)
+			if _, isFeed := claimed[canonical]; isFeed || seen[canonical] {
+				continue
+			}
+			seen[canonical] = true
+			if _, err := tx.ExecContext(ctx, "insert into feed_redirects (url, created_at, feed_id) values ("+param(1)+", "+param(2)+", "+param(3)+")",
+				canonical, r.createdAt, r.feedID); err != nil {
+				return fmt.Errorf("could not redirect %s: %w", r.url, err)
+			}
+		}
+
+		if len(duplicates) > 0 {
+			fmt.Fprintf(s.out, "%d feed(s) duplicate an older feed and should be merged into it:\n", len(duplicates))
+			for _, d := range duplicates {
+				fmt.Fprintln(s.out, d)
+			}
+		}
+		return nil
+	}
+	down := func(ctx context.Context, tx *sql.Tx) error {
+		if version != canonicalURLsVersion {
+			// gator looks feeds up by the canonical URLs this leaves, so
+			// rolling it back leaves them alone
+			return nil
+		}
+		_, err := tx.ExecContext(ctx, "update feeds set canonical_url = null")
+		return err
+	}
+	return goose.NewGoMigration(version, &goose.GoFunc{RunTx: up}, &goose.GoFunc{RunTx: down})
+}
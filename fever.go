@@ -0,0 +1,429 @@
+package main
+
+import (
+	"context"
+	"crypto/md5"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richardteaman/gator/internal/database"
+)
+
+const (
+	feverAPIVersion   = 3
+	feverItemsPerPage = 50
+	// feverGroupID is the single group every followed feed is placed in,
+	// since gator has no folders of its own.
+	feverGroupID = 1
+)
+
+type feverGroup struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+}
+
+type feverFeedsGroup struct {
+	GroupID int64  `json:"group_id"`
+	FeedIDs string `json:"feed_ids"`
+}
+
+type feverFeed struct {
+	ID                int64  `json:"id"`
+	FaviconID         int64  `json:"favicon_id"`
+	Title             string `json:"title"`
+	URL               string `json:"url"`
+	SiteURL           string `json:"site_url"`
+	IsSpark           int    `json:"is_spark"`
+	LastUpdatedOnTime int64  `json:"last_updated_on_time"`
+}
+
+type feverItem struct {
+	ID            int64  `json:"id"`
+	FeedID        int64  `json:"feed_id"`
+	Title         string `json:"title"`
+	Author        string `json:"author"`
+	HTML          string `json:"html"`
+	URL           string `json:"url"`
+	IsSaved       int    `json:"is_saved"`
+	IsRead        int    `json:"is_read"`
+	CreatedOnTime int64  `json:"created_on_time"`
+}
+
+// feverAPIKey is the key Fever clients send: md5 of "email:password".
+// gator uses the user name in place of the email address.
+func feverAPIKey(username, password string) string {
+	sum := md5.Sum([]byte(username + ":" + password))
+	return hex.EncodeToString(sum[:])
+}
+
+var feverKeyCommand = &commandSpec{
+	Name:    "fever-key",
+	Summary: "sets the password Fever API clients log in with",
+	Handler: middlewareLoggedIn(handlerFeverKey),
+}
+
+// handlerFeverKey reads the Fever password the way register does, so it
+// doesn't end up in the shell history or the process list.
+func handlerFeverKey(s *state, cmd command, user database.User) error {
+	password, err := readNewPassword(s, "Fever password: ")
+	if err != nil {
+		return err
+	}
+	if password == "" {
+		return kindErrorf(kindInvalidInput, "password can't be empty")
+	}
+
+	err = s.db.SetFeverAPIKey(context.Background(), database.SetFeverAPIKeyParams{
+		ID:          user.ID,
+		FeverApiKey: sql.NullString{String: feverAPIKey(user.Name, password), Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("could not set fever api key: %w", err)
+	}
+
+	fmt.Fprintln(s.out, "Fever API key set. Log in from your client with your user name and this password.")
+	return nil
+}
+
+// handleFever implements the Fever API so clients such as Reeder and
+// NetNewsWire can sync followed feeds, posts and read/starred state.
+func (a *apiServer) handleFever(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid form data")
+		return
+	}
+	if !r.Form.Has("api") {
+		respondError(w, http.StatusBadRequest, "missing api parameter")
+		return
+	}
+
+	ctx := r.Context()
+	resp := map[string]any{
+		"api_version": feverAPIVersion,
+		"auth":        0,
+	}
+
+	apiKey := strings.ToLower(strings.TrimSpace(r.Form.Get("api_key")))
+	user, err := a.state.db.GetUserByFeverAPIKey(ctx, sql.NullString{String: apiKey, Valid: apiKey != ""})
+	if errors.Is(err, sql.ErrNoRows) || apiKey == "" {
+		respondJSON(w, http.StatusOK, resp)
+		return
+	}
+	if err != nil {
+		respondInternalError(w, "could not authenticate fever client", err)
+		return
+	}
+	resp["auth"] = 1
+	resp["last_refreshed_on_time"] = time.Now().Unix()
+
+	if r.Form.Has("mark") {
+		if err := a.feverMark(ctx, user, r.Form, resp); err != nil {
+			respondInternalError(w, "could not update item state", err)
+			return
+		}
+	}
+
+	if r.Form.Has("groups") || r.Form.Has("feeds") {
+		if err := a.feverFeeds(ctx, user, r.Form, resp); err != nil {
+			respondInternalError(w, "could not list feeds", err)
+			return
+		}
+	}
+	if r.Form.Has("favicons") {
+		resp["favicons"] = []any{}
+	}
+	if r.Form.Has("links") {
+		resp["links"] = []any{}
+	}
+	if r.Form.Has("items") {
+		if err := a.feverItems(ctx, user, r.Form, resp); err != nil {
+			respondInternalError(w, "could not list items", err)
+			return
+		}
+	}
+	if r.Form.Has("unread_item_ids") {
+		if err := a.feverUnreadIDs(ctx, user, resp); err != nil {
+			respondInternalError(w, "could not list unread items", err)
+			return
+		}
+	}
+	if r.Form.Has("saved_item_ids") {
+		if err := a.feverSavedIDs(ctx, user, resp); err != nil {
+			respondInternalError(w, "could not list saved items", err)
+			return
+		}
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func (a *apiServer) feverFeeds(ctx context.Context, user database.User, form url.Values, resp map[string]any) error {
+	feeds, err := a.state.db.GetFollowedFeeds(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(feeds))
+	items := make([]feverFeed, 0, len(feeds))
+	for _, feed := range feeds {
+		ids = append(ids, strconv.FormatInt(feed.SerialID, 10))
+		item := feverFeed{
+			ID:      feed.SerialID,
+			Title:   feed.Name,
+			URL:     feed.Url,
+			SiteURL: feed.Url,
+		}
+		if feed.LastFetchedAt.Valid {
+			item.LastUpdatedOnTime = feed.LastFetchedAt.Time.Unix()
+		}
+		items = append(items, item)
+	}
+
+	resp["feeds_groups"] = []feverFeedsGroup{{GroupID: feverGroupID, FeedIDs: strings.Join(ids, ",")}}
+	if form.Has("groups") {
+		resp["groups"] = []feverGroup{{ID: feverGroupID, Title: "All"}}
+	}
+	if form.Has("feeds") {
+		resp["feeds"] = items
+	}
+	return nil
+}
+
+func (a *apiServer) feverItems(ctx context.Context, user database.User, form url.Values, resp map[string]any) error {
+	var rows []database.GetFeverItemsSinceRow
+
+	switch {
+	case form.Get("with_ids") != "":
+		for _, raw := range strings.Split(form.Get("with_ids"), ",") {
+			id, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+			if err != nil {
+				continue
+			}
+			row, err := a.state.db.GetFeverItem(ctx, database.GetFeverItemParams{UserID: user.ID, SerialID: id})
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			rows = append(rows, database.GetFeverItemsSinceRow(row))
+			if len(rows) == feverItemsPerPage {
+				break
+			}
+		}
+
+	case form.Get("max_id") != "":
+		maxID, _ := strconv.ParseInt(form.Get("max_id"), 10, 64)
+		before, err := a.state.db.GetFeverItemsBefore(ctx, database.GetFeverItemsBeforeParams{
+			UserID:    user.ID,
+			MaxID:     maxID,
+			PageLimit: feverItemsPerPage,
+		})
+		if err != nil {
+			return err
+		}
+		for _, row := range before {
+			rows = append(rows, database.GetFeverItemsSinceRow(row))
+		}
+
+	default:
+		sinceID, _ := strconv.ParseInt(form.Get("since_id"), 10, 64)
+		var err error
+		rows, err = a.state.db.GetFeverItemsSince(ctx, database.GetFeverItemsSinceParams{
+			UserID:    user.ID,
+			SinceID:   sinceID,
+			PageLimit: feverItemsPerPage,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	total, err := a.state.db.CountPostsForUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	items := make([]feverItem, 0, len(rows))
+	for _, row := range rows {
+		created := row.CreatedAt
+		if row.PublishedAt.Valid {
+			created = row.PublishedAt.Time
+		}
+		items = append(items, feverItem{
+			ID:            row.SerialID,
+			FeedID:        row.FeedSerialID,
+			Title:         row.Title.String,
+			Author:        row.Author.String,
+			HTML:          row.Description.String,
+			URL:           row.Url,
+			IsSaved:       boolToInt(row.IsSaved),
+			IsRead:        boolToInt(row.IsRead),
+			CreatedOnTime: created.Unix(),
+		})
+	}
+
+	resp["items"] = items
+	resp["total_items"] = total
+	return nil
+}
+
+func (a *apiServer) feverUnreadIDs(ctx context.Context, user database.User, resp map[string]any) error {
+	ids, err := a.state.db.GetUnreadPostSerialIDs(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	resp["unread_item_ids"] = joinIDs(ids)
+	return nil
+}
+
+func (a *apiServer) feverSavedIDs(ctx context.Context, user database.User, resp map[string]any) error {
+	ids, err := a.state.db.GetStarredPostSerialIDs(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	resp["saved_item_ids"] = joinIDs(ids)
+	return nil
+}
+
+// feverMark applies mark=item|feed|group requests. Unknown ids are ignored,
+// as Fever itself does.
+func (a *apiServer) feverMark(ctx context.Context, user database.User, form url.Values, resp map[string]any) error {
+	id, err := strconv.ParseInt(form.Get("id"), 10, 64)
+	if err != nil {
+		return nil
+	}
+	as := form.Get("as")
+
+	// before is a created_on_time the client was shown, the post's
+	// publication date if it has one, in whole seconds; the queries
+	// compare the same date, up to the end of that second
+	before := time.Now()
+	if raw := form.Get("before"); raw != "" {
+		if unix, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			before = time.Unix(unix, 0).Add(time.Second - time.Microsecond)
+		}
+	}
+
+	switch form.Get("mark") {
+	case "item":
+		// items of feeds the user doesn't follow are unknown to them
+		post, err := a.state.db.GetPostBySerialID(ctx, id)
+		if err == nil {
+			_, err = a.state.db.GetFeedFollowForUserAndFeed(ctx, database.GetFeedFollowForUserAndFeedParams{
+				UserID: user.ID,
+				FeedID: post.FeedID,
+			})
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := a.markItem(ctx, user, post, as); err != nil {
+			return err
+		}
+		if as == "saved" || as == "unsaved" {
+			return a.feverSavedIDs(ctx, user, resp)
+		}
+
+	case "feed":
+		if as != "read" {
+			return nil
+		}
+		feed, err := a.state.db.GetFeedBySerialID(ctx, id)
+		if err == nil {
+			_, err = a.state.db.GetFeedFollowForUserAndFeed(ctx, database.GetFeedFollowForUserAndFeedParams{
+				UserID: user.ID,
+				FeedID: feed.ID,
+			})
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		err = a.state.db.MarkFeedPostsRead(ctx, database.MarkFeedPostsReadParams{
+			UserID: user.ID,
+			FeedID: feed.ID,
+			Before: before,
+		})
+		if err != nil {
+			return err
+		}
+
+	case "group":
+		// group 0 is Fever's "Kindling" (everything), -1 are sparks which gator does not have
+		if as != "read" || (id != 0 && id != feverGroupID) {
+			return nil
+		}
+		err := a.state.db.MarkAllPostsRead(ctx, database.MarkAllPostsReadParams{
+			UserID: user.ID,
+			Before: before,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return a.feverUnreadIDs(ctx, user, resp)
+}
+
+func (a *apiServer) markItem(ctx context.Context, user database.User, post database.Post, as string) error {
+	now := time.Now()
+
+	switch as {
+	case "read":
+		return a.state.db.MarkPostRead(ctx, database.MarkPostReadParams{
+			ID:        uuid.New(),
+			CreatedAt: now,
+			UpdatedAt: now,
+			UserID:    user.ID,
+			PostID:    post.ID,
+		})
+	case "unread":
+		return a.state.db.MarkPostUnread(ctx, database.MarkPostUnreadParams{
+			UserID: user.ID,
+			PostID: post.ID,
+		})
+	case "saved":
+		return a.state.db.StarPost(ctx, database.StarPostParams{
+			ID:        uuid.New(),
+			CreatedAt: now,
+			UpdatedAt: now,
+			UserID:    user.ID,
+			PostID:    post.ID,
+		})
+	case "unsaved":
+		return a.state.db.UnstarPost(ctx, database.UnstarPostParams{
+			UserID: user.ID,
+			PostID: post.ID,
+		})
+	}
+	return nil
+}
+
+func joinIDs(ids []int64) string {
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parts = append(parts, strconv.FormatInt(id, 10))
+	}
+	return strings.Join(parts, ",")
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
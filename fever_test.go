@@ -0,0 +1,124 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// feverResponse is the part of Fever responses the tests look at.
+type feverResponse struct {
+	APIVersion    int         `json:"api_version"`
+	Auth          int         `json:"auth"`
+	Feeds         []feverFeed `json:"feeds"`
+	Items         []feverItem `json:"items"`
+	TotalItems    int64       `json:"total_items"`
+	UnreadItemIDs string      `json:"unread_item_ids"`
+	SavedItemIDs  string      `json:"saved_item_ids"`
+}
+
+// fever posts form to the Fever endpoint as a client logged in as name
+// with password would.
+func (c *apiClient) fever(name, password string, form url.Values) feverResponse {
+	c.t.Helper()
+	if name != "" {
+		form.Set("api_key", feverAPIKey(name, password))
+	}
+	var resp feverResponse
+	c.expect(http.StatusOK, "POST", "/fever/?api", "", form.Encode(), &resp)
+	return resp
+}
+
+func feverIDs(ids ...int64) string {
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parts = append(parts, strconv.FormatInt(id, 10))
+	}
+	return strings.Join(parts, ",")
+}
+
+func TestFever(t *testing.T) {
+	for name, dbURL := range map[string]string{"memory": "memory:", "sqlite": "sqlite://{dir}/gator.db"} {
+		t.Run(name, func(t *testing.T) {
+			ts := newSession(t, dbURL)
+			if dbURL != "memory:" {
+				ts.run("migrate up", "")
+			}
+			ts.registerUser("alice")
+			feedURL := feedServer(t)
+			ts.run(`addfeed "Test Feed" `+feedURL, "")
+			ts.call("fetch the feed", scrapeFeeds)
+			ts.run("fever-key", "hunter2\n")
+			ts.registerUser("bob")
+			ts.run("fever-key", "bobpass\n")
+			api := newAPIClient(ts)
+
+			if resp := api.fever("", "", url.Values{}); resp.Auth != 0 || resp.APIVersion != feverAPIVersion {
+				t.Errorf("without a key: %+v", resp)
+			}
+			if resp := api.fever("alice", "wrong", url.Values{"items": {""}}); resp.Auth != 0 || resp.Items != nil {
+				t.Errorf("with a wrong key: %+v", resp)
+			}
+			api.expect(http.StatusBadRequest, "POST", "/fever/", "", "api_key=x", nil)
+
+			resp := api.fever("alice", "hunter2", url.Values{"feeds": {""}, "items": {""}, "unread_item_ids": {""}})
+			if resp.Auth != 1 || len(resp.Feeds) != 1 || resp.Feeds[0].URL != feedURL {
+				t.Fatalf("alice's feeds: %+v", resp)
+			}
+			if len(resp.Items) != 2 || resp.TotalItems != 2 {
+				t.Fatalf("alice's items: %+v", resp.Items)
+			}
+			// items come in the order they were stored, dated by their pubDate
+			hello, ad := resp.Items[0], resp.Items[1]
+			if hello.Title != "Hello gophers" || hello.CreatedOnTime != time.Date(2026, 10, 5, 10, 0, 0, 0, time.UTC).Unix() {
+				t.Errorf("first item %+v, want Hello gophers published on 2026-10-05", hello)
+			}
+			if hello.Author != "Ada" || ad.Author != "" {
+				t.Errorf("authors %q and %q, want Ada and none", hello.Author, ad.Author)
+			}
+			if resp.UnreadItemIDs != feverIDs(hello.ID, ad.ID) {
+				t.Errorf("unread ids %q, want both items", resp.UnreadItemIDs)
+			}
+
+			// bob doesn't follow the feed, so alice's items are unknown to them
+			feedID := strconv.FormatInt(resp.Feeds[0].ID, 10)
+			api.fever("bob", "bobpass", url.Values{"mark": {"item"}, "as": {"read"}, "id": {strconv.FormatInt(hello.ID, 10)}})
+			api.fever("bob", "bobpass", url.Values{"mark": {"feed"}, "as": {"read"}, "id": {feedID}})
+
+			resp = api.fever("alice", "hunter2", url.Values{"mark": {"item"}, "as": {"saved"}, "id": {strconv.FormatInt(ad.ID, 10)}})
+			if resp.SavedItemIDs != feverIDs(ad.ID) {
+				t.Errorf("saved ids after saving %d: %q", ad.ID, resp.SavedItemIDs)
+			}
+			resp = api.fever("alice", "hunter2", url.Values{"mark": {"item"}, "as": {"read"}, "id": {strconv.FormatInt(ad.ID, 10)}})
+			if resp.UnreadItemIDs != feverIDs(hello.ID) {
+				t.Errorf("unread ids after reading %d: %q", ad.ID, resp.UnreadItemIDs)
+			}
+			resp = api.fever("alice", "hunter2", url.Values{"mark": {"item"}, "as": {"unread"}, "id": {strconv.FormatInt(ad.ID, 10)}})
+			if resp.UnreadItemIDs != feverIDs(hello.ID, ad.ID) {
+				t.Errorf("unread ids after marking %d unread: %q", ad.ID, resp.UnreadItemIDs)
+			}
+
+			// marking the feed read up to the date shown for the first item
+			// leaves the later one, although both were stored after it
+			before := strconv.FormatInt(hello.CreatedOnTime, 10)
+			resp = api.fever("alice", "hunter2", url.Values{"mark": {"feed"}, "as": {"read"}, "id": {feedID}, "before": {before}})
+			if resp.UnreadItemIDs != feverIDs(ad.ID) {
+				t.Errorf("unread ids after marking the feed read before %s: %q", before, resp.UnreadItemIDs)
+			}
+			resp = api.fever("alice", "hunter2", url.Values{"mark": {"group"}, "as": {"read"}, "id": {"0"}, "before": {strconv.FormatInt(ad.CreatedOnTime, 10)}})
+			if resp.UnreadItemIDs != "" {
+				t.Errorf("unread ids after marking everything read: %q", resp.UnreadItemIDs)
+			}
+
+			ts.run("login bob", testPassword+"\n")
+			ts.run("follow "+feedURL, "")
+			resp = api.fever("bob", "bobpass", url.Values{"unread_item_ids": {""}, "saved_item_ids": {""}})
+			if resp.UnreadItemIDs != feverIDs(hello.ID, ad.ID) || resp.SavedItemIDs != "" {
+				t.Errorf("bob's unread and saved ids after following: %+v", resp)
+			}
+		})
+	}
+}
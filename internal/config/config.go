@@ -8,60 +8,283 @@ import (
 	"path/filepath"
 )
 
+// Config is the settings gator runs with: the values of the active profile
+// on top of the file's top-level values, with environment overrides
+// applied last.
 type Config struct {
-	DBURL           string `json:"db_url"`
+	DBURL           string `json:"db_url,omitempty"`
 	CurrentUserName string `json:"current_user_name,omitempty"`
+	// Sessions maps user names to the session tokens from gator login, so
+	// switching between accounts you have logged in to needs no password.
+	Sessions map[string]string `json:"sessions,omitempty"`
+	SMTP     *SMTPConfig       `json:"smtp,omitempty"`
+
+	// Path is the file the config was read from and is written back to.
+	Path string `json:"-"`
+	// Profile is the active profile, empty for the top-level settings.
+	Profile string `json:"-"`
+
+	file *fileConfig
+}
+
+// SMTPConfig is the mail server digests are sent through.
+type SMTPConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	From     string `json:"from"`
+	// To is the default digest recipient.
+	To string `json:"to,omitempty"`
+}
+
+// fileConfig is the layout on disk: top-level settings plus named profiles.
+type fileConfig struct {
+	Config
+	DefaultProfile string             `json:"default_profile,omitempty"`
+	Profiles       map[string]*Config `json:"profiles,omitempty"`
+}
+
+// Options select which file and profile to load. Empty fields fall back
+// to the environment and then to the defaults.
+type Options struct {
+	Path    string
+	Profile string
+}
+
+const (
+	configFileName = ".gatorconfig.json"
+
+	EnvConfig  = "GATOR_CONFIG"
+	EnvProfile = "GATOR_PROFILE"
+	EnvDBURL   = "GATOR_DB_URL"
+	EnvUser    = "GATOR_USER"
+)
+
+var (
+	ErrNotFound = errors.New("config file not found")
+	ErrExists   = errors.New("database already configured")
+)
+
+// DefaultPath is where a new config file goes:
+// $XDG_CONFIG_HOME/gator/config.json, or ~/.config/gator/config.json. It
+// is the same on every platform, unlike os.UserConfigDir.
+func DefaultPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if !filepath.IsAbs(dir) {
+		// the spec says to ignore relative paths
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", errors.New("could not locate config dir")
+		}
+		dir = filepath.Join(homeDir, ".config")
+	}
+	return filepath.Join(dir, "gator", "config.json"), nil
 }
 
-const configFileName = ".gatorconfig.json"
+// findConfigFile picks the file to read. In order: the --config flag,
+// $GATOR_CONFIG, the XDG location, then the legacy ~/.gatorconfig.json.
+func findConfigFile(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	if path := os.Getenv(EnvConfig); path != "" {
+		return path, nil
+	}
+
+	xdgPath, err := DefaultPath()
+	if err == nil {
+		if _, err := os.Stat(xdgPath); err == nil {
+			return xdgPath, nil
+		}
+	}
 
-func getConfigFilePath() (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return "", errors.New("could not locate home dir")
 	}
-	return filepath.Join(homeDir, configFileName), nil
+	legacyPath := filepath.Join(homeDir, configFileName)
+	if _, err := os.Stat(legacyPath); err == nil {
+		return legacyPath, nil
+	}
+
+	if xdgPath != "" {
+		return xdgPath, nil
+	}
+	return legacyPath, nil
 }
 
-func ReadConfigFile() (Config, error) {
-	configPath, err := getConfigFilePath()
+// Load reads the config file and resolves the active profile. The profile
+// is chosen by opts.Profile, then $GATOR_PROFILE, then default_profile in
+// the file. $GATOR_DB_URL and $GATOR_USER override whatever the file says.
+func Load(opts Options) (Config, error) {
+	configPath, err := findConfigFile(opts.Path)
 	if err != nil {
 		return Config{}, err
 	}
 
-	file, err := os.ReadFile(configPath)
+	data, err := os.ReadFile(configPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return Config{Path: configPath}, fmt.Errorf("%w at %s", ErrNotFound, configPath)
+	}
 	if err != nil {
-		return Config{}, errors.New("failed to read config file")
+		return Config{}, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	var cfg Config
-	err = json.Unmarshal(file, &cfg)
+	var file fileConfig
+	err = json.Unmarshal(data, &file)
 	if err != nil {
-		return Config{}, errors.New("failed to unmarshal")
+		return Config{}, fmt.Errorf("failed to parse %s: %w", configPath, err)
+	}
+
+	profile := opts.Profile
+	if profile == "" {
+		profile = os.Getenv(EnvProfile)
+	}
+	if profile == "" {
+		profile = file.DefaultProfile
+	}
+
+	cfg := file.Config
+	if profile != "" {
+		p, ok := file.Profiles[profile]
+		if !ok {
+			return Config{}, fmt.Errorf("profile %q not found in %s", profile, configPath)
+		}
+		cfg = resolveProfile(file.Config, *p)
+	}
+	cfg.Path = configPath
+	cfg.Profile = profile
+	cfg.file = &file
+
+	if dbURL := os.Getenv(EnvDBURL); dbURL != "" {
+		cfg.DBURL = dbURL
+	}
+	if user := os.Getenv(EnvUser); user != "" {
+		cfg.CurrentUserName = user
 	}
 
 	return cfg, nil
 }
 
-func (cfg *Config) SetUser(username string) error {
-	cfg.CurrentUserName = username
-	return write(*cfg)
+// resolveProfile lays a profile over the top-level settings. Logins are
+// never inherited since sessions belong to one database.
+func resolveProfile(base, p Config) Config {
+	cfg := p
+	if cfg.DBURL == "" {
+		cfg.DBURL = base.DBURL
+	}
+	if cfg.SMTP == nil {
+		cfg.SMTP = base.SMTP
+	}
+	return cfg
 }
 
-func write(cfg Config) error {
-	configPath, err := getConfigFilePath()
+// Create records dbURL in the config file selected by opts, creating the
+// file if needed. With a profile the URL goes into that profile and the
+// rest of an existing file is kept. An already configured URL is only
+// replaced when overwrite is set.
+func Create(opts Options, dbURL string, overwrite bool) (string, error) {
+	configPath, err := findConfigFile(opts.Path)
 	if err != nil {
-		return err
+		return "", err
+	}
+
+	var file fileConfig
+	data, err := os.ReadFile(configPath)
+	if err == nil {
+		if err := json.Unmarshal(data, &file); err != nil {
+			return "", fmt.Errorf("failed to parse %s: %w", configPath, err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	section := &file.Config
+	if opts.Profile != "" {
+		if file.Profiles == nil {
+			file.Profiles = map[string]*Config{}
+		}
+		if file.Profiles[opts.Profile] == nil {
+			file.Profiles[opts.Profile] = &Config{}
+		}
+		section = file.Profiles[opts.Profile]
+	}
+	if section.DBURL != "" && !overwrite {
+		return configPath, fmt.Errorf("%w in %s", ErrExists, configPath)
+	}
+	if section.DBURL != dbURL {
+		// sessions belong to the old database
+		section.Sessions = nil
+		section.CurrentUserName = ""
+	}
+	section.DBURL = dbURL
+
+	return configPath, write(configPath, &file)
+}
+
+// SessionToken is the token of the current user, if they are logged in.
+func (cfg *Config) SessionToken() string {
+	return cfg.Sessions[cfg.CurrentUserName]
+}
+
+// SetSession records username's session token and makes them the current
+// user. An empty token logs them out.
+func (cfg *Config) SetSession(username, token string) error {
+	if cfg.file == nil {
+		cfg.file = &fileConfig{}
+	}
+
+	section := &cfg.file.Config
+	if cfg.Profile != "" {
+		if cfg.file.Profiles == nil {
+			cfg.file.Profiles = map[string]*Config{}
+		}
+		if cfg.file.Profiles[cfg.Profile] == nil {
+			cfg.file.Profiles[cfg.Profile] = &Config{}
+		}
+		section = cfg.file.Profiles[cfg.Profile]
+	}
+
+	for _, c := range []*Config{section, cfg} {
+		if c.Sessions == nil {
+			c.Sessions = map[string]string{}
+		}
+		if token == "" {
+			delete(c.Sessions, username)
+			if c.CurrentUserName == username {
+				c.CurrentUserName = ""
+			}
+		} else {
+			c.Sessions[username] = token
+			c.CurrentUserName = username
+		}
 	}
 
-	data, err := json.MarshalIndent(cfg, "", " ")
+	return write(cfg.Path, cfg.file)
+}
+
+func write(configPath string, file *fileConfig) error {
+	if configPath == "" {
+		return errors.New("no config file path")
+	}
+	if err := os.MkdirAll(filepath.Dir(configPath), 0700); err != nil {
+		return fmt.Errorf("failed to create config dir: %w", err)
+	}
+
+	data, err := json.MarshalIndent(file, "", " ")
 	if err != nil {
 		return fmt.Errorf("failed to serialize config: %w", err)
 	}
-	err = os.WriteFile(configPath, data, 0644)
+	// the file holds session tokens, so keep it private, including files
+	// created by hand before that was the case
+	err = os.WriteFile(configPath, data, 0600)
 	if err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
+	if err := os.Chmod(configPath, 0600); err != nil {
+		return fmt.Errorf("failed to restrict config file permissions: %w", err)
+	}
 
 	return nil
 }
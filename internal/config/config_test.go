@@ -0,0 +1,236 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// isolate points the home and config directories at a fresh directory and
+// clears the GATOR_* variables, returning the home directory.
+func isolate(t *testing.T) string {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	for _, env := range []string{EnvConfig, EnvProfile, EnvDBURL, EnvUser} {
+		t.Setenv(env, "")
+	}
+	return home
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDefaultPath(t *testing.T) {
+	home := isolate(t)
+	tests := []struct {
+		xdg, want string
+	}{
+		{"", filepath.Join(home, ".config", "gator", "config.json")},
+		{"/etc/xdg-home", filepath.Join("/etc/xdg-home", "gator", "config.json")},
+		{"relative/dir", filepath.Join(home, ".config", "gator", "config.json")},
+	}
+	for _, tt := range tests {
+		t.Setenv("XDG_CONFIG_HOME", tt.xdg)
+		if got, err := DefaultPath(); err != nil || got != tt.want {
+			t.Errorf("XDG_CONFIG_HOME=%q: DefaultPath() = %q, %v, want %q", tt.xdg, got, err, tt.want)
+		}
+	}
+}
+
+func TestFindConfigFile(t *testing.T) {
+	home := isolate(t)
+	xdgPath := filepath.Join(home, ".config", "gator", "config.json")
+	legacyPath := filepath.Join(home, configFileName)
+	flagPath := filepath.Join(home, "flag.json")
+	envPath := filepath.Join(home, "env.json")
+
+	check := func(flag, want string) {
+		t.Helper()
+		if got, err := findConfigFile(flag); err != nil || got != want {
+			t.Errorf("findConfigFile(%q) = %q, %v, want %q", flag, got, err, want)
+		}
+	}
+
+	// a new install gets the XDG path
+	check("", xdgPath)
+	writeFile(t, legacyPath, "{}")
+	check("", legacyPath)
+	writeFile(t, xdgPath, "{}")
+	check("", xdgPath)
+	// neither has to exist
+	t.Setenv(EnvConfig, envPath)
+	check("", envPath)
+	check(flagPath, flagPath)
+}
+
+// profilesFile has top-level settings, a default profile and profiles that
+// leave out the database URL or the mail server.
+const profilesFile = `{
+ "db_url": "postgres://top",
+ "current_user_name": "top-user",
+ "smtp": {"host": "mail.example", "from": "gator@example.com"},
+ "default_profile": "dev",
+ "profiles": {
+  "dev": {"db_url": "sqlite://dev.db", "current_user_name": "dev-user"},
+  "prod": {"db_url": "postgres://prod", "smtp": {"host": "mail.prod.example", "from": "gator@prod.example"}},
+  "inherit": {"current_user_name": "inherit-user"}
+ }
+}`
+
+func TestLoadPrecedence(t *testing.T) {
+	tests := []struct {
+		name                   string
+		profile                string
+		env                    map[string]string
+		wantProfile, wantDB    string
+		wantUser, wantSMTPHost string
+	}{
+		{"default profile", "", nil, "dev", "sqlite://dev.db", "dev-user", "mail.example"},
+		{"profile from the environment", "", map[string]string{EnvProfile: "prod"}, "prod", "postgres://prod", "", "mail.prod.example"},
+		{"profile flag over the environment", "inherit", map[string]string{EnvProfile: "prod"}, "inherit", "postgres://top", "inherit-user", "mail.example"},
+		{"environment over the profile", "prod", map[string]string{EnvDBURL: "sqlite://env.db", EnvUser: "env-user"}, "prod", "sqlite://env.db", "env-user", "mail.prod.example"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := isolate(t)
+			path := filepath.Join(home, "gator.json")
+			writeFile(t, path, profilesFile)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cfg, err := Load(Options{Path: path, Profile: tt.profile})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Path != path || cfg.Profile != tt.wantProfile || cfg.DBURL != tt.wantDB || cfg.CurrentUserName != tt.wantUser {
+				t.Errorf("got path %q, profile %q, db %q, user %q; want %q, %q, %q, %q",
+					cfg.Path, cfg.Profile, cfg.DBURL, cfg.CurrentUserName, path, tt.wantProfile, tt.wantDB, tt.wantUser)
+			}
+			if cfg.SMTP == nil || cfg.SMTP.Host != tt.wantSMTPHost {
+				t.Errorf("SMTP %+v, want host %q", cfg.SMTP, tt.wantSMTPHost)
+			}
+		})
+	}
+}
+
+func TestLoadTopLevel(t *testing.T) {
+	home := isolate(t)
+	path := filepath.Join(home, "gator.json")
+	writeFile(t, path, `{"db_url": "postgres://top", "current_user_name": "top-user"}`)
+	t.Setenv(EnvConfig, path)
+
+	cfg, err := Load(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Path != path || cfg.Profile != "" || cfg.DBURL != "postgres://top" || cfg.CurrentUserName != "top-user" {
+		t.Errorf("config %+v", cfg)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	home := isolate(t)
+	missing := filepath.Join(home, "missing.json")
+	if cfg, err := Load(Options{Path: missing}); !errors.Is(err, ErrNotFound) || cfg.Path != missing {
+		t.Errorf("missing file: %v with path %q, want ErrNotFound with %q", err, cfg.Path, missing)
+	}
+
+	path := filepath.Join(home, "gator.json")
+	writeFile(t, path, profilesFile)
+	if _, err := Load(Options{Path: path, Profile: "nope"}); err == nil {
+		t.Error("unknown profile from the flag loaded")
+	}
+	t.Setenv(EnvProfile, "nope")
+	if _, err := Load(Options{Path: path}); err == nil {
+		t.Error("unknown profile from the environment loaded")
+	}
+
+	writeFile(t, path, "{")
+	if _, err := Load(Options{Path: path}); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("broken file: %v", err)
+	}
+}
+
+func TestSetSessionInProfile(t *testing.T) {
+	home := isolate(t)
+	path := filepath.Join(home, "gator.json")
+	writeFile(t, path, profilesFile)
+
+	cfg, err := Load(Options{Path: path, Profile: "prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.SetSession("alice", "token"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.SessionToken() != "token" || cfg.CurrentUserName != "alice" {
+		t.Errorf("after SetSession: user %q, token %q", cfg.CurrentUserName, cfg.SessionToken())
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("config file mode %v, want 0600", info.Mode().Perm())
+	}
+	var file fileConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatal(err)
+	}
+	prod := file.Profiles["prod"]
+	if prod.CurrentUserName != "alice" || prod.Sessions["alice"] != "token" {
+		t.Errorf("prod profile %+v", prod)
+	}
+	if file.CurrentUserName != "top-user" || file.Profiles["dev"].CurrentUserName != "dev-user" || file.DefaultProfile != "dev" {
+		t.Errorf("the rest of the file changed:\n%s", data)
+	}
+
+	// logging out drops the session
+	if err := cfg.SetSession("alice", ""); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.SessionToken() != "" || cfg.CurrentUserName != "" {
+		t.Errorf("after logging out: user %q, token %q", cfg.CurrentUserName, cfg.SessionToken())
+	}
+}
+
+func TestCreate(t *testing.T) {
+	home := isolate(t)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "xdg"))
+
+	path, err := Create(Options{}, "postgres://first", false)
+	if want := filepath.Join(home, "xdg", "gator", "config.json"); err != nil || path != want {
+		t.Fatalf("Create() = %q, %v, want %q", path, err, want)
+	}
+	if _, err := Create(Options{}, "postgres://second", false); !errors.Is(err, ErrExists) {
+		t.Errorf("replacing without overwrite: %v, want ErrExists", err)
+	}
+	if _, err := Create(Options{Profile: "test"}, "sqlite://test.db", false); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(Options{})
+	if err != nil || cfg.DBURL != "postgres://first" {
+		t.Errorf("top level: %q, %v", cfg.DBURL, err)
+	}
+	cfg, err = Load(Options{Profile: "test"})
+	if err != nil || cfg.DBURL != "sqlite://test.db" {
+		t.Errorf("test profile: %q, %v", cfg.DBURL, err)
+	}
+}
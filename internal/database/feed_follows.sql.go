@@ -77,20 +77,35 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 	return i, err
 }
 
-const deleteFeedFollow = `-- name: DeleteFeedFollow :exec
+const deleteFeedFollowByFeedID = `-- name: DeleteFeedFollowByFeedID :execrows
 delete from feed_follows
-where feed_follows.user_id = $1 
-and feed_id = (select id from feeds where url = $2 limit 1)
+where user_id = $1 and feed_id = $2
 `
 
-type DeleteFeedFollowParams struct {
+type DeleteFeedFollowByFeedIDParams struct {
 	UserID uuid.UUID
-	Url    string
+	FeedID uuid.UUID
+}
+
+func (q *Queries) DeleteFeedFollowByFeedID(ctx context.Context, arg DeleteFeedFollowByFeedIDParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFeedFollowByFeedID, arg.UserID, arg.FeedID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-func (q *Queries) DeleteFeedFollow(ctx context.Context, arg DeleteFeedFollowParams) error {
-	_, err := q.db.ExecContext(ctx, deleteFeedFollow, arg.UserID, arg.Url)
-	return err
+const deleteFeedFollowsForUser = `-- name: DeleteFeedFollowsForUser :execrows
+delete from feed_follows
+where user_id = $1
+`
+
+func (q *Queries) DeleteFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFeedFollowsForUser, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const getFeedFollowForUserAndFeed = `-- name: GetFeedFollowForUserAndFeed :one
@@ -172,3 +187,23 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 	}
 	return items, nil
 }
+
+const moveFeedFollows = `-- name: MoveFeedFollows :execrows
+update feed_follows
+set feed_id = $1, updated_at = now()
+where feed_id = $2
+and user_id not in (select user_id from feed_follows where feed_id = $1)
+`
+
+type MoveFeedFollowsParams struct {
+	IntoFeedID uuid.UUID
+	FromFeedID uuid.UUID
+}
+
+func (q *Queries) MoveFeedFollows(ctx context.Context, arg MoveFeedFollowsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, moveFeedFollows, arg.IntoFeedID, arg.FromFeedID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
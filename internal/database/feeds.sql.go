@@ -20,18 +20,20 @@ insert into feeds (
     updated_at,
     name,
     url,
-    user_id
-) values ( $1,$2,$3,$4,$5,$6)
-returning id, created_at, updated_at, name, url, user_id, last_fetched_at
+    user_id,
+    canonical_url
+) values ( $1,$2,$3,$4,$5,$6,$7)
+returning id, created_at, updated_at, name, url, user_id, last_fetched_at, serial_id, canonical_url
 `
 
 type CreateFeedParams struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Name      string
-	Url       string
-	UserID    uuid.UUID
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Name         string
+	Url          string
+	UserID       uuid.UUID
+	CanonicalUrl sql.NullString
 }
 
 func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, error) {
@@ -42,6 +44,7 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		arg.Name,
 		arg.Url,
 		arg.UserID,
+		arg.CanonicalUrl,
 	)
 	var i Feed
 	err := row.Scan(
@@ -52,12 +55,95 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.SerialID,
+		&i.CanonicalUrl,
+	)
+	return i, err
+}
+
+const deleteFeed = `-- name: DeleteFeed :execrows
+delete from feeds
+where id = $1
+`
+
+func (q *Queries) DeleteFeed(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFeed, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getFeedByCanonicalURL = `-- name: GetFeedByCanonicalURL :one
+select id, created_at, updated_at, name, url, user_id, last_fetched_at, serial_id, canonical_url from feeds
+where canonical_url = $1::text
+`
+
+func (q *Queries) GetFeedByCanonicalURL(ctx context.Context, canonicalUrl string) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByCanonicalURL, canonicalUrl)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.SerialID,
+		&i.CanonicalUrl,
+	)
+	return i, err
+}
+
+const getFeedByID = `-- name: GetFeedByID :one
+select id, created_at, updated_at, name, url, user_id, last_fetched_at, serial_id, canonical_url from feeds
+where id = $1
+limit 1
+`
+
+func (q *Queries) GetFeedByID(ctx context.Context, id uuid.UUID) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByID, id)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.SerialID,
+		&i.CanonicalUrl,
+	)
+	return i, err
+}
+
+const getFeedBySerialID = `-- name: GetFeedBySerialID :one
+select id, created_at, updated_at, name, url, user_id, last_fetched_at, serial_id, canonical_url from feeds
+where serial_id = $1
+limit 1
+`
+
+func (q *Queries) GetFeedBySerialID(ctx context.Context, serialID int64) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedBySerialID, serialID)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.SerialID,
+		&i.CanonicalUrl,
 	)
 	return i, err
 }
 
 const getFeedByURL = `-- name: GetFeedByURL :one
-select id, created_at, updated_at, name, url, user_id, last_fetched_at from feeds
+select id, created_at, updated_at, name, url, user_id, last_fetched_at, serial_id, canonical_url from feeds
 where url = $1
 limit 1
 `
@@ -73,12 +159,14 @@ func (q *Queries) GetFeedByURL(ctx context.Context, url string) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.SerialID,
+		&i.CanonicalUrl,
 	)
 	return i, err
 }
 
 const getFeeds = `-- name: GetFeeds :many
-select id, created_at, updated_at, name, url, user_id, last_fetched_at from feeds
+select id, created_at, updated_at, name, url, user_id, last_fetched_at, serial_id, canonical_url from feeds
 `
 
 func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
@@ -98,6 +186,8 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
 			&i.Url,
 			&i.UserID,
 			&i.LastFetchedAt,
+			&i.SerialID,
+			&i.CanonicalUrl,
 		); err != nil {
 			return nil, err
 		}
@@ -163,8 +253,64 @@ func (q *Queries) GetFeedsWithUsers(ctx context.Context) ([]GetFeedsWithUsersRow
 	return items, nil
 }
 
+const getFollowedFeeds = `-- name: GetFollowedFeeds :many
+select
+    f.id,
+    f.created_at,
+    f.updated_at,
+    f.name,
+    f.url,
+    f.user_id,
+    f.last_fetched_at,
+    f.serial_id,
+    f.canonical_url
+from feeds f
+join feed_follows ff on ff.feed_id = f.id
+where ff.user_id = $1
+order by f.name
+`
+
+func (q *Queries) GetFollowedFeeds(ctx context.Context, userID uuid.UUID) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFollowedFeeds, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.SerialID,
+			&i.CanonicalUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-select id, created_at, updated_at, name, url, user_id, last_fetched_at from feeds
+select id, created_at, updated_at, name, url, user_id, last_fetched_at, serial_id, canonical_url from feeds f
+where not exists (
+    select 1 from websub_subscriptions ws
+    where ws.feed_id = f.id
+    and ws.state = 'active'
+    and ws.lease_expires_at > now()
+)
 order by last_fetched_at nulls first,updated_at asc
 limit 1
 `
@@ -180,10 +326,56 @@ func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.SerialID,
+		&i.CanonicalUrl,
 	)
 	return i, err
 }
 
+const listFeeds = `-- name: ListFeeds :many
+select id, created_at, updated_at, name, url, user_id, last_fetched_at, serial_id, canonical_url from feeds
+order by created_at
+limit $1 offset $2
+`
+
+type ListFeedsParams struct {
+	Limit  int32
+	Offset int32
+}
+
+func (q *Queries) ListFeeds(ctx context.Context, arg ListFeedsParams) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, listFeeds, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.SerialID,
+			&i.CanonicalUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const markFeedFetched = `-- name: MarkFeedFetched :exec
 update feeds 
 set last_fetched_at = now(), updated_at = now()
@@ -194,3 +386,62 @@ func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) error {
 	_, err := q.db.ExecContext(ctx, markFeedFetched, id)
 	return err
 }
+
+const renameFeed = `-- name: RenameFeed :one
+update feeds
+set name = $2, updated_at = now()
+where id = $1
+returning id, created_at, updated_at, name, url, user_id, last_fetched_at, serial_id, canonical_url
+`
+
+type RenameFeedParams struct {
+	ID   uuid.UUID
+	Name string
+}
+
+func (q *Queries) RenameFeed(ctx context.Context, arg RenameFeedParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, renameFeed, arg.ID, arg.Name)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.SerialID,
+		&i.CanonicalUrl,
+	)
+	return i, err
+}
+
+const setFeedURL = `-- name: SetFeedURL :one
+update feeds
+set url = $2, canonical_url = $3, last_fetched_at = null, updated_at = now()
+where id = $1
+returning id, created_at, updated_at, name, url, user_id, last_fetched_at, serial_id, canonical_url
+`
+
+type SetFeedURLParams struct {
+	ID           uuid.UUID
+	Url          string
+	CanonicalUrl sql.NullString
+}
+
+func (q *Queries) SetFeedURL(ctx context.Context, arg SetFeedURLParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, setFeedURL, arg.ID, arg.Url, arg.CanonicalUrl)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.SerialID,
+		&i.CanonicalUrl,
+	)
+	return i, err
+}
@@ -13,6 +13,19 @@ import (
 	"github.com/google/uuid"
 )
 
+const countPostsForUser = `-- name: CountPostsForUser :one
+select count(*) from posts p
+join feed_follows ff on ff.feed_id = p.feed_id
+where ff.user_id = $1
+`
+
+func (q *Queries) CountPostsForUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countPostsForUser, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createPost = `-- name: CreatePost :one
 insert into posts(
     id,
@@ -22,9 +35,11 @@ insert into posts(
     url,
     description,
     published_at,
-    feed_id
-) values ($1,$2,$3,$4,$5,$6,$7,$8)
-returning id, created_at, updated_at, title, url, description, published_at, feed_id
+    feed_id,
+    author,
+    categories
+) values ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
+returning id, created_at, updated_at, title, url, description, published_at, feed_id, serial_id, author, categories
 `
 
 type CreatePostParams struct {
@@ -36,6 +51,8 @@ type CreatePostParams struct {
 	Description sql.NullString
 	PublishedAt sql.NullTime
 	FeedID      uuid.UUID
+	Author      sql.NullString
+	Categories  sql.NullString
 }
 
 func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
@@ -48,6 +65,8 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		arg.Description,
 		arg.PublishedAt,
 		arg.FeedID,
+		arg.Author,
+		arg.Categories,
 	)
 	var i Post
 	err := row.Scan(
@@ -59,12 +78,412 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.Description,
 		&i.PublishedAt,
 		&i.FeedID,
+		&i.SerialID,
+		&i.Author,
+		&i.Categories,
+	)
+	return i, err
+}
+
+const deletePost = `-- name: DeletePost :exec
+delete from posts
+where id = $1
+`
+
+func (q *Queries) DeletePost(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deletePost, id)
+	return err
+}
+
+const deletePostsOlderThan = `-- name: DeletePostsOlderThan :execrows
+delete from posts
+where coalesce(published_at, created_at) < $1::timestamp
+`
+
+func (q *Queries) DeletePostsOlderThan(ctx context.Context, before time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deletePostsOlderThan, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getDigestPosts = `-- name: GetDigestPosts :many
+select
+    p.id,
+    p.title,
+    p.url,
+    p.description,
+    p.published_at,
+    p.created_at,
+    f.name as feed_name,
+    f.url as feed_url
+from posts p
+join feeds f on f.id = p.feed_id
+join feed_follows ff on ff.feed_id = p.feed_id
+left join post_reads pr on pr.post_id = p.id and pr.user_id = ff.user_id
+where ff.user_id = $1
+and pr.id is null
+and p.created_at > $2
+order by p.created_at, p.id
+limit $3
+`
+
+type GetDigestPostsParams struct {
+	UserID   uuid.UUID
+	Since    time.Time
+	MaxPosts int32
+}
+
+type GetDigestPostsRow struct {
+	ID          uuid.UUID
+	Title       sql.NullString
+	Url         string
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	CreatedAt   time.Time
+	FeedName    string
+	FeedUrl     string
+}
+
+func (q *Queries) GetDigestPosts(ctx context.Context, arg GetDigestPostsParams) ([]GetDigestPostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getDigestPosts, arg.UserID, arg.Since, arg.MaxPosts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDigestPostsRow
+	for rows.Next() {
+		var i GetDigestPostsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.CreatedAt,
+			&i.FeedName,
+			&i.FeedUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDuplicatePosts = `-- name: GetDuplicatePosts :many
+select f.id as from_post_id, i.id as into_post_id
+from posts f
+join posts i on i.serial_id = (
+    select min(d.serial_id) from posts d
+    where d.feed_id = $1
+        and d.title is not distinct from f.title
+        and d.published_at is not distinct from f.published_at
+)
+-- posts with neither a title nor a date have nothing to tell them apart
+where f.feed_id = $2 and (f.title is not null or f.published_at is not null)
+order by f.serial_id
+`
+
+type GetDuplicatePostsParams struct {
+	IntoFeedID uuid.UUID
+	FromFeedID uuid.UUID
+}
+
+type GetDuplicatePostsRow struct {
+	FromPostID uuid.UUID
+	IntoPostID uuid.UUID
+}
+
+func (q *Queries) GetDuplicatePosts(ctx context.Context, arg GetDuplicatePostsParams) ([]GetDuplicatePostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getDuplicatePosts, arg.IntoFeedID, arg.FromFeedID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDuplicatePostsRow
+	for rows.Next() {
+		var i GetDuplicatePostsRow
+		if err := rows.Scan(&i.FromPostID, &i.IntoPostID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFeverItem = `-- name: GetFeverItem :one
+select
+    p.serial_id,
+    f.serial_id as feed_serial_id,
+    p.title,
+    p.url,
+    p.description,
+    p.author,
+    p.published_at,
+    p.created_at,
+    (pr.id is not null)::bool as is_read,
+    (ps.id is not null)::bool as is_saved
+from posts p
+join feeds f on f.id = p.feed_id
+join feed_follows ff on ff.feed_id = p.feed_id
+left join post_reads pr on pr.post_id = p.id and pr.user_id = ff.user_id
+left join post_stars ps on ps.post_id = p.id and ps.user_id = ff.user_id
+where ff.user_id = $1 and p.serial_id = $2
+limit 1
+`
+
+type GetFeverItemParams struct {
+	UserID   uuid.UUID
+	SerialID int64
+}
+
+type GetFeverItemRow struct {
+	SerialID     int64
+	FeedSerialID int64
+	Title        sql.NullString
+	Url          string
+	Description  sql.NullString
+	Author       sql.NullString
+	PublishedAt  sql.NullTime
+	CreatedAt    time.Time
+	IsRead       bool
+	IsSaved      bool
+}
+
+func (q *Queries) GetFeverItem(ctx context.Context, arg GetFeverItemParams) (GetFeverItemRow, error) {
+	row := q.db.QueryRowContext(ctx, getFeverItem, arg.UserID, arg.SerialID)
+	var i GetFeverItemRow
+	err := row.Scan(
+		&i.SerialID,
+		&i.FeedSerialID,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.Author,
+		&i.PublishedAt,
+		&i.CreatedAt,
+		&i.IsRead,
+		&i.IsSaved,
+	)
+	return i, err
+}
+
+const getFeverItemsBefore = `-- name: GetFeverItemsBefore :many
+select
+    p.serial_id,
+    f.serial_id as feed_serial_id,
+    p.title,
+    p.url,
+    p.description,
+    p.author,
+    p.published_at,
+    p.created_at,
+    (pr.id is not null)::bool as is_read,
+    (ps.id is not null)::bool as is_saved
+from posts p
+join feeds f on f.id = p.feed_id
+join feed_follows ff on ff.feed_id = p.feed_id
+left join post_reads pr on pr.post_id = p.id and pr.user_id = ff.user_id
+left join post_stars ps on ps.post_id = p.id and ps.user_id = ff.user_id
+where ff.user_id = $1 and p.serial_id < $2
+order by p.serial_id desc
+limit $3
+`
+
+type GetFeverItemsBeforeParams struct {
+	UserID    uuid.UUID
+	MaxID     int64
+	PageLimit int32
+}
+
+type GetFeverItemsBeforeRow struct {
+	SerialID     int64
+	FeedSerialID int64
+	Title        sql.NullString
+	Url          string
+	Description  sql.NullString
+	Author       sql.NullString
+	PublishedAt  sql.NullTime
+	CreatedAt    time.Time
+	IsRead       bool
+	IsSaved      bool
+}
+
+func (q *Queries) GetFeverItemsBefore(ctx context.Context, arg GetFeverItemsBeforeParams) ([]GetFeverItemsBeforeRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFeverItemsBefore, arg.UserID, arg.MaxID, arg.PageLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFeverItemsBeforeRow
+	for rows.Next() {
+		var i GetFeverItemsBeforeRow
+		if err := rows.Scan(
+			&i.SerialID,
+			&i.FeedSerialID,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.Author,
+			&i.PublishedAt,
+			&i.CreatedAt,
+			&i.IsRead,
+			&i.IsSaved,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFeverItemsSince = `-- name: GetFeverItemsSince :many
+select
+    p.serial_id,
+    f.serial_id as feed_serial_id,
+    p.title,
+    p.url,
+    p.description,
+    p.author,
+    p.published_at,
+    p.created_at,
+    (pr.id is not null)::bool as is_read,
+    (ps.id is not null)::bool as is_saved
+from posts p
+join feeds f on f.id = p.feed_id
+join feed_follows ff on ff.feed_id = p.feed_id
+left join post_reads pr on pr.post_id = p.id and pr.user_id = ff.user_id
+left join post_stars ps on ps.post_id = p.id and ps.user_id = ff.user_id
+where ff.user_id = $1 and p.serial_id > $2
+order by p.serial_id
+limit $3
+`
+
+type GetFeverItemsSinceParams struct {
+	UserID    uuid.UUID
+	SinceID   int64
+	PageLimit int32
+}
+
+type GetFeverItemsSinceRow struct {
+	SerialID     int64
+	FeedSerialID int64
+	Title        sql.NullString
+	Url          string
+	Description  sql.NullString
+	Author       sql.NullString
+	PublishedAt  sql.NullTime
+	CreatedAt    time.Time
+	IsRead       bool
+	IsSaved      bool
+}
+
+func (q *Queries) GetFeverItemsSince(ctx context.Context, arg GetFeverItemsSinceParams) ([]GetFeverItemsSinceRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFeverItemsSince, arg.UserID, arg.SinceID, arg.PageLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFeverItemsSinceRow
+	for rows.Next() {
+		var i GetFeverItemsSinceRow
+		if err := rows.Scan(
+			&i.SerialID,
+			&i.FeedSerialID,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.Author,
+			&i.PublishedAt,
+			&i.CreatedAt,
+			&i.IsRead,
+			&i.IsSaved,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostByID = `-- name: GetPostByID :one
+select id, created_at, updated_at, title, url, description, published_at, feed_id, serial_id, author, categories from posts
+where id = $1
+limit 1
+`
+
+func (q *Queries) GetPostByID(ctx context.Context, id uuid.UUID) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getPostByID, id)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.SerialID,
+		&i.Author,
+		&i.Categories,
+	)
+	return i, err
+}
+
+const getPostBySerialID = `-- name: GetPostBySerialID :one
+select id, created_at, updated_at, title, url, description, published_at, feed_id, serial_id, author, categories from posts
+where serial_id = $1
+limit 1
+`
+
+func (q *Queries) GetPostBySerialID(ctx context.Context, serialID int64) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getPostBySerialID, serialID)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.SerialID,
+		&i.Author,
+		&i.Categories,
 	)
 	return i, err
 }
 
 const getPostsByUserId = `-- name: GetPostsByUserId :many
-select p.id, p.created_at, p.updated_at, title, url, description, published_at, p.feed_id, ff.id, ff.created_at, ff.updated_at, user_id, ff.feed_id from posts p
+select p.id, p.created_at, p.updated_at, title, url, description, published_at, p.feed_id, serial_id, author, categories, ff.id, ff.created_at, ff.updated_at, user_id, ff.feed_id from posts p
 join feed_follows ff on ff.feed_id = p.feed_id
 where ff.user_id = $1
 order by p.published_at desc nulls last 
@@ -85,6 +504,9 @@ type GetPostsByUserIdRow struct {
 	Description sql.NullString
 	PublishedAt sql.NullTime
 	FeedID      uuid.UUID
+	SerialID    int64
+	Author      sql.NullString
+	Categories  sql.NullString
 	ID_2        uuid.UUID
 	CreatedAt_2 time.Time
 	UpdatedAt_2 time.Time
@@ -110,6 +532,9 @@ func (q *Queries) GetPostsByUserId(ctx context.Context, arg GetPostsByUserIdPara
 			&i.Description,
 			&i.PublishedAt,
 			&i.FeedID,
+			&i.SerialID,
+			&i.Author,
+			&i.Categories,
 			&i.ID_2,
 			&i.CreatedAt_2,
 			&i.UpdatedAt_2,
@@ -128,3 +553,105 @@ func (q *Queries) GetPostsByUserId(ctx context.Context, arg GetPostsByUserIdPara
 	}
 	return items, nil
 }
+
+const getPostsForUser = `-- name: GetPostsForUser :many
+select
+    p.id,
+    p.created_at,
+    p.updated_at,
+    p.title,
+    p.url,
+    p.description,
+    p.published_at,
+    p.feed_id,
+    p.author,
+    p.categories,
+    (pr.id is not null)::bool as read
+from posts p
+join feed_follows ff on ff.feed_id = p.feed_id
+left join post_reads pr on pr.post_id = p.id and pr.user_id = ff.user_id
+where ff.user_id = $1
+and (not $2::bool or pr.id is null)
+order by p.published_at desc nulls last, p.created_at desc
+limit $3 offset $4
+`
+
+type GetPostsForUserParams struct {
+	UserID     uuid.UUID
+	UnreadOnly bool
+	PageLimit  int32
+	PageOffset int32
+}
+
+type GetPostsForUserRow struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       sql.NullString
+	Url         string
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	FeedID      uuid.UUID
+	Author      sql.NullString
+	Categories  sql.NullString
+	Read        bool
+}
+
+func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForUser,
+		arg.UserID,
+		arg.UnreadOnly,
+		arg.PageLimit,
+		arg.PageOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostsForUserRow
+	for rows.Next() {
+		var i GetPostsForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Author,
+			&i.Categories,
+			&i.Read,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const moveFeedPosts = `-- name: MoveFeedPosts :execrows
+update posts
+set feed_id = $1, updated_at = now()
+where feed_id = $2
+`
+
+type MoveFeedPostsParams struct {
+	IntoFeedID uuid.UUID
+	FromFeedID uuid.UUID
+}
+
+func (q *Queries) MoveFeedPosts(ctx context.Context, arg MoveFeedPostsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, moveFeedPosts, arg.IntoFeedID, arg.FromFeedID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,543 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richardteaman/gator/internal/database"
+)
+
+// Querier runs the Postgres query set on SQLite. The SQLite queries return
+// the same shapes as the Postgres ones, so each method converts between
+// the two generated packages and the rest of gator only ever sees
+// database types.
+type Querier struct {
+	q *Queries
+}
+
+var _ database.Querier = (*Querier)(nil)
+
+// NewQuerier wraps a SQLite connection opened by Open.
+func NewQuerier(db DBTX) *Querier {
+	return &Querier{q: New(utcDB{db})}
+}
+
+func convertAll[T, U any](items []T, convert func(T) U) []U {
+	if items == nil {
+		return nil
+	}
+	out := make([]U, len(items))
+	for i, item := range items {
+		out[i] = convert(item)
+	}
+	return out
+}
+
+func (q *Querier) ActivateWebSubSubscription(ctx context.Context, arg database.ActivateWebSubSubscriptionParams) error {
+	return q.q.ActivateWebSubSubscription(ctx, ActivateWebSubSubscriptionParams{
+		LeaseExpiresAt: arg.LeaseExpiresAt,
+		ID:             arg.ID,
+	})
+}
+
+func (q *Querier) CountAdmins(ctx context.Context) (int64, error) {
+	return q.q.CountAdmins(ctx)
+}
+
+func (q *Querier) CountPostsForUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return q.q.CountPostsForUser(ctx, userID)
+}
+
+func (q *Querier) CreateAPIToken(ctx context.Context, arg database.CreateAPITokenParams) (database.ApiToken, error) {
+	v, err := q.q.CreateAPIToken(ctx, CreateAPITokenParams(arg))
+	return database.ApiToken(v), err
+}
+
+func (q *Querier) CreateFeed(ctx context.Context, arg database.CreateFeedParams) (database.Feed, error) {
+	v, err := q.q.CreateFeed(ctx, CreateFeedParams(arg))
+	return database.Feed(v), err
+}
+
+func (q *Querier) CreateFeedFollow(ctx context.Context, arg database.CreateFeedFollowParams) (database.CreateFeedFollowRow, error) {
+	v, err := q.q.CreateFeedFollow(ctx, CreateFeedFollowParams(arg))
+	return database.CreateFeedFollowRow(v), err
+}
+
+func (q *Querier) CreateFeedRedirect(ctx context.Context, arg database.CreateFeedRedirectParams) error {
+	return q.q.CreateFeedRedirect(ctx, CreateFeedRedirectParams(arg))
+}
+
+func (q *Querier) CreatePost(ctx context.Context, arg database.CreatePostParams) (database.Post, error) {
+	v, err := q.q.CreatePost(ctx, CreatePostParams(arg))
+	return database.Post(v), err
+}
+
+func (q *Querier) CreatePostRule(ctx context.Context, arg database.CreatePostRuleParams) (database.PostRule, error) {
+	v, err := q.q.CreatePostRule(ctx, CreatePostRuleParams(arg))
+	return database.PostRule(v), err
+}
+
+func (q *Querier) CreateSession(ctx context.Context, arg database.CreateSessionParams) (database.Session, error) {
+	v, err := q.q.CreateSession(ctx, CreateSessionParams(arg))
+	return database.Session(v), err
+}
+
+func (q *Querier) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	v, err := q.q.CreateUser(ctx, CreateUserParams(arg))
+	return database.User(v), err
+}
+
+func (q *Querier) CreateWebhook(ctx context.Context, arg database.CreateWebhookParams) (database.Webhook, error) {
+	v, err := q.q.CreateWebhook(ctx, CreateWebhookParams(arg))
+	return database.Webhook(v), err
+}
+
+func (q *Querier) CreateWebhookDelivery(ctx context.Context, arg database.CreateWebhookDeliveryParams) error {
+	return q.q.CreateWebhookDelivery(ctx, CreateWebhookDeliveryParams(arg))
+}
+
+func (q *Querier) DeleteExpiredSessions(ctx context.Context) error {
+	return q.q.DeleteExpiredSessions(ctx)
+}
+
+func (q *Querier) DeleteFeed(ctx context.Context, id uuid.UUID) (int64, error) {
+	return q.q.DeleteFeed(ctx, id)
+}
+
+func (q *Querier) DeleteFeedFollowByFeedID(ctx context.Context, arg database.DeleteFeedFollowByFeedIDParams) (int64, error) {
+	return q.q.DeleteFeedFollowByFeedID(ctx, DeleteFeedFollowByFeedIDParams(arg))
+}
+
+func (q *Querier) DeleteFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return q.q.DeleteFeedFollowsForUser(ctx, userID)
+}
+
+func (q *Querier) DeleteFeedRedirect(ctx context.Context, url string) error {
+	return q.q.DeleteFeedRedirect(ctx, url)
+}
+
+func (q *Querier) DeletePasswordReset(ctx context.Context, userID uuid.UUID) error {
+	return q.q.DeletePasswordReset(ctx, userID)
+}
+
+func (q *Querier) DeletePost(ctx context.Context, id uuid.UUID) error {
+	return q.q.DeletePost(ctx, id)
+}
+
+func (q *Querier) DeletePostRule(ctx context.Context, arg database.DeletePostRuleParams) (int64, error) {
+	return q.q.DeletePostRule(ctx, DeletePostRuleParams(arg))
+}
+
+func (q *Querier) DeletePostsOlderThan(ctx context.Context, before time.Time) (int64, error) {
+	return q.q.DeletePostsOlderThan(ctx, before)
+}
+
+func (q *Querier) DeleteQueuedWebhookDelivery(ctx context.Context, id uuid.UUID) error {
+	return q.q.DeleteQueuedWebhookDelivery(ctx, id)
+}
+
+func (q *Querier) DeleteSession(ctx context.Context, tokenHash string) error {
+	return q.q.DeleteSession(ctx, tokenHash)
+}
+
+func (q *Querier) DeleteUser(ctx context.Context, name string) (int64, error) {
+	return q.q.DeleteUser(ctx, name)
+}
+
+func (q *Querier) DeleteUserSessions(ctx context.Context, userID uuid.UUID) error {
+	return q.q.DeleteUserSessions(ctx, userID)
+}
+
+func (q *Querier) DeleteWebSubSubscriptionForFeed(ctx context.Context, feedID uuid.UUID) error {
+	return q.q.DeleteWebSubSubscriptionForFeed(ctx, feedID)
+}
+
+func (q *Querier) DeleteWebhook(ctx context.Context, arg database.DeleteWebhookParams) (int64, error) {
+	return q.q.DeleteWebhook(ctx, DeleteWebhookParams(arg))
+}
+
+func (q *Querier) EnqueueWebhookDelivery(ctx context.Context, arg database.EnqueueWebhookDeliveryParams) error {
+	return q.q.EnqueueWebhookDelivery(ctx, EnqueueWebhookDeliveryParams(arg))
+}
+
+func (q *Querier) GetAPITokenByHash(ctx context.Context, tokenHash string) (database.ApiToken, error) {
+	v, err := q.q.GetAPITokenByHash(ctx, tokenHash)
+	return database.ApiToken(v), err
+}
+
+func (q *Querier) GetAPITokensForUser(ctx context.Context, userID uuid.UUID) ([]database.ApiToken, error) {
+	items, err := q.q.GetAPITokensForUser(ctx, userID)
+	return convertAll(items, func(v ApiToken) database.ApiToken { return database.ApiToken(v) }), err
+}
+
+func (q *Querier) GetDigestPosts(ctx context.Context, arg database.GetDigestPostsParams) ([]database.GetDigestPostsRow, error) {
+	items, err := q.q.GetDigestPosts(ctx, GetDigestPostsParams{
+		UserID:   arg.UserID,
+		Since:    arg.Since,
+		MaxPosts: int64(arg.MaxPosts),
+	})
+	return convertAll(items, func(v GetDigestPostsRow) database.GetDigestPostsRow { return database.GetDigestPostsRow(v) }), err
+}
+
+func (q *Querier) GetDueWebhookDeliveries(ctx context.Context, arg database.GetDueWebhookDeliveriesParams) ([]database.WebhookQueue, error) {
+	items, err := q.q.GetDueWebhookDeliveries(ctx, GetDueWebhookDeliveriesParams{
+		Now:           arg.Now,
+		MaxDeliveries: int64(arg.MaxDeliveries),
+	})
+	return convertAll(items, func(v WebhookQueue) database.WebhookQueue { return database.WebhookQueue(v) }), err
+}
+
+func (q *Querier) GetDuplicatePosts(ctx context.Context, arg database.GetDuplicatePostsParams) ([]database.GetDuplicatePostsRow, error) {
+	items, err := q.q.GetDuplicatePosts(ctx, GetDuplicatePostsParams(arg))
+	return convertAll(items, func(v GetDuplicatePostsRow) database.GetDuplicatePostsRow { return database.GetDuplicatePostsRow(v) }), err
+}
+
+func (q *Querier) GetFeedByCanonicalURL(ctx context.Context, canonicalUrl string) (database.Feed, error) {
+	v, err := q.q.GetFeedByCanonicalURL(ctx, canonicalUrl)
+	return database.Feed(v), err
+}
+
+func (q *Querier) GetFeedByID(ctx context.Context, id uuid.UUID) (database.Feed, error) {
+	v, err := q.q.GetFeedByID(ctx, id)
+	return database.Feed(v), err
+}
+
+func (q *Querier) GetFeedBySerialID(ctx context.Context, serialID int64) (database.Feed, error) {
+	v, err := q.q.GetFeedBySerialID(ctx, serialID)
+	return database.Feed(v), err
+}
+
+func (q *Querier) GetFeedByURL(ctx context.Context, url string) (database.Feed, error) {
+	v, err := q.q.GetFeedByURL(ctx, url)
+	return database.Feed(v), err
+}
+
+func (q *Querier) GetFeedFollowForUserAndFeed(ctx context.Context, arg database.GetFeedFollowForUserAndFeedParams) (database.FeedFollow, error) {
+	v, err := q.q.GetFeedFollowForUserAndFeed(ctx, GetFeedFollowForUserAndFeedParams(arg))
+	return database.FeedFollow(v), err
+}
+
+func (q *Querier) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]database.GetFeedFollowsForUserRow, error) {
+	items, err := q.q.GetFeedFollowsForUser(ctx, userID)
+	return convertAll(items, func(v GetFeedFollowsForUserRow) database.GetFeedFollowsForUserRow {
+		return database.GetFeedFollowsForUserRow(v)
+	}), err
+}
+
+func (q *Querier) GetFeedRedirect(ctx context.Context, url string) (database.FeedRedirect, error) {
+	v, err := q.q.GetFeedRedirect(ctx, url)
+	return database.FeedRedirect(v), err
+}
+
+func (q *Querier) GetFeeds(ctx context.Context) ([]database.Feed, error) {
+	items, err := q.q.GetFeeds(ctx)
+	return convertAll(items, func(v Feed) database.Feed { return database.Feed(v) }), err
+}
+
+func (q *Querier) GetFeedsWithUsers(ctx context.Context) ([]database.GetFeedsWithUsersRow, error) {
+	items, err := q.q.GetFeedsWithUsers(ctx)
+	return convertAll(items, func(v GetFeedsWithUsersRow) database.GetFeedsWithUsersRow { return database.GetFeedsWithUsersRow(v) }), err
+}
+
+func (q *Querier) GetFeverItem(ctx context.Context, arg database.GetFeverItemParams) (database.GetFeverItemRow, error) {
+	v, err := q.q.GetFeverItem(ctx, GetFeverItemParams(arg))
+	return database.GetFeverItemRow(v), err
+}
+
+func (q *Querier) GetFeverItemsBefore(ctx context.Context, arg database.GetFeverItemsBeforeParams) ([]database.GetFeverItemsBeforeRow, error) {
+	items, err := q.q.GetFeverItemsBefore(ctx, GetFeverItemsBeforeParams{
+		UserID:    arg.UserID,
+		MaxID:     arg.MaxID,
+		PageLimit: int64(arg.PageLimit),
+	})
+	return convertAll(items, func(v GetFeverItemsBeforeRow) database.GetFeverItemsBeforeRow {
+		return database.GetFeverItemsBeforeRow(v)
+	}), err
+}
+
+func (q *Querier) GetFeverItemsSince(ctx context.Context, arg database.GetFeverItemsSinceParams) ([]database.GetFeverItemsSinceRow, error) {
+	items, err := q.q.GetFeverItemsSince(ctx, GetFeverItemsSinceParams{
+		UserID:    arg.UserID,
+		SinceID:   arg.SinceID,
+		PageLimit: int64(arg.PageLimit),
+	})
+	return convertAll(items, func(v GetFeverItemsSinceRow) database.GetFeverItemsSinceRow { return database.GetFeverItemsSinceRow(v) }), err
+}
+
+func (q *Querier) GetFollowedFeeds(ctx context.Context, userID uuid.UUID) ([]database.Feed, error) {
+	items, err := q.q.GetFollowedFeeds(ctx, userID)
+	return convertAll(items, func(v Feed) database.Feed { return database.Feed(v) }), err
+}
+
+func (q *Querier) GetNextFeedToFetch(ctx context.Context) (database.Feed, error) {
+	v, err := q.q.GetNextFeedToFetch(ctx)
+	return database.Feed(v), err
+}
+
+func (q *Querier) GetPasswordReset(ctx context.Context, userID uuid.UUID) (database.PasswordReset, error) {
+	v, err := q.q.GetPasswordReset(ctx, userID)
+	return database.PasswordReset(v), err
+}
+
+func (q *Querier) GetPostByID(ctx context.Context, id uuid.UUID) (database.Post, error) {
+	v, err := q.q.GetPostByID(ctx, id)
+	return database.Post(v), err
+}
+
+func (q *Querier) GetPostBySerialID(ctx context.Context, serialID int64) (database.Post, error) {
+	v, err := q.q.GetPostBySerialID(ctx, serialID)
+	return database.Post(v), err
+}
+
+func (q *Querier) GetPostRulesForUser(ctx context.Context, userID uuid.UUID) ([]database.PostRule, error) {
+	items, err := q.q.GetPostRulesForUser(ctx, userID)
+	return convertAll(items, func(v PostRule) database.PostRule { return database.PostRule(v) }), err
+}
+
+func (q *Querier) GetPostsByUserId(ctx context.Context, arg database.GetPostsByUserIdParams) ([]database.GetPostsByUserIdRow, error) {
+	items, err := q.q.GetPostsByUserId(ctx, GetPostsByUserIdParams{
+		UserID: arg.UserID,
+		Limit:  int64(arg.Limit),
+	})
+	return convertAll(items, func(v GetPostsByUserIdRow) database.GetPostsByUserIdRow { return database.GetPostsByUserIdRow(v) }), err
+}
+
+func (q *Querier) GetPostsForUser(ctx context.Context, arg database.GetPostsForUserParams) ([]database.GetPostsForUserRow, error) {
+	items, err := q.q.GetPostsForUser(ctx, GetPostsForUserParams{
+		UserID:     arg.UserID,
+		UnreadOnly: arg.UnreadOnly,
+		PageLimit:  int64(arg.PageLimit),
+		PageOffset: int64(arg.PageOffset),
+	})
+	return convertAll(items, func(v GetPostsForUserRow) database.GetPostsForUserRow { return database.GetPostsForUserRow(v) }), err
+}
+
+func (q *Querier) GetStarredPostSerialIDs(ctx context.Context, userID uuid.UUID) ([]int64, error) {
+	return q.q.GetStarredPostSerialIDs(ctx, userID)
+}
+
+func (q *Querier) GetUnreadPostSerialIDs(ctx context.Context, userID uuid.UUID) ([]int64, error) {
+	return q.q.GetUnreadPostSerialIDs(ctx, userID)
+}
+
+func (q *Querier) GetUser(ctx context.Context, name string) (database.User, error) {
+	v, err := q.q.GetUser(ctx, name)
+	return database.User(v), err
+}
+
+func (q *Querier) GetUserByFeverAPIKey(ctx context.Context, feverApiKey sql.NullString) (database.User, error) {
+	v, err := q.q.GetUserByFeverAPIKey(ctx, feverApiKey)
+	return database.User(v), err
+}
+
+func (q *Querier) GetUserById(ctx context.Context, id uuid.UUID) (database.User, error) {
+	v, err := q.q.GetUserById(ctx, id)
+	return database.User(v), err
+}
+
+func (q *Querier) GetUserBySessionToken(ctx context.Context, tokenHash string) (database.User, error) {
+	v, err := q.q.GetUserBySessionToken(ctx, tokenHash)
+	return database.User(v), err
+}
+
+func (q *Querier) GetUsers(ctx context.Context) ([]database.User, error) {
+	items, err := q.q.GetUsers(ctx)
+	return convertAll(items, func(v User) database.User { return database.User(v) }), err
+}
+
+func (q *Querier) GetUsersWithoutPassword(ctx context.Context) ([]database.User, error) {
+	items, err := q.q.GetUsersWithoutPassword(ctx)
+	return convertAll(items, func(v User) database.User { return database.User(v) }), err
+}
+
+func (q *Querier) GetWebSubSubscription(ctx context.Context, id uuid.UUID) (database.WebsubSubscription, error) {
+	v, err := q.q.GetWebSubSubscription(ctx, id)
+	return database.WebsubSubscription(v), err
+}
+
+func (q *Querier) GetWebSubSubscriptionsDue(ctx context.Context, arg database.GetWebSubSubscriptionsDueParams) ([]database.WebsubSubscription, error) {
+	items, err := q.q.GetWebSubSubscriptionsDue(ctx, GetWebSubSubscriptionsDueParams(arg))
+	return convertAll(items, func(v WebsubSubscription) database.WebsubSubscription { return database.WebsubSubscription(v) }), err
+}
+
+func (q *Querier) GetWebhook(ctx context.Context, id uuid.UUID) (database.Webhook, error) {
+	v, err := q.q.GetWebhook(ctx, id)
+	return database.Webhook(v), err
+}
+
+func (q *Querier) GetWebhookDeliveries(ctx context.Context, arg database.GetWebhookDeliveriesParams) ([]database.WebhookDelivery, error) {
+	items, err := q.q.GetWebhookDeliveries(ctx, GetWebhookDeliveriesParams{
+		WebhookID: arg.WebhookID,
+		Limit:     int64(arg.Limit),
+	})
+	return convertAll(items, func(v WebhookDelivery) database.WebhookDelivery { return database.WebhookDelivery(v) }), err
+}
+
+func (q *Querier) GetWebhookForUser(ctx context.Context, arg database.GetWebhookForUserParams) (database.Webhook, error) {
+	v, err := q.q.GetWebhookForUser(ctx, GetWebhookForUserParams(arg))
+	return database.Webhook(v), err
+}
+
+func (q *Querier) GetWebhooksForFeed(ctx context.Context, feedID uuid.UUID) ([]database.Webhook, error) {
+	items, err := q.q.GetWebhooksForFeed(ctx, feedID)
+	return convertAll(items, func(v Webhook) database.Webhook { return database.Webhook(v) }), err
+}
+
+func (q *Querier) GetWebhooksForUser(ctx context.Context, userID uuid.UUID) ([]database.Webhook, error) {
+	items, err := q.q.GetWebhooksForUser(ctx, userID)
+	return convertAll(items, func(v Webhook) database.Webhook { return database.Webhook(v) }), err
+}
+
+func (q *Querier) ListFeeds(ctx context.Context, arg database.ListFeedsParams) ([]database.Feed, error) {
+	items, err := q.q.ListFeeds(ctx, ListFeedsParams{
+		Limit:  int64(arg.Limit),
+		Offset: int64(arg.Offset),
+	})
+	return convertAll(items, func(v Feed) database.Feed { return database.Feed(v) }), err
+}
+
+func (q *Querier) ListUsers(ctx context.Context, arg database.ListUsersParams) ([]database.User, error) {
+	items, err := q.q.ListUsers(ctx, ListUsersParams{
+		Limit:  int64(arg.Limit),
+		Offset: int64(arg.Offset),
+	})
+	return convertAll(items, func(v User) database.User { return database.User(v) }), err
+}
+
+func (q *Querier) MarkAllPostsRead(ctx context.Context, arg database.MarkAllPostsReadParams) error {
+	return q.q.MarkAllPostsRead(ctx, MarkAllPostsReadParams(arg))
+}
+
+func (q *Querier) MarkFeedFetched(ctx context.Context, id uuid.UUID) error {
+	return q.q.MarkFeedFetched(ctx, id)
+}
+
+func (q *Querier) MarkFeedPostsRead(ctx context.Context, arg database.MarkFeedPostsReadParams) error {
+	return q.q.MarkFeedPostsRead(ctx, MarkFeedPostsReadParams(arg))
+}
+
+func (q *Querier) MarkPostRead(ctx context.Context, arg database.MarkPostReadParams) error {
+	return q.q.MarkPostRead(ctx, MarkPostReadParams(arg))
+}
+
+func (q *Querier) MarkPostUnread(ctx context.Context, arg database.MarkPostUnreadParams) error {
+	return q.q.MarkPostUnread(ctx, MarkPostUnreadParams(arg))
+}
+
+func (q *Querier) MarkWebSubRequested(ctx context.Context, id uuid.UUID) error {
+	return q.q.MarkWebSubRequested(ctx, id)
+}
+
+func (q *Querier) MoveFeedFollows(ctx context.Context, arg database.MoveFeedFollowsParams) (int64, error) {
+	return q.q.MoveFeedFollows(ctx, MoveFeedFollowsParams(arg))
+}
+
+func (q *Querier) MoveFeedPostRules(ctx context.Context, arg database.MoveFeedPostRulesParams) (int64, error) {
+	return q.q.MoveFeedPostRules(ctx, MoveFeedPostRulesParams(arg))
+}
+
+func (q *Querier) MoveFeedPosts(ctx context.Context, arg database.MoveFeedPostsParams) (int64, error) {
+	return q.q.MoveFeedPosts(ctx, MoveFeedPostsParams(arg))
+}
+
+func (q *Querier) MoveFeedRedirects(ctx context.Context, arg database.MoveFeedRedirectsParams) (int64, error) {
+	return q.q.MoveFeedRedirects(ctx, MoveFeedRedirectsParams(arg))
+}
+
+func (q *Querier) MoveFeedWebhooks(ctx context.Context, arg database.MoveFeedWebhooksParams) (int64, error) {
+	return q.q.MoveFeedWebhooks(ctx, MoveFeedWebhooksParams(arg))
+}
+
+func (q *Querier) MovePostReads(ctx context.Context, arg database.MovePostReadsParams) error {
+	return q.q.MovePostReads(ctx, MovePostReadsParams(arg))
+}
+
+func (q *Querier) MovePostStars(ctx context.Context, arg database.MovePostStarsParams) error {
+	return q.q.MovePostStars(ctx, MovePostStarsParams(arg))
+}
+
+func (q *Querier) RenameFeed(ctx context.Context, arg database.RenameFeedParams) (database.Feed, error) {
+	v, err := q.q.RenameFeed(ctx, RenameFeedParams{
+		Name: arg.Name,
+		ID:   arg.ID,
+	})
+	return database.Feed(v), err
+}
+
+func (q *Querier) RescheduleWebhookDelivery(ctx context.Context, arg database.RescheduleWebhookDeliveryParams) error {
+	return q.q.RescheduleWebhookDelivery(ctx, RescheduleWebhookDeliveryParams(arg))
+}
+
+func (q *Querier) ResetUsers(ctx context.Context) error {
+	return q.q.ResetUsers(ctx)
+}
+
+func (q *Querier) RevokeAPIToken(ctx context.Context, arg database.RevokeAPITokenParams) (int64, error) {
+	return q.q.RevokeAPIToken(ctx, RevokeAPITokenParams(arg))
+}
+
+func (q *Querier) SetFeedURL(ctx context.Context, arg database.SetFeedURLParams) (database.Feed, error) {
+	v, err := q.q.SetFeedURL(ctx, SetFeedURLParams{
+		Url:          arg.Url,
+		CanonicalUrl: arg.CanonicalUrl,
+		ID:           arg.ID,
+	})
+	return database.Feed(v), err
+}
+
+func (q *Querier) SetFeverAPIKey(ctx context.Context, arg database.SetFeverAPIKeyParams) error {
+	return q.q.SetFeverAPIKey(ctx, SetFeverAPIKeyParams{
+		FeverApiKey: arg.FeverApiKey,
+		ID:          arg.ID,
+	})
+}
+
+func (q *Querier) SetLastDigestAt(ctx context.Context, arg database.SetLastDigestAtParams) error {
+	return q.q.SetLastDigestAt(ctx, SetLastDigestAtParams{
+		LastDigestAt: arg.LastDigestAt,
+		ID:           arg.ID,
+	})
+}
+
+func (q *Querier) SetPasswordReset(ctx context.Context, arg database.SetPasswordResetParams) error {
+	return q.q.SetPasswordReset(ctx, SetPasswordResetParams(arg))
+}
+
+func (q *Querier) SetUserPassword(ctx context.Context, arg database.SetUserPasswordParams) error {
+	return q.q.SetUserPassword(ctx, SetUserPasswordParams{
+		PasswordHash: arg.PasswordHash,
+		ID:           arg.ID,
+	})
+}
+
+func (q *Querier) SetUserRole(ctx context.Context, arg database.SetUserRoleParams) error {
+	return q.q.SetUserRole(ctx, SetUserRoleParams{
+		Role: arg.Role,
+		ID:   arg.ID,
+	})
+}
+
+func (q *Querier) SetWebSubState(ctx context.Context, arg database.SetWebSubStateParams) error {
+	return q.q.SetWebSubState(ctx, SetWebSubStateParams{
+		State: arg.State,
+		ID:    arg.ID,
+	})
+}
+
+func (q *Querier) StarPost(ctx context.Context, arg database.StarPostParams) error {
+	return q.q.StarPost(ctx, StarPostParams(arg))
+}
+
+func (q *Querier) TouchAPIToken(ctx context.Context, id uuid.UUID) error {
+	return q.q.TouchAPIToken(ctx, id)
+}
+
+func (q *Querier) UnstarPost(ctx context.Context, arg database.UnstarPostParams) error {
+	return q.q.UnstarPost(ctx, UnstarPostParams(arg))
+}
+
+func (q *Querier) UpsertWebSubSubscription(ctx context.Context, arg database.UpsertWebSubSubscriptionParams) error {
+	return q.q.UpsertWebSubSubscription(ctx, UpsertWebSubSubscriptionParams(arg))
+}
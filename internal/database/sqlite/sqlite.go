@@ -0,0 +1,61 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Open opens the SQLite database file at path, creating it if needed.
+// Foreign keys are off by default in SQLite, and every delete relies on
+// their cascades, so they are switched on for each connection.
+func Open(path string) (*sql.DB, error) {
+	params := url.Values{}
+	params.Set("_foreign_keys", "on")
+	params.Set("_busy_timeout", "5000")
+	params.Set("_journal_mode", "WAL")
+	// the path is escaped since SQLite reads the DSN as a URI, where a ?
+	// or # in a file name would start the options and % escapes a byte
+	dsn := url.URL{
+		Scheme:   "file",
+		Opaque:   (&url.URL{Path: path}).EscapedPath(),
+		RawQuery: params.Encode(),
+	}
+	return sql.Open("sqlite3", dsn.String())
+}
+
+// utcDB stores every time in UTC. SQLite keeps timestamps as text and
+// compares them as strings, which only orders correctly when they share
+// an offset, including the ones the queries take from strftime('now').
+type utcDB struct {
+	DBTX
+}
+
+func (db utcDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return db.DBTX.ExecContext(ctx, query, toUTC(args)...)
+}
+
+func (db utcDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return db.DBTX.QueryContext(ctx, query, toUTC(args)...)
+}
+
+func (db utcDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return db.DBTX.QueryRowContext(ctx, query, toUTC(args)...)
+}
+
+func toUTC(args []interface{}) []interface{} {
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case time.Time:
+			args[i] = v.UTC()
+		case sql.NullTime:
+			if v.Valid {
+				args[i] = sql.NullTime{Time: v.Time.UTC(), Valid: true}
+			}
+		}
+	}
+	return args
+}
@@ -0,0 +1,31 @@
+package sqlite
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenPath(t *testing.T) {
+	for _, name := range []string{"gator.db", "what?.db", "a#b.db", "100%25.db", "with space.db", "_foreign_keys=off&x.db"} {
+		path := filepath.Join(t.TempDir(), "dir?#", name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		db, err := Open(path)
+		if err != nil {
+			t.Fatalf("Open(%q): %v", path, err)
+		}
+		var foreignKeys int
+		if err := db.QueryRow("pragma foreign_keys").Scan(&foreignKeys); err != nil {
+			t.Fatalf("Open(%q): %v", path, err)
+		}
+		db.Close()
+		if foreignKeys != 1 {
+			t.Errorf("Open(%q) left foreign keys off", path)
+		}
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Open(%q) didn't create the file under its name: %v", path, err)
+		}
+	}
+}
@@ -7,27 +7,42 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const countAdmins = `-- name: CountAdmins :one
+select count(*) from users
+where role = 'admin'
+`
+
+func (q *Queries) CountAdmins(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAdmins)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createUser = `-- name: CreateUser :one
-insert into users (id,created_at,updated_at,name)
+insert into users (id,created_at,updated_at,name,password_hash)
 values(
     $1,
     $2,
     $3,
-    $4
+    $4,
+    $5
 )
-returning id, created_at, updated_at, name
+returning id, created_at, updated_at, name, fever_api_key, last_digest_at, password_hash, role
 `
 
 type CreateUserParams struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Name      string
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Name         string
+	PasswordHash sql.NullString
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
@@ -36,6 +51,7 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		arg.CreatedAt,
 		arg.UpdatedAt,
 		arg.Name,
+		arg.PasswordHash,
 	)
 	var i User
 	err := row.Scan(
@@ -43,12 +59,29 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.FeverApiKey,
+		&i.LastDigestAt,
+		&i.PasswordHash,
+		&i.Role,
 	)
 	return i, err
 }
 
+const deleteUser = `-- name: DeleteUser :execrows
+delete from users
+where name = $1
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, name string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUser, name)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getUser = `-- name: GetUser :one
-select id, created_at, updated_at, name from  users
+select id, created_at, updated_at, name, fever_api_key, last_digest_at, password_hash, role from  users
 where name = $1
 limit 1
 `
@@ -61,12 +94,38 @@ func (q *Queries) GetUser(ctx context.Context, name string) (User, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.FeverApiKey,
+		&i.LastDigestAt,
+		&i.PasswordHash,
+		&i.Role,
+	)
+	return i, err
+}
+
+const getUserByFeverAPIKey = `-- name: GetUserByFeverAPIKey :one
+select id, created_at, updated_at, name, fever_api_key, last_digest_at, password_hash, role from users
+where fever_api_key = $1
+limit 1
+`
+
+func (q *Queries) GetUserByFeverAPIKey(ctx context.Context, feverApiKey sql.NullString) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByFeverAPIKey, feverApiKey)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.FeverApiKey,
+		&i.LastDigestAt,
+		&i.PasswordHash,
+		&i.Role,
 	)
 	return i, err
 }
 
 const getUserById = `-- name: GetUserById :one
-select id, created_at, updated_at, name from users 
+select id, created_at, updated_at, name, fever_api_key, last_digest_at, password_hash, role from users 
 where id = $1
 limit 1
 `
@@ -79,12 +138,16 @@ func (q *Queries) GetUserById(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.FeverApiKey,
+		&i.LastDigestAt,
+		&i.PasswordHash,
+		&i.Role,
 	)
 	return i, err
 }
 
 const getUsers = `-- name: GetUsers :many
-select id, created_at, updated_at, name from users
+select id, created_at, updated_at, name, fever_api_key, last_digest_at, password_hash, role from users
 `
 
 func (q *Queries) GetUsers(ctx context.Context) ([]User, error) {
@@ -101,6 +164,91 @@ func (q *Queries) GetUsers(ctx context.Context) ([]User, error) {
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.Name,
+			&i.FeverApiKey,
+			&i.LastDigestAt,
+			&i.PasswordHash,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUsersWithoutPassword = `-- name: GetUsersWithoutPassword :many
+select id, created_at, updated_at, name, fever_api_key, last_digest_at, password_hash, role from users
+where password_hash is null
+order by created_at
+`
+
+func (q *Queries) GetUsersWithoutPassword(ctx context.Context) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersWithoutPassword)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.FeverApiKey,
+			&i.LastDigestAt,
+			&i.PasswordHash,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsers = `-- name: ListUsers :many
+select id, created_at, updated_at, name, fever_api_key, last_digest_at, password_hash, role from users
+order by created_at
+limit $1 offset $2
+`
+
+type ListUsersParams struct {
+	Limit  int32
+	Offset int32
+}
+
+func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsers, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.FeverApiKey,
+			&i.LastDigestAt,
+			&i.PasswordHash,
+			&i.Role,
 		); err != nil {
 			return nil, err
 		}
@@ -123,3 +271,67 @@ func (q *Queries) ResetUsers(ctx context.Context) error {
 	_, err := q.db.ExecContext(ctx, resetUsers)
 	return err
 }
+
+const setFeverAPIKey = `-- name: SetFeverAPIKey :exec
+update users
+set fever_api_key = $2, updated_at = now()
+where id = $1
+`
+
+type SetFeverAPIKeyParams struct {
+	ID          uuid.UUID
+	FeverApiKey sql.NullString
+}
+
+func (q *Queries) SetFeverAPIKey(ctx context.Context, arg SetFeverAPIKeyParams) error {
+	_, err := q.db.ExecContext(ctx, setFeverAPIKey, arg.ID, arg.FeverApiKey)
+	return err
+}
+
+const setLastDigestAt = `-- name: SetLastDigestAt :exec
+update users
+set last_digest_at = $2, updated_at = now()
+where id = $1
+`
+
+type SetLastDigestAtParams struct {
+	ID           uuid.UUID
+	LastDigestAt sql.NullTime
+}
+
+func (q *Queries) SetLastDigestAt(ctx context.Context, arg SetLastDigestAtParams) error {
+	_, err := q.db.ExecContext(ctx, setLastDigestAt, arg.ID, arg.LastDigestAt)
+	return err
+}
+
+const setUserPassword = `-- name: SetUserPassword :exec
+update users
+set password_hash = $2, updated_at = now()
+where id = $1
+`
+
+type SetUserPasswordParams struct {
+	ID           uuid.UUID
+	PasswordHash sql.NullString
+}
+
+func (q *Queries) SetUserPassword(ctx context.Context, arg SetUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, setUserPassword, arg.ID, arg.PasswordHash)
+	return err
+}
+
+const setUserRole = `-- name: SetUserRole :exec
+update users
+set role = $2, updated_at = now()
+where id = $1
+`
+
+type SetUserRoleParams struct {
+	ID   uuid.UUID
+	Role string
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) error {
+	_, err := q.db.ExecContext(ctx, setUserRole, arg.ID, arg.Role)
+	return err
+}
@@ -0,0 +1,241 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richardteaman/gator/internal/database"
+)
+
+// Memory is a Store that keeps its tables in slices. It enforces the same
+// unique, foreign key and check constraints as the schema and cascades
+// deletes the way the foreign keys do, so code behaves as it would
+// against a database. Rows keep insertion order, which is the order
+// Postgres returns unordered queries in for a table nobody updates.
+type Memory struct {
+	mu sync.Mutex
+
+	users      []database.User
+	feeds      []database.Feed
+	redirects  []database.FeedRedirect
+	follows    []database.FeedFollow
+	posts      []database.Post
+	reads      []database.PostRead
+	stars      []database.PostStar
+	websubs    []database.WebsubSubscription
+	webhooks   []database.Webhook
+	deliveries []database.WebhookDelivery
+	queue      []database.WebhookQueue
+	rules      []database.PostRule
+	sessions   []database.Session
+	resets     []database.PasswordReset
+	tokens     []database.ApiToken
+
+	feedSerial int64
+	postSerial int64
+
+	// now stands in for the database clock.
+	now func() time.Time
+}
+
+// NewMemory returns an empty store.
+func NewMemory() *Memory {
+	return &Memory{now: time.Now}
+}
+
+// SetClock replaces the clock used where the queries call now(), so tests
+// can control expiry and fetch order.
+func (m *Memory) SetClock(now func() time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = now
+}
+
+func uniqueViolation(constraint string) error {
+	return fmt.Errorf("%w %q", ErrUniqueViolation, constraint)
+}
+
+func foreignKeyViolation(constraint string) error {
+	return fmt.Errorf("%w %q", ErrForeignKeyViolation, constraint)
+}
+
+func checkViolation(constraint string) error {
+	return fmt.Errorf("%w %q", ErrCheckViolation, constraint)
+}
+
+func find[T any](items []T, match func(T) bool) (T, bool) {
+	for _, item := range items {
+		if match(item) {
+			return item, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+func filter[T any](items []T, match func(T) bool) []T {
+	var out []T
+	for _, item := range items {
+		if match(item) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// partition splits items into the ones to keep and the ones match removes.
+func partition[T any](items []T, match func(T) bool) (kept, removed []T) {
+	kept = items[:0:0]
+	for _, item := range items {
+		if match(item) {
+			removed = append(removed, item)
+		} else {
+			kept = append(kept, item)
+		}
+	}
+	return kept, removed
+}
+
+func one[T any](item T, ok bool) (T, error) {
+	if !ok {
+		return item, sql.ErrNoRows
+	}
+	return item, nil
+}
+
+// page applies limit and offset like SQL does.
+func page[T any](items []T, limit, offset int32) []T {
+	if offset > 0 {
+		if int(offset) >= len(items) {
+			return nil
+		}
+		items = items[offset:]
+	}
+	if limit >= 0 && int(limit) < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+// compareNullTimeDesc orders newest first with nulls last.
+func compareNullTimeDesc(a, b sql.NullTime) int {
+	switch {
+	case a.Valid && b.Valid:
+		return b.Time.Compare(a.Time)
+	case a.Valid:
+		return -1
+	case b.Valid:
+		return 1
+	}
+	return 0
+}
+
+func (m *Memory) userExists(id uuid.UUID) bool {
+	return slices.ContainsFunc(m.users, func(u database.User) bool { return u.ID == id })
+}
+
+func (m *Memory) feedExists(id uuid.UUID) bool {
+	return slices.ContainsFunc(m.feeds, func(f database.Feed) bool { return f.ID == id })
+}
+
+func (m *Memory) postExists(id uuid.UUID) bool {
+	return slices.ContainsFunc(m.posts, func(p database.Post) bool { return p.ID == id })
+}
+
+func (m *Memory) feedByID(id uuid.UUID) (database.Feed, bool) {
+	return find(m.feeds, func(f database.Feed) bool { return f.ID == id })
+}
+
+func (m *Memory) userByID(id uuid.UUID) (database.User, bool) {
+	return find(m.users, func(u database.User) bool { return u.ID == id })
+}
+
+func (m *Memory) isFollowing(userID, feedID uuid.UUID) bool {
+	return slices.ContainsFunc(m.follows, func(ff database.FeedFollow) bool {
+		return ff.UserID == userID && ff.FeedID == feedID
+	})
+}
+
+func (m *Memory) isRead(userID, postID uuid.UUID) bool {
+	return slices.ContainsFunc(m.reads, func(r database.PostRead) bool {
+		return r.UserID == userID && r.PostID == postID
+	})
+}
+
+func (m *Memory) isStarred(userID, postID uuid.UUID) bool {
+	return slices.ContainsFunc(m.stars, func(s database.PostStar) bool {
+		return s.UserID == userID && s.PostID == postID
+	})
+}
+
+// followedPosts are the posts of every feed userID follows, in insertion
+// order.
+func (m *Memory) followedPosts(userID uuid.UUID) []database.Post {
+	return filter(m.posts, func(p database.Post) bool { return m.isFollowing(userID, p.FeedID) })
+}
+
+// The delete helpers follow the "on delete cascade" foreign keys.
+
+func (m *Memory) deleteUsers(match func(database.User) bool) int64 {
+	var removed []database.User
+	m.users, removed = partition(m.users, match)
+	for _, u := range removed {
+		m.deleteFeeds(func(f database.Feed) bool { return f.UserID == u.ID })
+		m.follows, _ = partition(m.follows, func(ff database.FeedFollow) bool { return ff.UserID == u.ID })
+		m.reads, _ = partition(m.reads, func(r database.PostRead) bool { return r.UserID == u.ID })
+		m.stars, _ = partition(m.stars, func(s database.PostStar) bool { return s.UserID == u.ID })
+		m.deleteWebhooks(func(w database.Webhook) bool { return w.UserID == u.ID })
+		m.rules, _ = partition(m.rules, func(r database.PostRule) bool { return r.UserID == u.ID })
+		m.sessions, _ = partition(m.sessions, func(s database.Session) bool { return s.UserID == u.ID })
+		m.resets, _ = partition(m.resets, func(r database.PasswordReset) bool { return r.UserID == u.ID })
+		m.tokens, _ = partition(m.tokens, func(t database.ApiToken) bool { return t.UserID == u.ID })
+	}
+	return int64(len(removed))
+}
+
+func (m *Memory) deleteFeeds(match func(database.Feed) bool) int64 {
+	var removed []database.Feed
+	m.feeds, removed = partition(m.feeds, match)
+	for _, f := range removed {
+		m.follows, _ = partition(m.follows, func(ff database.FeedFollow) bool { return ff.FeedID == f.ID })
+		m.deletePosts(func(p database.Post) bool { return p.FeedID == f.ID })
+		m.websubs, _ = partition(m.websubs, func(ws database.WebsubSubscription) bool { return ws.FeedID == f.ID })
+		m.deleteWebhooks(func(w database.Webhook) bool { return w.FeedID.Valid && w.FeedID.UUID == f.ID })
+		m.rules, _ = partition(m.rules, func(r database.PostRule) bool { return r.FeedID.Valid && r.FeedID.UUID == f.ID })
+		m.redirects, _ = partition(m.redirects, func(r database.FeedRedirect) bool { return r.FeedID == f.ID })
+	}
+	return int64(len(removed))
+}
+
+func (m *Memory) deletePosts(match func(database.Post) bool) int64 {
+	var removed []database.Post
+	m.posts, removed = partition(m.posts, match)
+	for _, p := range removed {
+		m.reads, _ = partition(m.reads, func(r database.PostRead) bool { return r.PostID == p.ID })
+		m.stars, _ = partition(m.stars, func(s database.PostStar) bool { return s.PostID == p.ID })
+		m.deliveries, _ = partition(m.deliveries, func(d database.WebhookDelivery) bool { return d.PostID == p.ID })
+		m.queue, _ = partition(m.queue, func(q database.WebhookQueue) bool { return q.PostID == p.ID })
+	}
+	return int64(len(removed))
+}
+
+func (m *Memory) deleteWebhooks(match func(database.Webhook) bool) int64 {
+	var removed []database.Webhook
+	m.webhooks, removed = partition(m.webhooks, match)
+	for _, w := range removed {
+		m.deliveries, _ = partition(m.deliveries, func(d database.WebhookDelivery) bool { return d.WebhookID == w.ID })
+		m.queue, _ = partition(m.queue, func(q database.WebhookQueue) bool { return q.WebhookID == w.ID })
+	}
+	return int64(len(removed))
+}
+
+// sortedBy returns a sorted copy, keeping insertion order among equals.
+func sortedBy[T any](items []T, compare func(a, b T) int) []T {
+	items = slices.Clone(items)
+	slices.SortStableFunc(items, compare)
+	return items
+}
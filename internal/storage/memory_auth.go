@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/richardteaman/gator/internal/database"
+)
+
+func (m *Memory) CreateSession(ctx context.Context, arg database.CreateSessionParams) (database.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if slices.ContainsFunc(m.sessions, func(s database.Session) bool { return s.ID == arg.ID }) {
+		return database.Session{}, uniqueViolation("sessions_pkey")
+	}
+	if slices.ContainsFunc(m.sessions, func(s database.Session) bool { return s.TokenHash == arg.TokenHash }) {
+		return database.Session{}, uniqueViolation("sessions_token_hash_key")
+	}
+	if !m.userExists(arg.UserID) {
+		return database.Session{}, foreignKeyViolation("sessions_user_id_fkey")
+	}
+	session := database.Session(arg)
+	m.sessions = append(m.sessions, session)
+	return session, nil
+}
+
+func (m *Memory) GetUserBySessionToken(ctx context.Context, tokenHash string) (database.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	session, ok := find(m.sessions, func(s database.Session) bool {
+		return s.TokenHash == tokenHash && s.ExpiresAt.After(now)
+	})
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	return one(m.userByID(session.UserID))
+}
+
+func (m *Memory) DeleteSession(ctx context.Context, tokenHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions, _ = partition(m.sessions, func(s database.Session) bool { return s.TokenHash == tokenHash })
+	return nil
+}
+
+func (m *Memory) DeleteUserSessions(ctx context.Context, userID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions, _ = partition(m.sessions, func(s database.Session) bool { return s.UserID == userID })
+	return nil
+}
+
+func (m *Memory) DeleteExpiredSessions(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	m.sessions, _ = partition(m.sessions, func(s database.Session) bool { return !s.ExpiresAt.After(now) })
+	return nil
+}
+
+// SetPasswordReset replaces the user's reset token if there is one, like
+// "on conflict (user_id) do update".
+func (m *Memory) SetPasswordReset(ctx context.Context, arg database.SetPasswordResetParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if slices.ContainsFunc(m.resets, func(r database.PasswordReset) bool {
+		return r.TokenHash == arg.TokenHash && r.UserID != arg.UserID
+	}) {
+		return uniqueViolation("password_resets_token_hash_key")
+	}
+	if !m.userExists(arg.UserID) {
+		return foreignKeyViolation("password_resets_user_id_fkey")
+	}
+	reset := database.PasswordReset(arg)
+	if i := slices.IndexFunc(m.resets, func(r database.PasswordReset) bool { return r.UserID == arg.UserID }); i >= 0 {
+		m.resets[i] = reset
+	} else {
+		m.resets = append(m.resets, reset)
+	}
+	return nil
+}
+
+func (m *Memory) GetPasswordReset(ctx context.Context, userID uuid.UUID) (database.PasswordReset, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return one(find(m.resets, func(r database.PasswordReset) bool { return r.UserID == userID }))
+}
+
+func (m *Memory) DeletePasswordReset(ctx context.Context, userID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.resets, _ = partition(m.resets, func(r database.PasswordReset) bool { return r.UserID == userID })
+	return nil
+}
+
+func (m *Memory) CreateAPIToken(ctx context.Context, arg database.CreateAPITokenParams) (database.ApiToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if slices.ContainsFunc(m.tokens, func(t database.ApiToken) bool { return t.ID == arg.ID }) {
+		return database.ApiToken{}, uniqueViolation("api_tokens_pkey")
+	}
+	if slices.ContainsFunc(m.tokens, func(t database.ApiToken) bool { return t.TokenHash == arg.TokenHash }) {
+		return database.ApiToken{}, uniqueViolation("api_tokens_token_hash_key")
+	}
+	if slices.ContainsFunc(m.tokens, func(t database.ApiToken) bool { return t.UserID == arg.UserID && t.Name == arg.Name }) {
+		return database.ApiToken{}, uniqueViolation("api_tokens_user_id_name_key")
+	}
+	if !m.userExists(arg.UserID) {
+		return database.ApiToken{}, foreignKeyViolation("api_tokens_user_id_fkey")
+	}
+	token := database.ApiToken{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		UserID:    arg.UserID,
+		Name:      arg.Name,
+		TokenHash: arg.TokenHash,
+		Scopes:    arg.Scopes,
+		ExpiresAt: arg.ExpiresAt,
+	}
+	m.tokens = append(m.tokens, token)
+	return token, nil
+}
+
+func (m *Memory) GetAPITokensForUser(ctx context.Context, userID uuid.UUID) ([]database.ApiToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tokens := filter(m.tokens, func(t database.ApiToken) bool { return t.UserID == userID })
+	return sortedBy(tokens, func(a, b database.ApiToken) int { return a.CreatedAt.Compare(b.CreatedAt) }), nil
+}
+
+func (m *Memory) GetAPITokenByHash(ctx context.Context, tokenHash string) (database.ApiToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	return one(find(m.tokens, func(t database.ApiToken) bool {
+		return t.TokenHash == tokenHash && (!t.ExpiresAt.Valid || t.ExpiresAt.Time.After(now))
+	}))
+}
+
+func (m *Memory) TouchAPIToken(ctx context.Context, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := slices.IndexFunc(m.tokens, func(t database.ApiToken) bool { return t.ID == id })
+	if i >= 0 {
+		m.tokens[i].LastUsedAt = sql.NullTime{Time: m.now(), Valid: true}
+	}
+	return nil
+}
+
+func (m *Memory) RevokeAPIToken(ctx context.Context, arg database.RevokeAPITokenParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var removed []database.ApiToken
+	m.tokens, removed = partition(m.tokens, func(t database.ApiToken) bool {
+		return t.UserID == arg.UserID && t.Name == arg.Name
+	})
+	return int64(len(removed)), nil
+}
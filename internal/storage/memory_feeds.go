@@ -0,0 +1,337 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/richardteaman/gator/internal/database"
+)
+
+func (m *Memory) CreateFeed(ctx context.Context, arg database.CreateFeedParams) (database.Feed, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.feedByID(arg.ID); ok {
+		return database.Feed{}, uniqueViolation("feeds_pkey")
+	}
+	if slices.ContainsFunc(m.feeds, func(f database.Feed) bool { return f.Url == arg.Url }) {
+		return database.Feed{}, uniqueViolation("feeds_url_key")
+	}
+	if m.canonicalURLTaken(arg.CanonicalUrl, arg.ID) {
+		return database.Feed{}, uniqueViolation("feeds_canonical_url_key")
+	}
+	if !m.userExists(arg.UserID) {
+		return database.Feed{}, foreignKeyViolation("feeds_user_id_fkey")
+	}
+	m.feedSerial++
+	feed := database.Feed{
+		ID:           arg.ID,
+		CreatedAt:    arg.CreatedAt,
+		UpdatedAt:    arg.UpdatedAt,
+		Name:         arg.Name,
+		Url:          arg.Url,
+		UserID:       arg.UserID,
+		SerialID:     m.feedSerial,
+		CanonicalUrl: arg.CanonicalUrl,
+	}
+	m.feeds = append(m.feeds, feed)
+	return feed, nil
+}
+
+func (m *Memory) GetFeeds(ctx context.Context) ([]database.Feed, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.feeds), nil
+}
+
+func (m *Memory) GetFeedsWithUsers(ctx context.Context) ([]database.GetFeedsWithUsersRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var rows []database.GetFeedsWithUsersRow
+	for _, f := range m.feeds {
+		u, _ := m.userByID(f.UserID)
+		rows = append(rows, database.GetFeedsWithUsersRow{
+			FeedID:        f.ID,
+			FeedName:      f.Name,
+			FeedUrl:       f.Url,
+			UserID:        u.ID,
+			UserName:      u.Name,
+			LastFetchedAt: f.LastFetchedAt,
+		})
+	}
+	return rows, nil
+}
+
+func (m *Memory) GetFeedByURL(ctx context.Context, url string) (database.Feed, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return one(find(m.feeds, func(f database.Feed) bool { return f.Url == url }))
+}
+
+func (m *Memory) GetFeedByCanonicalURL(ctx context.Context, canonicalUrl string) (database.Feed, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return one(find(m.feeds, func(f database.Feed) bool { return f.CanonicalUrl.Valid && f.CanonicalUrl.String == canonicalUrl }))
+}
+
+// canonicalURLTaken reports whether a feed other than id has canonical,
+// which like a unique index never counts a null.
+func (m *Memory) canonicalURLTaken(canonical sql.NullString, id uuid.UUID) bool {
+	return canonical.Valid && slices.ContainsFunc(m.feeds, func(f database.Feed) bool {
+		return f.CanonicalUrl == canonical && f.ID != id
+	})
+}
+
+func (m *Memory) GetFeedByID(ctx context.Context, id uuid.UUID) (database.Feed, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return one(m.feedByID(id))
+}
+
+func (m *Memory) GetFeedBySerialID(ctx context.Context, serialID int64) (database.Feed, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return one(find(m.feeds, func(f database.Feed) bool { return f.SerialID == serialID }))
+}
+
+func (m *Memory) ListFeeds(ctx context.Context, arg database.ListFeedsParams) ([]database.Feed, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	feeds := sortedBy(m.feeds, func(a, b database.Feed) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return page(feeds, arg.Limit, arg.Offset), nil
+}
+
+func (m *Memory) GetFollowedFeeds(ctx context.Context, userID uuid.UUID) ([]database.Feed, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	feeds := filter(m.feeds, func(f database.Feed) bool { return m.isFollowing(userID, f.ID) })
+	return sortedBy(feeds, func(a, b database.Feed) int { return strings.Compare(a.Name, b.Name) }), nil
+}
+
+func (m *Memory) MarkFeedFetched(ctx context.Context, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := slices.IndexFunc(m.feeds, func(f database.Feed) bool { return f.ID == id })
+	if i >= 0 {
+		now := m.now()
+		m.feeds[i].LastFetchedAt = sql.NullTime{Time: now, Valid: true}
+		m.feeds[i].UpdatedAt = now
+	}
+	return nil
+}
+
+// GetNextFeedToFetch picks the feed fetched longest ago, never fetched
+// ones first, skipping feeds a WebSub hub currently pushes.
+func (m *Memory) GetNextFeedToFetch(ctx context.Context) (database.Feed, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	feeds := filter(m.feeds, func(f database.Feed) bool {
+		return !slices.ContainsFunc(m.websubs, func(ws database.WebsubSubscription) bool {
+			return ws.FeedID == f.ID && ws.State == "active" && ws.LeaseExpiresAt.Valid && ws.LeaseExpiresAt.Time.After(now)
+		})
+	})
+	feeds = sortedBy(feeds, func(a, b database.Feed) int {
+		switch {
+		case !a.LastFetchedAt.Valid && b.LastFetchedAt.Valid:
+			return -1
+		case a.LastFetchedAt.Valid && !b.LastFetchedAt.Valid:
+			return 1
+		case a.LastFetchedAt.Valid && b.LastFetchedAt.Valid:
+			if c := a.LastFetchedAt.Time.Compare(b.LastFetchedAt.Time); c != 0 {
+				return c
+			}
+		}
+		return a.UpdatedAt.Compare(b.UpdatedAt)
+	})
+	if len(feeds) == 0 {
+		return database.Feed{}, sql.ErrNoRows
+	}
+	return feeds[0], nil
+}
+
+func (m *Memory) DeleteFeed(ctx context.Context, id uuid.UUID) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.deleteFeeds(func(f database.Feed) bool { return f.ID == id }), nil
+}
+
+// updateFeed applies change to the feed with id and returns it as it is
+// afterwards.
+func (m *Memory) updateFeed(id uuid.UUID, change func(*database.Feed)) (database.Feed, error) {
+	i := slices.IndexFunc(m.feeds, func(f database.Feed) bool { return f.ID == id })
+	if i < 0 {
+		return database.Feed{}, sql.ErrNoRows
+	}
+	change(&m.feeds[i])
+	m.feeds[i].UpdatedAt = m.now()
+	return m.feeds[i], nil
+}
+
+func (m *Memory) RenameFeed(ctx context.Context, arg database.RenameFeedParams) (database.Feed, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.updateFeed(arg.ID, func(f *database.Feed) { f.Name = arg.Name })
+}
+
+func (m *Memory) SetFeedURL(ctx context.Context, arg database.SetFeedURLParams) (database.Feed, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if slices.ContainsFunc(m.feeds, func(f database.Feed) bool { return f.Url == arg.Url && f.ID != arg.ID }) {
+		return database.Feed{}, uniqueViolation("feeds_url_key")
+	}
+	if m.canonicalURLTaken(arg.CanonicalUrl, arg.ID) {
+		return database.Feed{}, uniqueViolation("feeds_canonical_url_key")
+	}
+	return m.updateFeed(arg.ID, func(f *database.Feed) {
+		f.Url = arg.Url
+		f.CanonicalUrl = arg.CanonicalUrl
+		f.LastFetchedAt = sql.NullTime{}
+	})
+}
+
+func (m *Memory) CreateFeedFollow(ctx context.Context, arg database.CreateFeedFollowParams) (database.CreateFeedFollowRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if slices.ContainsFunc(m.follows, func(ff database.FeedFollow) bool { return ff.ID == arg.ID }) {
+		return database.CreateFeedFollowRow{}, uniqueViolation("feed_follows_pkey")
+	}
+	if m.isFollowing(arg.UserID, arg.FeedID) {
+		return database.CreateFeedFollowRow{}, uniqueViolation("feed_follows_user_id_feed_id_key")
+	}
+	user, ok := m.userByID(arg.UserID)
+	if !ok {
+		return database.CreateFeedFollowRow{}, foreignKeyViolation("feed_follows_user_id_fkey")
+	}
+	feed, ok := m.feedByID(arg.FeedID)
+	if !ok {
+		return database.CreateFeedFollowRow{}, foreignKeyViolation("feed_follows_feed_id_fkey")
+	}
+	m.follows = append(m.follows, database.FeedFollow{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		UserID:    arg.UserID,
+		FeedID:    arg.FeedID,
+	})
+	return database.CreateFeedFollowRow{
+		ID:        arg.ID,
+		UserID:    arg.UserID,
+		FeedID:    arg.FeedID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		UserName:  user.Name,
+		FeedName:  feed.Name,
+	}, nil
+}
+
+func (m *Memory) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]database.GetFeedFollowsForUserRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var rows []database.GetFeedFollowsForUserRow
+	for _, ff := range m.follows {
+		if ff.UserID != userID {
+			continue
+		}
+		user, _ := m.userByID(ff.UserID)
+		feed, _ := m.feedByID(ff.FeedID)
+		rows = append(rows, database.GetFeedFollowsForUserRow{
+			ID:        ff.ID,
+			UserID:    ff.UserID,
+			FeedID:    ff.FeedID,
+			CreatedAt: ff.CreatedAt,
+			UpdatedAt: ff.UpdatedAt,
+			UserName:  user.Name,
+			FeedName:  feed.Name,
+		})
+	}
+	return rows, nil
+}
+
+func (m *Memory) GetFeedFollowForUserAndFeed(ctx context.Context, arg database.GetFeedFollowForUserAndFeedParams) (database.FeedFollow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return one(find(m.follows, func(ff database.FeedFollow) bool {
+		return ff.UserID == arg.UserID && ff.FeedID == arg.FeedID
+	}))
+}
+
+func (m *Memory) DeleteFeedFollowByFeedID(ctx context.Context, arg database.DeleteFeedFollowByFeedIDParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var removed []database.FeedFollow
+	m.follows, removed = partition(m.follows, func(ff database.FeedFollow) bool {
+		return ff.UserID == arg.UserID && ff.FeedID == arg.FeedID
+	})
+	return int64(len(removed)), nil
+}
+
+func (m *Memory) DeleteFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var removed []database.FeedFollow
+	m.follows, removed = partition(m.follows, func(ff database.FeedFollow) bool { return ff.UserID == userID })
+	return int64(len(removed)), nil
+}
+
+// MoveFeedFollows leaves the follows of users who already follow the
+// target feed where they are.
+func (m *Memory) MoveFeedFollows(ctx context.Context, arg database.MoveFeedFollowsParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var moved int64
+	now := m.now()
+	for i, ff := range m.follows {
+		if ff.FeedID == arg.FromFeedID && !m.isFollowing(ff.UserID, arg.IntoFeedID) {
+			m.follows[i].FeedID = arg.IntoFeedID
+			m.follows[i].UpdatedAt = now
+			moved++
+		}
+	}
+	return moved, nil
+}
+
+func (m *Memory) CreateFeedRedirect(ctx context.Context, arg database.CreateFeedRedirectParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.feedExists(arg.FeedID) {
+		return foreignKeyViolation("feed_redirects_feed_id_fkey")
+	}
+	redirect := database.FeedRedirect(arg)
+	if i := slices.IndexFunc(m.redirects, func(r database.FeedRedirect) bool { return r.Url == arg.Url }); i >= 0 {
+		m.redirects[i] = redirect
+		return nil
+	}
+	m.redirects = append(m.redirects, redirect)
+	return nil
+}
+
+func (m *Memory) GetFeedRedirect(ctx context.Context, url string) (database.FeedRedirect, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return one(find(m.redirects, func(r database.FeedRedirect) bool { return r.Url == url }))
+}
+
+func (m *Memory) DeleteFeedRedirect(ctx context.Context, url string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.redirects = slices.DeleteFunc(m.redirects, func(r database.FeedRedirect) bool { return r.Url == url })
+	return nil
+}
+
+func (m *Memory) MoveFeedRedirects(ctx context.Context, arg database.MoveFeedRedirectsParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var moved int64
+	for i, r := range m.redirects {
+		if r.FeedID == arg.FromFeedID {
+			m.redirects[i].FeedID = arg.IntoFeedID
+			moved++
+		}
+	}
+	return moved, nil
+}
@@ -0,0 +1,246 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/richardteaman/gator/internal/database"
+)
+
+func (m *Memory) CreateWebhook(ctx context.Context, arg database.CreateWebhookParams) (database.Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if slices.ContainsFunc(m.webhooks, func(w database.Webhook) bool { return w.ID == arg.ID }) {
+		return database.Webhook{}, uniqueViolation("webhooks_pkey")
+	}
+	if !m.userExists(arg.UserID) {
+		return database.Webhook{}, foreignKeyViolation("webhooks_user_id_fkey")
+	}
+	if arg.FeedID.Valid && !m.feedExists(arg.FeedID.UUID) {
+		return database.Webhook{}, foreignKeyViolation("webhooks_feed_id_fkey")
+	}
+	webhook := database.Webhook(arg)
+	m.webhooks = append(m.webhooks, webhook)
+	return webhook, nil
+}
+
+func (m *Memory) GetWebhooksForUser(ctx context.Context, userID uuid.UUID) ([]database.Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	webhooks := filter(m.webhooks, func(w database.Webhook) bool { return w.UserID == userID })
+	return sortedBy(webhooks, func(a, b database.Webhook) int { return a.CreatedAt.Compare(b.CreatedAt) }), nil
+}
+
+func (m *Memory) GetWebhookForUser(ctx context.Context, arg database.GetWebhookForUserParams) (database.Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return one(find(m.webhooks, func(w database.Webhook) bool { return w.ID == arg.ID && w.UserID == arg.UserID }))
+}
+
+// GetWebhooksForFeed returns the webhooks of the feed's followers that
+// watch either every feed or this one.
+func (m *Memory) GetWebhooksForFeed(ctx context.Context, feedID uuid.UUID) ([]database.Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return filter(m.webhooks, func(w database.Webhook) bool {
+		return m.isFollowing(w.UserID, feedID) && (!w.FeedID.Valid || w.FeedID.UUID == feedID)
+	}), nil
+}
+
+func (m *Memory) DeleteWebhook(ctx context.Context, arg database.DeleteWebhookParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.deleteWebhooks(func(w database.Webhook) bool { return w.ID == arg.ID && w.UserID == arg.UserID }), nil
+}
+
+func (m *Memory) MoveFeedWebhooks(ctx context.Context, arg database.MoveFeedWebhooksParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var moved int64
+	now := m.now()
+	for i, w := range m.webhooks {
+		if w.FeedID.Valid && w.FeedID.UUID == arg.FromFeedID {
+			m.webhooks[i].FeedID = uuid.NullUUID{UUID: arg.IntoFeedID, Valid: true}
+			m.webhooks[i].UpdatedAt = now
+			moved++
+		}
+	}
+	return moved, nil
+}
+
+func (m *Memory) CreateWebhookDelivery(ctx context.Context, arg database.CreateWebhookDeliveryParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if slices.ContainsFunc(m.deliveries, func(d database.WebhookDelivery) bool { return d.ID == arg.ID }) {
+		return uniqueViolation("webhook_deliveries_pkey")
+	}
+	if !slices.ContainsFunc(m.webhooks, func(w database.Webhook) bool { return w.ID == arg.WebhookID }) {
+		return foreignKeyViolation("webhook_deliveries_webhook_id_fkey")
+	}
+	if !m.postExists(arg.PostID) {
+		return foreignKeyViolation("webhook_deliveries_post_id_fkey")
+	}
+	m.deliveries = append(m.deliveries, database.WebhookDelivery(arg))
+	return nil
+}
+
+func (m *Memory) GetWebhookDeliveries(ctx context.Context, arg database.GetWebhookDeliveriesParams) ([]database.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	deliveries := filter(m.deliveries, func(d database.WebhookDelivery) bool { return d.WebhookID == arg.WebhookID })
+	deliveries = sortedBy(deliveries, func(a, b database.WebhookDelivery) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	return page(deliveries, arg.Limit, 0), nil
+}
+
+func (m *Memory) GetWebhook(ctx context.Context, id uuid.UUID) (database.Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return one(find(m.webhooks, func(w database.Webhook) bool { return w.ID == id }))
+}
+
+// EnqueueWebhookDelivery ignores a post already queued for the webhook,
+// like the query's "on conflict do nothing".
+func (m *Memory) EnqueueWebhookDelivery(ctx context.Context, arg database.EnqueueWebhookDeliveryParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if slices.ContainsFunc(m.queue, func(q database.WebhookQueue) bool {
+		return q.ID == arg.ID || q.WebhookID == arg.WebhookID && q.PostID == arg.PostID
+	}) {
+		return nil
+	}
+	if !slices.ContainsFunc(m.webhooks, func(w database.Webhook) bool { return w.ID == arg.WebhookID }) {
+		return foreignKeyViolation("webhook_queue_webhook_id_fkey")
+	}
+	if !m.postExists(arg.PostID) {
+		return foreignKeyViolation("webhook_queue_post_id_fkey")
+	}
+	m.queue = append(m.queue, database.WebhookQueue{
+		ID:            arg.ID,
+		CreatedAt:     arg.CreatedAt,
+		WebhookID:     arg.WebhookID,
+		PostID:        arg.PostID,
+		NextAttemptAt: arg.NextAttemptAt,
+	})
+	return nil
+}
+
+func (m *Memory) GetDueWebhookDeliveries(ctx context.Context, arg database.GetDueWebhookDeliveriesParams) ([]database.WebhookQueue, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	due := filter(m.queue, func(q database.WebhookQueue) bool { return !q.NextAttemptAt.After(arg.Now) })
+	due = sortedBy(due, func(a, b database.WebhookQueue) int { return a.NextAttemptAt.Compare(b.NextAttemptAt) })
+	return page(due, arg.MaxDeliveries, 0), nil
+}
+
+func (m *Memory) RescheduleWebhookDelivery(ctx context.Context, arg database.RescheduleWebhookDeliveryParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := slices.IndexFunc(m.queue, func(q database.WebhookQueue) bool { return q.ID == arg.ID })
+	if i >= 0 {
+		m.queue[i].Attempts = arg.Attempts
+		m.queue[i].NextAttemptAt = arg.NextAttemptAt
+	}
+	return nil
+}
+
+func (m *Memory) DeleteQueuedWebhookDelivery(ctx context.Context, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.queue = slices.DeleteFunc(m.queue, func(q database.WebhookQueue) bool { return q.ID == id })
+	return nil
+}
+
+// UpsertWebSubSubscription only resets an existing subscription when the
+// hub or topic changed, like the query's "do update ... where".
+func (m *Memory) UpsertWebSubSubscription(ctx context.Context, arg database.UpsertWebSubSubscriptionParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := slices.IndexFunc(m.websubs, func(ws database.WebsubSubscription) bool { return ws.FeedID == arg.FeedID })
+	if i >= 0 {
+		ws := &m.websubs[i]
+		if ws.HubUrl != arg.HubUrl || ws.TopicUrl != arg.TopicUrl {
+			ws.HubUrl = arg.HubUrl
+			ws.TopicUrl = arg.TopicUrl
+			ws.State = "pending"
+			ws.RequestedAt = sql.NullTime{}
+			ws.UpdatedAt = arg.UpdatedAt
+		}
+		return nil
+	}
+	if slices.ContainsFunc(m.websubs, func(ws database.WebsubSubscription) bool { return ws.ID == arg.ID }) {
+		return uniqueViolation("websub_subscriptions_pkey")
+	}
+	if !m.feedExists(arg.FeedID) {
+		return foreignKeyViolation("websub_subscriptions_feed_id_fkey")
+	}
+	m.websubs = append(m.websubs, database.WebsubSubscription{
+		ID:        arg.ID,
+		CreatedAt: arg.CreatedAt,
+		UpdatedAt: arg.UpdatedAt,
+		FeedID:    arg.FeedID,
+		HubUrl:    arg.HubUrl,
+		TopicUrl:  arg.TopicUrl,
+		Secret:    arg.Secret,
+		State:     "pending",
+	})
+	return nil
+}
+
+func (m *Memory) GetWebSubSubscription(ctx context.Context, id uuid.UUID) (database.WebsubSubscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return one(find(m.websubs, func(ws database.WebsubSubscription) bool { return ws.ID == id }))
+}
+
+func (m *Memory) GetWebSubSubscriptionsDue(ctx context.Context, arg database.GetWebSubSubscriptionsDueParams) ([]database.WebsubSubscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return filter(m.websubs, func(ws database.WebsubSubscription) bool {
+		return ws.State != "denied" &&
+			(!ws.LeaseExpiresAt.Valid || ws.LeaseExpiresAt.Time.Before(arg.RenewBefore)) &&
+			(!ws.RequestedAt.Valid || ws.RequestedAt.Time.Before(arg.RetryBefore))
+	}), nil
+}
+
+// updateWebSub applies change to the subscription with id, if there is one.
+func (m *Memory) updateWebSub(id uuid.UUID, change func(*database.WebsubSubscription)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := slices.IndexFunc(m.websubs, func(ws database.WebsubSubscription) bool { return ws.ID == id })
+	if i >= 0 {
+		change(&m.websubs[i])
+		m.websubs[i].UpdatedAt = m.now()
+	}
+}
+
+func (m *Memory) MarkWebSubRequested(ctx context.Context, id uuid.UUID) error {
+	m.updateWebSub(id, func(ws *database.WebsubSubscription) {
+		ws.RequestedAt = sql.NullTime{Time: m.now(), Valid: true}
+	})
+	return nil
+}
+
+func (m *Memory) ActivateWebSubSubscription(ctx context.Context, arg database.ActivateWebSubSubscriptionParams) error {
+	m.updateWebSub(arg.ID, func(ws *database.WebsubSubscription) {
+		ws.State = "active"
+		ws.LeaseExpiresAt = arg.LeaseExpiresAt
+	})
+	return nil
+}
+
+func (m *Memory) SetWebSubState(ctx context.Context, arg database.SetWebSubStateParams) error {
+	m.updateWebSub(arg.ID, func(ws *database.WebsubSubscription) {
+		ws.State = arg.State
+		ws.LeaseExpiresAt = sql.NullTime{}
+	})
+	return nil
+}
+
+func (m *Memory) DeleteWebSubSubscriptionForFeed(ctx context.Context, feedID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.websubs = slices.DeleteFunc(m.websubs, func(ws database.WebsubSubscription) bool { return ws.FeedID == feedID })
+	return nil
+}
@@ -0,0 +1,447 @@
+package storage
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richardteaman/gator/internal/database"
+)
+
+func (m *Memory) CreatePost(ctx context.Context, arg database.CreatePostParams) (database.Post, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.postExists(arg.ID) {
+		return database.Post{}, uniqueViolation("posts_pkey")
+	}
+	if slices.ContainsFunc(m.posts, func(p database.Post) bool { return p.Url == arg.Url }) {
+		return database.Post{}, uniqueViolation("posts_url_key")
+	}
+	if !m.feedExists(arg.FeedID) {
+		return database.Post{}, foreignKeyViolation("posts_feed_id_fkey")
+	}
+	m.postSerial++
+	post := database.Post{
+		ID:          arg.ID,
+		CreatedAt:   arg.CreatedAt,
+		UpdatedAt:   arg.UpdatedAt,
+		Title:       arg.Title,
+		Url:         arg.Url,
+		Description: arg.Description,
+		PublishedAt: arg.PublishedAt,
+		FeedID:      arg.FeedID,
+		SerialID:    m.postSerial,
+		Author:      arg.Author,
+		Categories:  arg.Categories,
+	}
+	m.posts = append(m.posts, post)
+	return post, nil
+}
+
+func (m *Memory) GetPostByID(ctx context.Context, id uuid.UUID) (database.Post, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return one(find(m.posts, func(p database.Post) bool { return p.ID == id }))
+}
+
+func (m *Memory) GetPostBySerialID(ctx context.Context, serialID int64) (database.Post, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return one(find(m.posts, func(p database.Post) bool { return p.SerialID == serialID }))
+}
+
+func (m *Memory) GetPostsByUserId(ctx context.Context, arg database.GetPostsByUserIdParams) ([]database.GetPostsByUserIdRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	posts := sortedBy(m.followedPosts(arg.UserID), func(a, b database.Post) int {
+		return compareNullTimeDesc(a.PublishedAt, b.PublishedAt)
+	})
+	var rows []database.GetPostsByUserIdRow
+	for _, p := range page(posts, arg.Limit, 0) {
+		ff, _ := find(m.follows, func(ff database.FeedFollow) bool { return ff.UserID == arg.UserID && ff.FeedID == p.FeedID })
+		rows = append(rows, database.GetPostsByUserIdRow{
+			ID:          p.ID,
+			CreatedAt:   p.CreatedAt,
+			UpdatedAt:   p.UpdatedAt,
+			Title:       p.Title,
+			Url:         p.Url,
+			Description: p.Description,
+			PublishedAt: p.PublishedAt,
+			FeedID:      p.FeedID,
+			SerialID:    p.SerialID,
+			Author:      p.Author,
+			Categories:  p.Categories,
+			ID_2:        ff.ID,
+			CreatedAt_2: ff.CreatedAt,
+			UpdatedAt_2: ff.UpdatedAt,
+			UserID:      ff.UserID,
+			FeedID_2:    ff.FeedID,
+		})
+	}
+	return rows, nil
+}
+
+func (m *Memory) GetPostsForUser(ctx context.Context, arg database.GetPostsForUserParams) ([]database.GetPostsForUserRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	posts := filter(m.followedPosts(arg.UserID), func(p database.Post) bool {
+		return !arg.UnreadOnly || !m.isRead(arg.UserID, p.ID)
+	})
+	posts = sortedBy(posts, func(a, b database.Post) int {
+		if c := compareNullTimeDesc(a.PublishedAt, b.PublishedAt); c != 0 {
+			return c
+		}
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	var rows []database.GetPostsForUserRow
+	for _, p := range page(posts, arg.PageLimit, arg.PageOffset) {
+		rows = append(rows, database.GetPostsForUserRow{
+			ID:          p.ID,
+			CreatedAt:   p.CreatedAt,
+			UpdatedAt:   p.UpdatedAt,
+			Title:       p.Title,
+			Url:         p.Url,
+			Description: p.Description,
+			PublishedAt: p.PublishedAt,
+			FeedID:      p.FeedID,
+			Author:      p.Author,
+			Categories:  p.Categories,
+			Read:        m.isRead(arg.UserID, p.ID),
+		})
+	}
+	return rows, nil
+}
+
+func (m *Memory) CountPostsForUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return int64(len(m.followedPosts(userID))), nil
+}
+
+func (m *Memory) feverItem(userID uuid.UUID, p database.Post) database.GetFeverItemRow {
+	feed, _ := m.feedByID(p.FeedID)
+	return database.GetFeverItemRow{
+		SerialID:     p.SerialID,
+		FeedSerialID: feed.SerialID,
+		Title:        p.Title,
+		Url:          p.Url,
+		Description:  p.Description,
+		Author:       p.Author,
+		PublishedAt:  p.PublishedAt,
+		CreatedAt:    p.CreatedAt,
+		IsRead:       m.isRead(userID, p.ID),
+		IsSaved:      m.isStarred(userID, p.ID),
+	}
+}
+
+func (m *Memory) GetFeverItem(ctx context.Context, arg database.GetFeverItemParams) (database.GetFeverItemRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	post, ok := find(m.followedPosts(arg.UserID), func(p database.Post) bool { return p.SerialID == arg.SerialID })
+	if !ok {
+		return one(database.GetFeverItemRow{}, false)
+	}
+	return m.feverItem(arg.UserID, post), nil
+}
+
+func (m *Memory) GetFeverItemsSince(ctx context.Context, arg database.GetFeverItemsSinceParams) ([]database.GetFeverItemsSinceRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	posts := filter(m.followedPosts(arg.UserID), func(p database.Post) bool { return p.SerialID > arg.SinceID })
+	posts = sortedBy(posts, func(a, b database.Post) int { return cmp.Compare(a.SerialID, b.SerialID) })
+	var rows []database.GetFeverItemsSinceRow
+	for _, p := range page(posts, arg.PageLimit, 0) {
+		rows = append(rows, database.GetFeverItemsSinceRow(m.feverItem(arg.UserID, p)))
+	}
+	return rows, nil
+}
+
+func (m *Memory) GetFeverItemsBefore(ctx context.Context, arg database.GetFeverItemsBeforeParams) ([]database.GetFeverItemsBeforeRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	posts := filter(m.followedPosts(arg.UserID), func(p database.Post) bool { return p.SerialID < arg.MaxID })
+	posts = sortedBy(posts, func(a, b database.Post) int { return cmp.Compare(b.SerialID, a.SerialID) })
+	var rows []database.GetFeverItemsBeforeRow
+	for _, p := range page(posts, arg.PageLimit, 0) {
+		rows = append(rows, database.GetFeverItemsBeforeRow(m.feverItem(arg.UserID, p)))
+	}
+	return rows, nil
+}
+
+func (m *Memory) GetDigestPosts(ctx context.Context, arg database.GetDigestPostsParams) ([]database.GetDigestPostsRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var rows []database.GetDigestPostsRow
+	for _, p := range m.followedPosts(arg.UserID) {
+		if m.isRead(arg.UserID, p.ID) || !p.CreatedAt.After(arg.Since) {
+			continue
+		}
+		feed, _ := m.feedByID(p.FeedID)
+		rows = append(rows, database.GetDigestPostsRow{
+			ID:          p.ID,
+			Title:       p.Title,
+			Url:         p.Url,
+			Description: p.Description,
+			PublishedAt: p.PublishedAt,
+			CreatedAt:   p.CreatedAt,
+			FeedName:    feed.Name,
+			FeedUrl:     feed.Url,
+		})
+	}
+	rows = sortedBy(rows, func(a, b database.GetDigestPostsRow) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID.String(), b.ID.String())
+	})
+	return page(rows, arg.MaxPosts, 0), nil
+}
+
+func (m *Memory) DeletePostsOlderThan(ctx context.Context, before time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.deletePosts(func(p database.Post) bool {
+		// the date Fever clients are shown and send back as before
+		date := p.CreatedAt
+		if p.PublishedAt.Valid {
+			date = p.PublishedAt.Time
+		}
+		return date.Before(before)
+	}), nil
+}
+
+// addRead records a read mark unless there is one, like
+// "on conflict (user_id, post_id) do nothing".
+func (m *Memory) MoveFeedPosts(ctx context.Context, arg database.MoveFeedPostsParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var moved int64
+	now := m.now()
+	for i, p := range m.posts {
+		if p.FeedID == arg.FromFeedID {
+			m.posts[i].FeedID = arg.IntoFeedID
+			m.posts[i].UpdatedAt = now
+			moved++
+		}
+	}
+	return moved, nil
+}
+
+// GetDuplicatePosts pairs each post of from with the oldest post of into
+// that has the same title and publication time.
+func (m *Memory) GetDuplicatePosts(ctx context.Context, arg database.GetDuplicatePostsParams) ([]database.GetDuplicatePostsRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var rows []database.GetDuplicatePostsRow
+	from := sortedBy(m.posts, func(a, b database.Post) int { return cmp.Compare(a.SerialID, b.SerialID) })
+	for _, f := range from {
+		if f.FeedID != arg.FromFeedID || (!f.Title.Valid && !f.PublishedAt.Valid) {
+			continue
+		}
+		var twin database.Post
+		for _, p := range m.posts {
+			if p.FeedID == arg.IntoFeedID && p.Title == f.Title && p.PublishedAt.Valid == f.PublishedAt.Valid &&
+				(!p.PublishedAt.Valid || p.PublishedAt.Time.Equal(f.PublishedAt.Time)) && (twin.ID == uuid.Nil || p.SerialID < twin.SerialID) {
+				twin = p
+			}
+		}
+		if twin.ID != uuid.Nil {
+			rows = append(rows, database.GetDuplicatePostsRow{FromPostID: f.ID, IntoPostID: twin.ID})
+		}
+	}
+	return rows, nil
+}
+
+func (m *Memory) DeletePost(ctx context.Context, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deletePosts(func(p database.Post) bool { return p.ID == id })
+	return nil
+}
+
+func (m *Memory) addRead(read database.PostRead) error {
+	if !m.userExists(read.UserID) {
+		return foreignKeyViolation("post_reads_user_id_fkey")
+	}
+	if !m.postExists(read.PostID) {
+		return foreignKeyViolation("post_reads_post_id_fkey")
+	}
+	if !m.isRead(read.UserID, read.PostID) {
+		m.reads = append(m.reads, read)
+	}
+	return nil
+}
+
+func (m *Memory) MarkPostRead(ctx context.Context, arg database.MarkPostReadParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.addRead(database.PostRead(arg))
+}
+
+func (m *Memory) MarkPostUnread(ctx context.Context, arg database.MarkPostUnreadParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reads, _ = partition(m.reads, func(r database.PostRead) bool {
+		return r.UserID == arg.UserID && r.PostID == arg.PostID
+	})
+	return nil
+}
+
+func (m *Memory) markRead(userID uuid.UUID, posts []database.Post, before time.Time) error {
+	now := m.now()
+	for _, p := range posts {
+		// the date Fever clients are shown and send back as before
+		date := p.CreatedAt
+		if p.PublishedAt.Valid {
+			date = p.PublishedAt.Time
+		}
+		if date.After(before) {
+			continue
+		}
+		err := m.addRead(database.PostRead{ID: uuid.New(), CreatedAt: now, UpdatedAt: now, UserID: userID, PostID: p.ID})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Memory) MarkFeedPostsRead(ctx context.Context, arg database.MarkFeedPostsReadParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	posts := filter(m.posts, func(p database.Post) bool { return p.FeedID == arg.FeedID })
+	return m.markRead(arg.UserID, posts, arg.Before)
+}
+
+func (m *Memory) MarkAllPostsRead(ctx context.Context, arg database.MarkAllPostsReadParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.markRead(arg.UserID, m.followedPosts(arg.UserID), arg.Before)
+}
+
+func serialIDs(posts []database.Post) []int64 {
+	var ids []int64
+	for _, p := range posts {
+		ids = append(ids, p.SerialID)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func (m *Memory) MovePostReads(ctx context.Context, arg database.MovePostReadsParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	for i, r := range m.reads {
+		if r.PostID == arg.FromPostID && !m.isRead(r.UserID, arg.IntoPostID) {
+			m.reads[i].PostID = arg.IntoPostID
+			m.reads[i].UpdatedAt = now
+		}
+	}
+	return nil
+}
+
+func (m *Memory) GetUnreadPostSerialIDs(ctx context.Context, userID uuid.UUID) ([]int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return serialIDs(filter(m.followedPosts(userID), func(p database.Post) bool { return !m.isRead(userID, p.ID) })), nil
+}
+
+func (m *Memory) StarPost(ctx context.Context, arg database.StarPostParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.userExists(arg.UserID) {
+		return foreignKeyViolation("post_stars_user_id_fkey")
+	}
+	if !m.postExists(arg.PostID) {
+		return foreignKeyViolation("post_stars_post_id_fkey")
+	}
+	if !m.isStarred(arg.UserID, arg.PostID) {
+		m.stars = append(m.stars, database.PostStar(arg))
+	}
+	return nil
+}
+
+func (m *Memory) UnstarPost(ctx context.Context, arg database.UnstarPostParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stars, _ = partition(m.stars, func(s database.PostStar) bool {
+		return s.UserID == arg.UserID && s.PostID == arg.PostID
+	})
+	return nil
+}
+
+func (m *Memory) MovePostStars(ctx context.Context, arg database.MovePostStarsParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	for i, s := range m.stars {
+		if s.PostID == arg.FromPostID && !m.isStarred(s.UserID, arg.IntoPostID) {
+			m.stars[i].PostID = arg.IntoPostID
+			m.stars[i].UpdatedAt = now
+		}
+	}
+	return nil
+}
+
+func (m *Memory) GetStarredPostSerialIDs(ctx context.Context, userID uuid.UUID) ([]int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return serialIDs(filter(m.posts, func(p database.Post) bool { return m.isStarred(userID, p.ID) })), nil
+}
+
+func (m *Memory) CreatePostRule(ctx context.Context, arg database.CreatePostRuleParams) (database.PostRule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.userExists(arg.UserID) {
+		return database.PostRule{}, foreignKeyViolation("post_rules_user_id_fkey")
+	}
+	if arg.FeedID.Valid && !m.feedExists(arg.FeedID.UUID) {
+		return database.PostRule{}, foreignKeyViolation("post_rules_feed_id_fkey")
+	}
+	if arg.Action != "mute" && arg.Action != "highlight" {
+		return database.PostRule{}, checkViolation("post_rules_action_check")
+	}
+	if !slices.Contains([]string{"keyword", "regex", "author", "category"}, arg.MatchType) {
+		return database.PostRule{}, checkViolation("post_rules_match_type_check")
+	}
+	rule := database.PostRule(arg)
+	m.rules = append(m.rules, rule)
+	return rule, nil
+}
+
+func (m *Memory) GetPostRulesForUser(ctx context.Context, userID uuid.UUID) ([]database.PostRule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rules := filter(m.rules, func(r database.PostRule) bool { return r.UserID == userID })
+	return sortedBy(rules, func(a, b database.PostRule) int { return a.CreatedAt.Compare(b.CreatedAt) }), nil
+}
+
+func (m *Memory) DeletePostRule(ctx context.Context, arg database.DeletePostRuleParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var removed []database.PostRule
+	m.rules, removed = partition(m.rules, func(r database.PostRule) bool {
+		return r.ID == arg.ID && r.UserID == arg.UserID
+	})
+	return int64(len(removed)), nil
+}
+
+func (m *Memory) MoveFeedPostRules(ctx context.Context, arg database.MoveFeedPostRulesParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var moved int64
+	now := m.now()
+	for i, r := range m.rules {
+		if r.FeedID.Valid && r.FeedID.UUID == arg.FromFeedID {
+			m.rules[i].FeedID = uuid.NullUUID{UUID: arg.IntoFeedID, Valid: true}
+			m.rules[i].UpdatedAt = now
+			moved++
+		}
+	}
+	return moved, nil
+}
@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/richardteaman/gator/internal/database"
+)
+
+func (m *Memory) CreateUser(ctx context.Context, arg database.CreateUserParams) (database.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if slices.ContainsFunc(m.users, func(u database.User) bool { return u.ID == arg.ID }) {
+		return database.User{}, uniqueViolation("users_pkey")
+	}
+	if slices.ContainsFunc(m.users, func(u database.User) bool { return u.Name == arg.Name }) {
+		return database.User{}, uniqueViolation("users_name_key")
+	}
+	user := database.User{
+		ID:           arg.ID,
+		CreatedAt:    arg.CreatedAt,
+		UpdatedAt:    arg.UpdatedAt,
+		Name:         arg.Name,
+		PasswordHash: arg.PasswordHash,
+		Role:         "user",
+	}
+	m.users = append(m.users, user)
+	return user, nil
+}
+
+func (m *Memory) GetUser(ctx context.Context, name string) (database.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return one(find(m.users, func(u database.User) bool { return u.Name == name }))
+}
+
+func (m *Memory) GetUserById(ctx context.Context, id uuid.UUID) (database.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return one(m.userByID(id))
+}
+
+func (m *Memory) GetUserByFeverAPIKey(ctx context.Context, feverApiKey sql.NullString) (database.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// null never equals anything in SQL
+	return one(find(m.users, func(u database.User) bool {
+		return feverApiKey.Valid && u.FeverApiKey.Valid && u.FeverApiKey.String == feverApiKey.String
+	}))
+}
+
+func (m *Memory) GetUsers(ctx context.Context) ([]database.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.users), nil
+}
+
+func (m *Memory) GetUsersWithoutPassword(ctx context.Context) ([]database.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	users := filter(m.users, func(u database.User) bool { return !u.PasswordHash.Valid })
+	return sortedBy(users, func(a, b database.User) int { return a.CreatedAt.Compare(b.CreatedAt) }), nil
+}
+
+func (m *Memory) ListUsers(ctx context.Context, arg database.ListUsersParams) ([]database.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	users := sortedBy(m.users, func(a, b database.User) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return page(users, arg.Limit, arg.Offset), nil
+}
+
+func (m *Memory) ResetUsers(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deleteUsers(func(database.User) bool { return true })
+	return nil
+}
+
+func (m *Memory) DeleteUser(ctx context.Context, name string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.deleteUsers(func(u database.User) bool { return u.Name == name }), nil
+}
+
+func (m *Memory) CountAdmins(ctx context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return int64(len(filter(m.users, func(u database.User) bool { return u.Role == "admin" }))), nil
+}
+
+// updateUser applies change to the user with id, if there is one.
+func (m *Memory) updateUser(id uuid.UUID, change func(*database.User) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i := slices.IndexFunc(m.users, func(u database.User) bool { return u.ID == id })
+	if i < 0 {
+		return nil
+	}
+	user := m.users[i]
+	if err := change(&user); err != nil {
+		return err
+	}
+	user.UpdatedAt = m.now()
+	m.users[i] = user
+	return nil
+}
+
+func (m *Memory) SetFeverAPIKey(ctx context.Context, arg database.SetFeverAPIKeyParams) error {
+	return m.updateUser(arg.ID, func(user *database.User) error {
+		taken := slices.ContainsFunc(m.users, func(u database.User) bool {
+			return u.ID != arg.ID && arg.FeverApiKey.Valid && u.FeverApiKey.Valid && u.FeverApiKey.String == arg.FeverApiKey.String
+		})
+		if taken {
+			return uniqueViolation("users_fever_api_key_key")
+		}
+		user.FeverApiKey = arg.FeverApiKey
+		return nil
+	})
+}
+
+func (m *Memory) SetLastDigestAt(ctx context.Context, arg database.SetLastDigestAtParams) error {
+	return m.updateUser(arg.ID, func(user *database.User) error {
+		user.LastDigestAt = arg.LastDigestAt
+		return nil
+	})
+}
+
+func (m *Memory) SetUserPassword(ctx context.Context, arg database.SetUserPasswordParams) error {
+	return m.updateUser(arg.ID, func(user *database.User) error {
+		user.PasswordHash = arg.PasswordHash
+		return nil
+	})
+}
+
+func (m *Memory) SetUserRole(ctx context.Context, arg database.SetUserRoleParams) error {
+	return m.updateUser(arg.ID, func(user *database.User) error {
+		if arg.Role != "user" && arg.Role != "admin" {
+			return checkViolation("users_role_check")
+		}
+		user.Role = arg.Role
+		return nil
+	})
+}
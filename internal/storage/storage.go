@@ -0,0 +1,30 @@
+// Package storage is what gator's commands need from a database. The
+// sqlc queries for Postgres and SQLite implement Store, and Memory keeps
+// everything in process for tests and demos.
+package storage
+
+import (
+	"errors"
+
+	"github.com/richardteaman/gator/internal/database"
+	"github.com/richardteaman/gator/internal/database/sqlite"
+)
+
+// Store is every query the commands run.
+type Store interface {
+	database.Querier
+}
+
+var (
+	_ Store = (*database.Queries)(nil)
+	_ Store = (*sqlite.Querier)(nil)
+	_ Store = (*Memory)(nil)
+)
+
+// Constraint errors from Memory. Their messages read like the Postgres
+// ones so callers matching on either see the same thing.
+var (
+	ErrUniqueViolation     = errors.New("duplicate key value violates unique constraint")
+	ErrForeignKeyViolation = errors.New("insert or update violates foreign key constraint")
+	ErrCheckViolation      = errors.New("new row violates check constraint")
+)
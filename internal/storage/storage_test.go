@@ -0,0 +1,595 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mattn/go-sqlite3"
+	"github.com/pressly/goose/v3"
+	"github.com/richardteaman/gator/internal/database"
+	"github.com/richardteaman/gator/internal/database/sqlite"
+	"github.com/richardteaman/gator/sql/sqlite/schema"
+)
+
+// stores returns an empty Memory and an empty SQLite database with the
+// schema, for tests that check Memory behaves like the schema does.
+func stores(t *testing.T) map[string]Store {
+	db, err := sqlite.Open(filepath.Join(t.TempDir(), "gator.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	migrator, err := goose.NewProvider(goose.DialectSQLite3, db, schema.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return map[string]Store{"memory": NewMemory(), "sqlite": sqlite.NewQuerier(db)}
+}
+
+// violation names the kind of constraint err violates, the same for
+// either store.
+func violation(err error) string {
+	var sqliteErr sqlite3.Error
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrUniqueViolation):
+		return "unique"
+	case errors.Is(err, ErrForeignKeyViolation):
+		return "foreign key"
+	case errors.Is(err, ErrCheckViolation):
+		return "check"
+	case errors.Is(err, sql.ErrNoRows):
+		return "no rows"
+	case errors.As(err, &sqliteErr):
+		switch sqliteErr.ExtendedCode {
+		case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+			return "unique"
+		case sqlite3.ErrConstraintForeignKey:
+			return "foreign key"
+		case sqlite3.ErrConstraintCheck:
+			return "check"
+		}
+	}
+	return err.Error()
+}
+
+// fixture fills a store with two users who each added a feed with two
+// posts, and everything that hangs off users, feeds and posts. Posts
+// created with an empty title have none.
+type fixture struct {
+	s          Store
+	now        time.Time
+	alice, bob database.User
+	// alice added feed a, bob added feed b
+	a, b           database.Feed
+	a1, a2, b1, b2 database.Post
+	hook, bobHook  database.Webhook
+	rule           database.PostRule
+}
+
+func (f *fixture) tick() time.Time {
+	f.now = f.now.Add(time.Minute)
+	return f.now
+}
+
+func (f *fixture) must(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (f *fixture) user(name string) database.User {
+	now := f.tick()
+	user, err := f.s.CreateUser(context.Background(), database.CreateUserParams{
+		ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: name,
+		PasswordHash: sql.NullString{String: "hash", Valid: true},
+	})
+	f.must(err)
+	return user
+}
+
+func (f *fixture) feed(user database.User, url string) database.Feed {
+	now := f.tick()
+	feed, err := f.s.CreateFeed(context.Background(), database.CreateFeedParams{
+		ID: uuid.New(), CreatedAt: now, UpdatedAt: now, Name: url, Url: url, UserID: user.ID,
+		CanonicalUrl: sql.NullString{String: url, Valid: true},
+	})
+	f.must(err)
+	return feed
+}
+
+func (f *fixture) follow(user database.User, feed database.Feed) error {
+	now := f.tick()
+	_, err := f.s.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
+		ID: uuid.New(), CreatedAt: now, UpdatedAt: now, UserID: user.ID, FeedID: feed.ID,
+	})
+	return err
+}
+
+func (f *fixture) post(feed database.Feed, title, url string, published time.Time) database.Post {
+	now := f.tick()
+	post, err := f.s.CreatePost(context.Background(), database.CreatePostParams{
+		ID: uuid.New(), CreatedAt: now, UpdatedAt: now, FeedID: feed.ID, Url: url,
+		Title:       sql.NullString{String: title, Valid: title != ""},
+		PublishedAt: sql.NullTime{Time: published, Valid: !published.IsZero()},
+	})
+	f.must(err)
+	return post
+}
+
+func (f *fixture) read(user database.User, post database.Post) error {
+	now := f.tick()
+	return f.s.MarkPostRead(context.Background(), database.MarkPostReadParams{
+		ID: uuid.New(), CreatedAt: now, UpdatedAt: now, UserID: user.ID, PostID: post.ID,
+	})
+}
+
+func (f *fixture) star(user database.User, post database.Post) error {
+	now := f.tick()
+	return f.s.StarPost(context.Background(), database.StarPostParams{
+		ID: uuid.New(), CreatedAt: now, UpdatedAt: now, UserID: user.ID, PostID: post.ID,
+	})
+}
+
+func (f *fixture) webhook(user database.User, feed *database.Feed) database.Webhook {
+	now := f.tick()
+	arg := database.CreateWebhookParams{
+		ID: uuid.New(), CreatedAt: now, UpdatedAt: now, UserID: user.ID,
+		Url: "https://hooks.example/" + user.Name, Secret: "s3cret",
+	}
+	if feed != nil {
+		arg.FeedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+		arg.Url += "/" + feed.Name
+	}
+	hook, err := f.s.CreateWebhook(context.Background(), arg)
+	f.must(err)
+	return hook
+}
+
+func newFixture(s Store) *fixture {
+	ctx := context.Background()
+	f := &fixture{s: s, now: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)}
+	f.alice, f.bob = f.user("alice"), f.user("bob")
+	f.a = f.feed(f.alice, "https://a.example/feed")
+	f.b = f.feed(f.bob, "https://b.example/feed")
+	f.must(f.follow(f.alice, f.a))
+	f.must(f.follow(f.bob, f.a))
+	f.must(f.follow(f.bob, f.b))
+
+	published := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	f.a1 = f.post(f.a, "one", "https://a.example/1", published)
+	f.a2 = f.post(f.a, "two", "https://a.example/2", published.Add(time.Hour))
+	f.b1 = f.post(f.b, "one", "https://b.example/1", published)
+	f.b2 = f.post(f.b, "three", "https://b.example/3", published.Add(2*time.Hour))
+	f.must(f.read(f.bob, f.a1))
+	f.must(f.read(f.bob, f.b1))
+	f.must(f.star(f.bob, f.b1))
+	f.must(f.star(f.alice, f.a2))
+
+	f.hook = f.webhook(f.alice, &f.a)
+	f.bobHook = f.webhook(f.bob, &f.b)
+	f.webhook(f.bob, nil)
+	now := f.tick()
+	f.must(s.CreateWebhookDelivery(ctx, database.CreateWebhookDeliveryParams{
+		ID: uuid.New(), CreatedAt: now, WebhookID: f.hook.ID, PostID: f.a1.ID, Attempt: 1, Succeeded: true,
+	}))
+	f.must(s.EnqueueWebhookDelivery(ctx, database.EnqueueWebhookDeliveryParams{
+		ID: uuid.New(), CreatedAt: now, WebhookID: f.bobHook.ID, PostID: f.b2.ID, NextAttemptAt: now,
+	}))
+
+	rule, err := s.CreatePostRule(ctx, database.CreatePostRuleParams{
+		ID: uuid.New(), CreatedAt: now, UpdatedAt: now, UserID: f.bob.ID,
+		FeedID: uuid.NullUUID{UUID: f.b.ID, Valid: true}, Action: "mute", MatchType: "keyword", Pattern: "three",
+	})
+	f.must(err)
+	f.rule = rule
+	_, err = s.CreatePostRule(ctx, database.CreatePostRuleParams{
+		ID: uuid.New(), CreatedAt: now, UpdatedAt: now, UserID: f.bob.ID,
+		Action: "highlight", MatchType: "author", Pattern: "ada",
+	})
+	f.must(err)
+
+	f.must(s.CreateFeedRedirect(ctx, database.CreateFeedRedirectParams{Url: "https://old.b.example/feed", CreatedAt: now, FeedID: f.b.ID}))
+	f.must(s.UpsertWebSubSubscription(ctx, database.UpsertWebSubSubscriptionParams{
+		ID: uuid.New(), CreatedAt: now, UpdatedAt: now, FeedID: f.b.ID,
+		HubUrl: "https://hub.example/", TopicUrl: f.b.Url, Secret: "s3cret",
+	}))
+	_, err = s.CreateSession(ctx, database.CreateSessionParams{
+		ID: uuid.New(), CreatedAt: now, UpdatedAt: now, UserID: f.bob.ID, TokenHash: "bob-session", ExpiresAt: now.Add(time.Hour),
+	})
+	f.must(err)
+	_, err = s.CreateAPIToken(ctx, database.CreateAPITokenParams{
+		ID: uuid.New(), CreatedAt: now, UpdatedAt: now, UserID: f.bob.ID, Name: "ci", TokenHash: "bob-token", Scopes: "read",
+	})
+	f.must(err)
+	f.must(s.SetPasswordReset(ctx, database.SetPasswordResetParams{
+		UserID: f.bob.ID, CreatedAt: now, TokenHash: "bob-reset", ExpiresAt: now.Add(time.Hour),
+	}))
+	return f
+}
+
+// snapshot describes what the users see of the store, by names and
+// titles rather than ids, so two stores can be compared.
+func (f *fixture) snapshot(t *testing.T) string {
+	t.Helper()
+	ctx := context.Background()
+	var b strings.Builder
+
+	feeds, err := f.s.GetFeeds(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	feedNames := map[uuid.UUID]string{}
+	for _, feed := range feeds {
+		feedNames[feed.ID] = feed.Name
+		fmt.Fprintf(&b, "feed %s\n", feed.Name)
+	}
+	for _, url := range []string{"https://old.b.example/feed", f.a.Url, f.b.Url} {
+		if redirect, err := f.s.GetFeedRedirect(ctx, url); err == nil {
+			fmt.Fprintf(&b, "redirect %s -> %s\n", url, feedNames[redirect.FeedID])
+		}
+	}
+
+	users, err := f.s.GetUsers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, user := range users {
+		fmt.Fprintf(&b, "user %s\n", user.Name)
+		follows, err := f.s.GetFeedFollowsForUser(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// follows come in no particular order
+		var followed []string
+		for _, follow := range follows {
+			followed = append(followed, follow.FeedName)
+		}
+		slices.Sort(followed)
+		for _, name := range followed {
+			fmt.Fprintf(&b, "  follows %s\n", name)
+		}
+
+		posts, err := f.s.GetPostsForUser(ctx, database.GetPostsForUserParams{UserID: user.ID, PageLimit: 100})
+		if err != nil {
+			t.Fatal(err)
+		}
+		starred, err := f.s.GetStarredPostSerialIDs(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var stars []string
+		for _, serial := range starred {
+			post, err := f.s.GetPostBySerialID(ctx, serial)
+			if err != nil {
+				t.Fatal(err)
+			}
+			stars = append(stars, post.Url)
+		}
+		for _, post := range posts {
+			fmt.Fprintf(&b, "  post %s %q in %s read=%v starred=%v\n", post.Url, post.Title.String, feedNames[post.FeedID], post.Read, slices.Contains(stars, post.Url))
+		}
+
+		rules, err := f.s.GetPostRulesForUser(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, rule := range rules {
+			fmt.Fprintf(&b, "  rule %s %s %s in %q\n", rule.Action, rule.MatchType, rule.Pattern, feedNames[rule.FeedID.UUID])
+		}
+		hooks, err := f.s.GetWebhooksForUser(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, hook := range hooks {
+			deliveries, err := f.s.GetWebhookDeliveries(ctx, database.GetWebhookDeliveriesParams{WebhookID: hook.ID, Limit: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+			fmt.Fprintf(&b, "  webhook %s for %q, %d deliveries\n", hook.Url, feedNames[hook.FeedID.UUID], len(deliveries))
+		}
+	}
+
+	queued, err := f.s.GetDueWebhookDeliveries(ctx, database.GetDueWebhookDeliveriesParams{Now: f.now.Add(time.Hour), MaxDeliveries: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintf(&b, "queued deliveries %d\n", len(queued))
+	return b.String()
+}
+
+// compare runs fn against a fixture in each store and fails unless both
+// end up the same; it returns the SQLite snapshot.
+func compare(t *testing.T, fn func(t *testing.T, f *fixture)) string {
+	t.Helper()
+	snapshots := map[string]string{}
+	for name, s := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			f := newFixture(s)
+			fn(t, f)
+			snapshots[name] = f.snapshot(t)
+		})
+	}
+	if snapshots["memory"] != snapshots["sqlite"] {
+		t.Errorf("memory store ends up as\n%s\nbut SQLite as\n%s", snapshots["memory"], snapshots["sqlite"])
+	}
+	return snapshots["sqlite"]
+}
+
+func TestConstraints(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		op   func(f *fixture) error
+		want string
+	}{
+		{"user with a taken name", func(f *fixture) error {
+			_, err := f.s.CreateUser(ctx, database.CreateUserParams{ID: uuid.New(), Name: "alice"})
+			return err
+		}, "unique"},
+		{"user with a taken id", func(f *fixture) error {
+			_, err := f.s.CreateUser(ctx, database.CreateUserParams{ID: f.alice.ID, Name: "carol"})
+			return err
+		}, "unique"},
+		{"unknown role", func(f *fixture) error {
+			return f.s.SetUserRole(ctx, database.SetUserRoleParams{ID: f.bob.ID, Role: "root"})
+		}, "check"},
+		{"feed of an unknown user", func(f *fixture) error {
+			_, err := f.s.CreateFeed(ctx, database.CreateFeedParams{ID: uuid.New(), Name: "x", Url: "https://x.example/", UserID: uuid.New()})
+			return err
+		}, "foreign key"},
+		{"feed with a taken URL", func(f *fixture) error {
+			_, err := f.s.CreateFeed(ctx, database.CreateFeedParams{ID: uuid.New(), Name: "x", Url: f.a.Url, UserID: f.bob.ID})
+			return err
+		}, "unique"},
+		{"feed with a taken canonical URL", func(f *fixture) error {
+			_, err := f.s.CreateFeed(ctx, database.CreateFeedParams{ID: uuid.New(), Name: "x", Url: "HTTPS://A.example/feed", UserID: f.bob.ID,
+				CanonicalUrl: f.a.CanonicalUrl})
+			return err
+		}, "unique"},
+		{"feeds without a canonical URL", func(f *fixture) error {
+			for _, url := range []string{"https://x.example/1", "https://x.example/2"} {
+				if _, err := f.s.CreateFeed(ctx, database.CreateFeedParams{ID: uuid.New(), Name: url, Url: url, UserID: f.bob.ID}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, ""},
+		{"moving a feed to a taken URL", func(f *fixture) error {
+			_, err := f.s.SetFeedURL(ctx, database.SetFeedURLParams{ID: f.b.ID, Url: f.a.Url, CanonicalUrl: sql.NullString{String: "https://b.example/new", Valid: true}})
+			return err
+		}, "unique"},
+		{"moving an unknown feed", func(f *fixture) error {
+			_, err := f.s.SetFeedURL(ctx, database.SetFeedURLParams{ID: uuid.New(), Url: "https://x.example/"})
+			return err
+		}, "no rows"},
+		{"following twice", func(f *fixture) error { return f.follow(f.alice, f.a) }, "unique"},
+		{"following an unknown feed", func(f *fixture) error { return f.follow(f.alice, database.Feed{ID: uuid.New()}) }, "foreign key"},
+		{"post with a taken URL", func(f *fixture) error {
+			_, err := f.s.CreatePost(ctx, database.CreatePostParams{ID: uuid.New(), Url: f.b1.Url, FeedID: f.a.ID})
+			return err
+		}, "unique"},
+		{"post of an unknown feed", func(f *fixture) error {
+			_, err := f.s.CreatePost(ctx, database.CreatePostParams{ID: uuid.New(), Url: "https://x.example/1", FeedID: uuid.New()})
+			return err
+		}, "foreign key"},
+		{"reading twice", func(f *fixture) error { return f.read(f.bob, f.a1) }, ""},
+		{"reading an unknown post", func(f *fixture) error { return f.read(f.bob, database.Post{ID: uuid.New()}) }, "foreign key"},
+		{"starring twice", func(f *fixture) error { return f.star(f.bob, f.b1) }, ""},
+		{"starring for an unknown user", func(f *fixture) error { return f.star(database.User{ID: uuid.New()}, f.b1) }, "foreign key"},
+		{"rule with an unknown action", func(f *fixture) error {
+			_, err := f.s.CreatePostRule(ctx, database.CreatePostRuleParams{ID: uuid.New(), UserID: f.bob.ID, Action: "hide", MatchType: "keyword", Pattern: "x"})
+			return err
+		}, "check"},
+		{"rule for an unknown feed", func(f *fixture) error {
+			_, err := f.s.CreatePostRule(ctx, database.CreatePostRuleParams{ID: uuid.New(), UserID: f.bob.ID, FeedID: uuid.NullUUID{UUID: uuid.New(), Valid: true},
+				Action: "mute", MatchType: "keyword", Pattern: "x"})
+			return err
+		}, "foreign key"},
+		{"redirecting a URL again", func(f *fixture) error {
+			return f.s.CreateFeedRedirect(ctx, database.CreateFeedRedirectParams{Url: "https://old.b.example/feed", FeedID: f.a.ID})
+		}, ""},
+		{"redirect to an unknown feed", func(f *fixture) error {
+			return f.s.CreateFeedRedirect(ctx, database.CreateFeedRedirectParams{Url: "https://old.x.example/", FeedID: uuid.New()})
+		}, "foreign key"},
+		{"queueing a post twice", func(f *fixture) error {
+			return f.s.EnqueueWebhookDelivery(ctx, database.EnqueueWebhookDeliveryParams{ID: uuid.New(), WebhookID: f.bobHook.ID, PostID: f.b2.ID, NextAttemptAt: f.now})
+		}, ""},
+		{"queueing an unknown post", func(f *fixture) error {
+			return f.s.EnqueueWebhookDelivery(ctx, database.EnqueueWebhookDeliveryParams{ID: uuid.New(), WebhookID: f.bobHook.ID, PostID: uuid.New(), NextAttemptAt: f.now})
+		}, "foreign key"},
+		{"delivery of an unknown webhook", func(f *fixture) error {
+			return f.s.CreateWebhookDelivery(ctx, database.CreateWebhookDeliveryParams{ID: uuid.New(), WebhookID: uuid.New(), PostID: f.a1.ID, Attempt: 1})
+		}, "foreign key"},
+		{"session with a taken token", func(f *fixture) error {
+			_, err := f.s.CreateSession(ctx, database.CreateSessionParams{ID: uuid.New(), UserID: f.alice.ID, TokenHash: "bob-session"})
+			return err
+		}, "unique"},
+		{"token with a taken name", func(f *fixture) error {
+			_, err := f.s.CreateAPIToken(ctx, database.CreateAPITokenParams{ID: uuid.New(), UserID: f.bob.ID, Name: "ci", TokenHash: "other", Scopes: "read"})
+			return err
+		}, "unique"},
+		{"replacing a reset token", func(f *fixture) error {
+			return f.s.SetPasswordReset(ctx, database.SetPasswordResetParams{UserID: f.bob.ID, TokenHash: "bob-reset-2", ExpiresAt: f.now})
+		}, ""},
+		{"reset token taken by another user", func(f *fixture) error {
+			return f.s.SetPasswordReset(ctx, database.SetPasswordResetParams{UserID: f.alice.ID, TokenHash: "bob-reset", ExpiresAt: f.now})
+		}, "unique"},
+		{"reset token of an unknown user", func(f *fixture) error {
+			return f.s.SetPasswordReset(ctx, database.SetPasswordResetParams{UserID: uuid.New(), TokenHash: "x", ExpiresAt: f.now})
+		}, "foreign key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compare(t, func(t *testing.T, f *fixture) {
+				if got := violation(tt.op(f)); got != tt.want {
+					t.Errorf("got %q, want %q", got, tt.want)
+				}
+			})
+		})
+	}
+}
+
+func TestCascades(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("delete feed", func(t *testing.T) {
+		got := compare(t, func(t *testing.T, f *fixture) {
+			if n, err := f.s.DeleteFeed(ctx, f.b.ID); err != nil || n != 1 {
+				t.Fatalf("DeleteFeed: %d, %v", n, err)
+			}
+			if n, err := f.s.DeleteFeed(ctx, f.b.ID); err != nil || n != 0 {
+				t.Errorf("DeleteFeed again: %d, %v", n, err)
+			}
+			if _, err := f.s.GetPostByID(ctx, f.b1.ID); !errors.Is(err, sql.ErrNoRows) {
+				t.Errorf("post of the deleted feed: %v", err)
+			}
+			if _, err := f.s.GetWebhook(ctx, f.bobHook.ID); !errors.Is(err, sql.ErrNoRows) {
+				t.Errorf("webhook for the deleted feed: %v", err)
+			}
+			if subs, err := f.s.GetWebSubSubscriptionsDue(ctx, database.GetWebSubSubscriptionsDueParams{RenewBefore: f.now.Add(time.Hour), RetryBefore: f.now.Add(time.Hour)}); err != nil || len(subs) != 0 {
+				t.Errorf("WebSub subscriptions of the deleted feed: %v, %v", subs, err)
+			}
+		})
+		for _, gone := range []string{"b.example", "rule mute"} {
+			if strings.Contains(got, gone) {
+				t.Errorf("%q left after deleting the feed:\n%s", gone, got)
+			}
+		}
+		if !strings.Contains(got, "rule highlight author ada") || !strings.Contains(got, `webhook https://hooks.example/bob for ""`) {
+			t.Errorf("rules and webhooks for all feeds should stay:\n%s", got)
+		}
+	})
+
+	t.Run("delete post", func(t *testing.T) {
+		got := compare(t, func(t *testing.T, f *fixture) {
+			if err := f.s.DeletePost(ctx, f.b2.ID); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if strings.Contains(got, "b.example/3") || !strings.HasSuffix(got, "queued deliveries 0\n") {
+			t.Errorf("post or its queued delivery left:\n%s", got)
+		}
+	})
+
+	t.Run("delete user", func(t *testing.T) {
+		compare(t, func(t *testing.T, f *fixture) {
+			if n, err := f.s.DeleteUser(ctx, "bob"); err != nil || n != 1 {
+				t.Fatalf("DeleteUser: %d, %v", n, err)
+			}
+			if _, err := f.s.GetFeedByID(ctx, f.b.ID); !errors.Is(err, sql.ErrNoRows) {
+				t.Errorf("feed bob added: %v", err)
+			}
+			if _, err := f.s.GetPostByID(ctx, f.a1.ID); err != nil {
+				t.Errorf("post of alice's feed bob had read: %v", err)
+			}
+			if _, err := f.s.GetUserBySessionToken(ctx, "bob-session"); !errors.Is(err, sql.ErrNoRows) {
+				t.Errorf("bob's session: %v", err)
+			}
+			if _, err := f.s.GetAPITokenByHash(ctx, "bob-token"); !errors.Is(err, sql.ErrNoRows) {
+				t.Errorf("bob's API token: %v", err)
+			}
+			if _, err := f.s.GetPasswordReset(ctx, f.bob.ID); !errors.Is(err, sql.ErrNoRows) {
+				t.Errorf("bob's reset token: %v", err)
+			}
+		})
+	})
+}
+
+// TestMoveFeed merges feed b into feed a the way feed merge does, step by
+// step, comparing what each step reports.
+func TestMoveFeed(t *testing.T) {
+	ctx := context.Background()
+	got := compare(t, func(t *testing.T, f *fixture) {
+		move := database.MoveFeedFollowsParams{FromFeedID: f.b.ID, IntoFeedID: f.a.ID}
+
+		dups, err := f.s.GetDuplicatePosts(ctx, database.GetDuplicatePostsParams(move))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(dups) != 1 || dups[0].FromPostID != f.b1.ID || dups[0].IntoPostID != f.a1.ID {
+			t.Fatalf("duplicates %+v, want b1 as a duplicate of a1", dups)
+		}
+		for _, dup := range dups {
+			movePost := database.MovePostReadsParams{FromPostID: dup.FromPostID, IntoPostID: dup.IntoPostID}
+			if err := f.s.MovePostReads(ctx, movePost); err != nil {
+				t.Fatal(err)
+			}
+			if err := f.s.MovePostStars(ctx, database.MovePostStarsParams(movePost)); err != nil {
+				t.Fatal(err)
+			}
+			if err := f.s.DeletePost(ctx, dup.FromPostID); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// bob already follows a, so only moving follows nobody has moves any
+		counts := map[string]func() (int64, error){
+			"follows":   func() (int64, error) { return f.s.MoveFeedFollows(ctx, move) },
+			"posts":     func() (int64, error) { return f.s.MoveFeedPosts(ctx, database.MoveFeedPostsParams(move)) },
+			"rules":     func() (int64, error) { return f.s.MoveFeedPostRules(ctx, database.MoveFeedPostRulesParams(move)) },
+			"webhooks":  func() (int64, error) { return f.s.MoveFeedWebhooks(ctx, database.MoveFeedWebhooksParams(move)) },
+			"redirects": func() (int64, error) { return f.s.MoveFeedRedirects(ctx, database.MoveFeedRedirectsParams(move)) },
+		}
+		want := map[string]int64{"follows": 0, "posts": 1, "rules": 1, "webhooks": 1, "redirects": 1}
+		for _, what := range []string{"follows", "posts", "rules", "webhooks", "redirects"} {
+			if n, err := counts[what](); err != nil || n != want[what] {
+				t.Errorf("moving %s: %d, %v; want %d", what, n, err, want[what])
+			}
+		}
+		if n, err := f.s.DeleteFeed(ctx, f.b.ID); err != nil || n != 1 {
+			t.Errorf("DeleteFeed: %d, %v", n, err)
+		}
+	})
+	for _, want := range []string{
+		"redirect https://old.b.example/feed -> https://a.example/feed",
+		`post https://a.example/1 "one" in https://a.example/feed read=true starred=true`,
+		`post https://b.example/3 "three" in https://a.example/feed read=false starred=false`,
+		`rule mute keyword three in "https://a.example/feed"`,
+		`webhook https://hooks.example/bob/https://b.example/feed for "https://a.example/feed", 0 deliveries`,
+		"queued deliveries 1",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("after merging, missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestDuplicatePosts(t *testing.T) {
+	ctx := context.Background()
+	compare(t, func(t *testing.T, f *fixture) {
+		published := time.Date(2026, 9, 2, 0, 0, 0, 0, time.UTC)
+		undatedA := f.post(f.a, "undated", "https://a.example/undated", time.Time{})
+		undatedB := f.post(f.b, "undated", "https://b.example/undated", time.Time{})
+		untitledA := f.post(f.a, "", "https://a.example/untitled", published)
+		untitledB := f.post(f.b, "", "https://b.example/untitled", published)
+		// with neither, posts are only the same by chance
+		f.post(f.a, "", "https://a.example/blank", time.Time{})
+		f.post(f.b, "", "https://b.example/blank", time.Time{})
+
+		dups, err := f.s.GetDuplicatePosts(ctx, database.GetDuplicatePostsParams{FromFeedID: f.b.ID, IntoFeedID: f.a.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []database.GetDuplicatePostsRow{
+			{FromPostID: f.b1.ID, IntoPostID: f.a1.ID},
+			{FromPostID: undatedB.ID, IntoPostID: undatedA.ID},
+			{FromPostID: untitledB.ID, IntoPostID: untitledA.ID},
+		}
+		if !slices.Equal(dups, want) {
+			t.Errorf("duplicates %+v, want %+v", dups, want)
+		}
+	})
+}
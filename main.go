@@ -1,151 +1,244 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"database/sql"
 	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"net/url"
 	"os"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/richardteaman/gator/internal/config"
 	"github.com/richardteaman/gator/internal/database"
+	"github.com/richardteaman/gator/internal/storage"
 
 	"github.com/google/uuid"
 	_ "github.com/lib/pq"
+	"golang.org/x/term"
 )
 
 type state struct {
-	Config *config.Config
-	db     *database.Queries
+	Config  *config.Config
+	db      storage.Store
+	conn    *sql.DB
+	backend backend
+	// commands is the registry, for help and the commands that run others.
+	commands *commands
+
+	// in, out and errOut are what commands read from and print to: the
+	// process's standard streams, or buffers in tests.
+	in     *bufio.Reader
+	out    io.Writer
+	errOut io.Writer
+	// tty is standard input when it is a terminal, for reading passwords
+	// without echo and for the interactive shell; nil otherwise.
+	tty *os.File
 }
 
-type command struct {
-	Name string
-	Args []string
+// newState returns a state on the standard streams, with nothing loaded
+// yet.
+func newState(c *commands) *state {
+	s := &state{
+		commands: c,
+		// one reader shared by every prompt, so buffered input is not lost
+		// between reads when answers are piped in
+		in:     bufio.NewReader(os.Stdin),
+		out:    os.Stdout,
+		errOut: os.Stderr,
+	}
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		s.tty = os.Stdin
+	}
+	return s
 }
 
-type commands struct {
-	list map[string]func(*state, command) error
-}
-
-func (c *commands) run(s *state, cmd command) error {
-	handler, exists := c.list[cmd.Name]
-	if !exists {
-		return fmt.Errorf("command not found :%v", cmd.Name)
-	}
-	return handler(s, cmd)
-}
-
-func (c *commands) register(name string, f func(*state, command) error) {
-	if c.list == nil {
-		c.list = make(map[string]func(*state, command) error)
-	}
-	c.list[name] = f
+// newCommands registers every command, in the order gator help lists
+// them.
+func newCommands() *commands {
+	c := &commands{}
+	for _, spec := range []*commandSpec{
+		helpCommand,
+		initCommand,
+		migrateCommand,
+		registerCommand,
+		loginCommand,
+		logoutCommand,
+		passwdCommand,
+		usersCommand,
+		userCommand,
+		resetCommand,
+		addFeedCommand,
+		feedsCommand,
+		feedCommand,
+		followCommand,
+		followingCommand,
+		unfollowCommand,
+		aggCommand,
+		browseCommand,
+		ruleCommand,
+		rulesCommand,
+		serveCommand,
+		feverKeyCommand,
+		publishCommand,
+		webhookCommand,
+		webhooksCommand,
+		tokenCommand,
+		tokensCommand,
+		digestCommand,
+		shellCommand,
+		completionCommand,
+		completeCommand,
+	} {
+		c.register(spec)
+	}
+	return c
 }
 
 func main() {
+	os.Exit(run())
+}
 
-	cfg, err := config.ReadConfigFile()
-	if err != nil {
-		log.Fatal("Error reading config: ", err)
-	}
-
-	db, err := sql.Open("postgres", cfg.DBURL)
+// run is main without os.Exit, so deferred cleanup happens before gator
+// exits with the code for how the command went.
+func run() int {
+	// global flags come before the command name
+	globals := flag.NewFlagSet("gator", flag.ExitOnError)
+	configPath := globals.String("config", "", "config file to use instead of the default locations")
+	profile := globals.String("profile", "", "config profile to use")
+	globals.Parse(os.Args[1:])
+	args := globals.Args()
+
+	appCommands := newCommands()
+	appState := newState(appCommands)
+	if len(args) < 1 {
+		writeHelp(appState.errOut, appCommands)
+		return exitInvalidInput
+	}
+	spec, err := appCommands.lookup(args[0])
 	if err != nil {
-		log.Fatal("can't connect to db", err)
+		return fail(err)
 	}
-	defer db.Close()
-
-	dbQueries := database.New(db)
 
-	appState := state{
-		Config: &cfg,
-		db:     dbQueries,
-	}
+	opts := config.Options{Path: *configPath, Profile: *profile}
+	if spec.Offline {
+		// init creates the config, so there is none to load yet
+		appState.Config = &config.Config{Path: opts.Path, Profile: opts.Profile}
+	} else {
+		if err := connect(appState, opts); err != nil {
+			return fail(err)
+		}
+		if appState.conn != nil {
+			defer appState.conn.Close()
+		}
 
-	appCommands := commands{
-		list: make(map[string]func(*state, command) error),
+		// migrate is how the schema gets fixed, so it skips the check
+		if spec != migrateCommand {
+			if err := checkSchema(context.Background(), appState); err != nil {
+				return fail(err)
+			}
+		}
 	}
-	appCommands.register("login", handlerLogin)
-	appCommands.register("register", handlerRegister)
-	appCommands.register("reset", handlerReset)
-	appCommands.register("users", handlerUsers)
-	appCommands.register("agg", handlerAgg)
-	appCommands.register("addfeed", middlewareLoggedIn(handlerAddFeed))
-	appCommands.register("feeds", handlerFeeds)
-	appCommands.register("follow", middlewareLoggedIn(handlerFollow))
-	appCommands.register("following", middlewareLoggedIn(handlerFollowing))
-	appCommands.register("unfollow", middlewareLoggedIn(handlerUnfollow))
-	appCommands.register("browse", middlewareLoggedIn(handlerBrowse))
 
-	if len(os.Args) < 2 {
-		log.Fatal("no command provided")
+	if err := appCommands.run(appState, args); err != nil {
+		return fail(fmt.Errorf("command failed: %w", err))
 	}
+	return 0
+}
 
-	cmdName := os.Args[1]
-	cmdArgs := os.Args[2:]
+// fail logs err and returns the exit code for it.
+func fail(err error) int {
+	log.Print(err)
+	return exitCode(err)
+}
 
-	cmd := command{
-		Name: cmdName,
-		Args: cmdArgs,
+// connect loads the config and opens the database it points at. The
+// caller closes s.conn.
+func connect(s *state, opts config.Options) error {
+	cfg, err := config.Load(opts)
+	if errors.Is(err, config.ErrNotFound) {
+		return kindErrorf(kindNotFound, "%w\nRun `gator init` to create one.", err)
+	} else if err != nil {
+		return fmt.Errorf("error reading config: %w", err)
 	}
+	s.Config = &cfg
 
-	err = appCommands.run(&appState, cmd)
+	db, queries, backend, err := openDatabase(cfg.DBURL)
 	if err != nil {
-		log.Fatal("command failed: ", err)
+		return fmt.Errorf("can't connect to db: %w", err)
 	}
-
-	//fmt.Printf("Current config: %+v\n", appState.Config)
+	s.db = queries
+	s.conn = db
+	s.backend = backend
+	return nil
 }
 
 func middlewareLoggedIn(
 	handler func(s *state, cmd command, user database.User) error,
 ) func(s *state, cmd command) error {
 	return func(s *state, cmd command) error {
-		user, err := s.db.GetUser(context.Background(), s.Config.CurrentUserName)
+		user, err := userForSession(context.Background(), s, s.Config.SessionToken())
 		if err != nil {
-			if err == sql.ErrNoRows {
-				return errors.New("no user logged in. please log in first")
-			}
-			return fmt.Errorf("could not fetch current user: %w", err)
+			return err
 		}
 		return handler(s, cmd, user)
 	}
 }
 
-func isUniqueViolation(err error) bool {
-	return err != nil && (strings.Contains(err.Error(), "duplicate key") || strings.Contains(err.Error(), "already exists"))
-}
-
 func scrapeFeeds(s *state) {
 	ctx := context.Background()
 
 	nextFeed, err := s.db.GetNextFeedToFetch(ctx)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			fmt.Println("No feeds left to scrape.")
+			fmt.Fprintln(s.out, "No feeds left to scrape.")
 			return
 		}
-		log.Println("Error fetching next feed:", err)
+		fmt.Fprintln(s.errOut, "Error fetching next feed:", err)
 		return
 	}
 
-	fmt.Println("Fetching feed:", nextFeed.Url)
+	fmt.Fprintln(s.out, "Fetching feed:", nextFeed.Url)
 
 	rssFeed, err := fetchFeed(ctx, nextFeed.Url)
 	if err != nil {
-		log.Println("Error fetching RSS feed:", err)
+		fmt.Fprintln(s.errOut, "Error fetching RSS feed:", err)
 		return
 	}
 
-	fmt.Printf("Fetched feed: %s\n", rssFeed.Channel.Title)
+	fmt.Fprintf(s.out, "Fetched feed: %s\n", rssFeed.Channel.Title)
+
+	if hub, self := rssFeed.hubLinks(); hub != "" {
+		recordWebSubHub(ctx, s, nextFeed, hub, self)
+	}
 
-	for _, item := range rssFeed.Channel.Item {
+	storeFeedItems(ctx, s, nextFeed, rssFeed.Channel.Item)
+
+	err = s.db.MarkFeedFetched(ctx, nextFeed.ID)
+	if err != nil {
+		fmt.Fprintln(s.errOut, "Could not mark feed fetched:", err)
+	}
+}
+
+// storeFeedItems saves new items of feed as posts. It is shared by polling
+// and WebSub content distribution so both go through the same sanitizing.
+func storeFeedItems(ctx context.Context, s *state, feed database.Feed, items []RSSItem) {
+	feedURL, err := url.Parse(feed.Url)
+	if err != nil {
+		fmt.Fprintln(s.errOut, "Error parsing feed URL:", err)
+		return
+	}
+
+	for _, item := range items {
 		pubTime, err := parsePubDate(item.PubDate)
+		if err != nil {
+			fmt.Fprintln(s.errOut, "Could not parse publish date:", err)
+		}
 		var pubTimeNull sql.NullTime
 		if err == nil && !pubTime.IsZero() {
 			pubTimeNull = sql.NullTime{Time: pubTime, Valid: true}
@@ -153,28 +246,39 @@ func scrapeFeeds(s *state) {
 
 		now := time.Now()
 
-		_, err = s.db.CreatePost(ctx, database.CreatePostParams{
+		// the link is served as is by the API, Fever and publish, so items
+		// without a web link are left out rather than stored unchecked
+		link, ok := safeURL(item.Link, feedURL, false)
+		if !ok {
+			fmt.Fprintf(s.errOut, "Skipping item %q with unsafe link %q\n", item.Title, item.Link)
+			continue
+		}
+		description := sanitizeHTML(item.Description, link)
+		author := item.author()
+		categories := joinCategories(item.Categories)
+
+		post, err := s.db.CreatePost(ctx, database.CreatePostParams{
 			ID:          uuid.New(),
 			CreatedAt:   now,
 			UpdatedAt:   now,
 			Title:       sql.NullString{String: item.Title, Valid: item.Title != ""},
-			Url:         item.Link,
-			Description: sql.NullString{String: item.Description, Valid: item.Description != ""},
+			Url:         link,
+			Description: sql.NullString{String: description, Valid: description != ""},
 			PublishedAt: pubTimeNull,
-			FeedID:      nextFeed.ID,
+			FeedID:      feed.ID,
+			Author:      sql.NullString{String: author, Valid: author != ""},
+			Categories:  sql.NullString{String: categories, Valid: categories != ""},
 		})
 
 		if err != nil {
-			if strings.Contains(err.Error(), "duplicate key") {
+			if isUniqueViolation(err) {
 				continue
 			}
-			log.Println("Could not insert post:", err)
+			fmt.Fprintln(s.errOut, "Could not insert post:", err)
+			continue
 		}
-	}
 
-	err = s.db.MarkFeedFetched(ctx, nextFeed.ID)
-	if err != nil {
-		log.Println("Could not mark feed fetched:", err)
+		notifyWebhooks(ctx, s, feed, post)
 	}
 }
 
@@ -200,199 +304,382 @@ func parsePubDate(pubDateStr string) (time.Time, error) {
 		}
 	}
 
-	log.Printf("could not parse publish date %q: %v", pubDateStr, err)
-	return time.Time{}, nil
+	return time.Time{}, fmt.Errorf("%q: %w", pubDateStr, err)
+}
 
+var loginCommand = &commandSpec{
+	Name:    "login",
+	Summary: "asks for the password and logs in as a user",
+	Details: "With --reset-token it asks for a new password instead. Users created before passwords existed need one to log in.",
+	Args:    []argSpec{{Name: "username", Complete: completeUserNames}},
+	Flags: []flagSpec{
+		stringFlag("reset-token", "", "one-time token from gator user reset-password or migrate up, to set a new password"),
+	},
+	Handler: handlerLogin,
 }
 
 func handlerLogin(s *state, cmd command) error {
-	if len(cmd.Args) < 1 {
-		return errors.New("no arguments provided")
-	}
 	username := cmd.Args[0]
+	resetToken := cmd.String("reset-token")
+	ctx := context.Background()
 
-	_, err := s.db.GetUser(context.Background(), username)
+	user, err := s.db.GetUser(ctx, username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return kindErrorf(kindNotFound, "user %s not found", username)
+	}
 	if err != nil {
-		if err == sql.ErrNoRows {
-			fmt.Println("User not found")
-			os.Exit(1)
-		}
+		return fmt.Errorf("could not get user: %w", err)
+	}
 
-		fmt.Printf("Error getting user: %v\n", err)
-		os.Exit(1)
+	switch {
+	case resetToken != "":
+		// checked before asking, and again when it is used
+		if err := checkResetToken(ctx, s, user, resetToken); err != nil {
+			return err
+		}
+		password, err := readNewPassword(s, "New password: ")
+		if err != nil {
+			return err
+		}
+		if err := resetPassword(ctx, s, user, resetToken, password); err != nil {
+			return err
+		}
+		fmt.Fprintln(s.out, "Password set. Other sessions have been logged out.")
+	case user.PasswordHash.Valid:
+		password, err := readPassword(s, "Password: ")
+		if err != nil {
+			return err
+		}
+		if user, err = authenticate(ctx, s, username, password); err != nil {
+			return err
+		}
+	default:
+		// accounts from before passwords existed can't be claimed by
+		// whoever logs in first, only with a token handed out by an admin
+		return errNoPassword
 	}
 
-	err = s.Config.SetUser(username)
+	if err := s.db.DeleteExpiredSessions(ctx); err != nil {
+		return fmt.Errorf("could not clean up sessions: %w", err)
+	}
+	token, _, err := startSession(ctx, s, user)
+	if err != nil {
+		return err
+	}
+	err = s.Config.SetSession(user.Name, token)
 	if err != nil {
-		return fmt.Errorf("login username set error: %v", err)
+		return fmt.Errorf("login session set error: %w", err)
 	}
-	fmt.Println("user has been set")
+	fmt.Fprintln(s.out, "user has been set")
 
 	return nil
 
 }
 
-func handlerRegister(s *state, cmd command) error {
-	if len(cmd.Args) < 1 {
-		return errors.New("no arguments provided")
-	}
+var registerCommand = &commandSpec{
+	Name:    "register",
+	Summary: "creates a user with a password and logs in as it",
+	Details: "Passwords need at least 8 characters. The first user ever registered becomes an admin.",
+	Args:    []argSpec{{Name: "username"}},
+	Handler: handlerRegister,
+}
 
+func handlerRegister(s *state, cmd command) error {
 	username := cmd.Args[0]
 	userID := uuid.New()
 	now := time.Now()
 
+	password, err := readNewPassword(s, "Password: ")
+	if err != nil {
+		return err
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
 	user, err := s.db.CreateUser(
 		context.Background(),
 		database.CreateUserParams{
-			ID:        userID,
-			CreatedAt: now,
-			UpdatedAt: now,
-			Name:      username,
+			ID:           userID,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+			Name:         username,
+			PasswordHash: sql.NullString{String: hash, Valid: true},
 		},
 	)
 	if err != nil {
 		if isUniqueViolation(err) {
-			fmt.Println("User already exists")
-			os.Exit(1)
+			return kindErrorf(kindAlreadyExists, "user %s already exists", username)
 		}
-		return fmt.Errorf("could not create user: %v", err)
+		return fmt.Errorf("could not create user: %w", err)
+	}
+	if err := promoteIfFirstUser(context.Background(), s, &user); err != nil {
+		return err
 	}
 
-	err = s.Config.SetUser(username)
+	token, _, err := startSession(context.Background(), s, user)
 	if err != nil {
-		return fmt.Errorf("could not set user in config file: %v", err)
+		return err
+	}
+	err = s.Config.SetSession(user.Name, token)
+	if err != nil {
+		return fmt.Errorf("could not set session in config file: %w", err)
 	}
 
-	fmt.Printf("User registered: \nID=%v, \nName=%v\nCreatedAt=%v\nUpdatedAt=%v\n", user.ID, user.Name, user.CreatedAt, user.UpdatedAt)
+	fmt.Fprintf(s.out, "User registered: \nID=%v, \nName=%v\nCreatedAt=%v\nUpdatedAt=%v\n", user.ID, user.Name, user.CreatedAt, user.UpdatedAt)
 	return nil
 }
 
-func handlerReset(s *state, cmd command) error {
-	err := s.db.ResetUsers(context.Background())
-	if err != nil {
-		return fmt.Errorf("could not reset the app: %v", err)
+var resetCommand = &commandSpec{
+	Name:    "reset",
+	Summary: "deletes everything, only your follows, or old posts",
+	Details: "Without flags every user, feed, follow and post is deleted (admin only).",
+	Flags: []flagSpec{
+		boolFlag("yes", "do not ask for confirmation"),
+		boolFlag("follows", "only remove your own follows"),
+		stringFlag("posts-older-than", "", "only remove posts older than this, e.g. 90d (admin only)"),
+	},
+	Handler: middlewareLoggedIn(handlerReset),
+}
+
+// handlerReset wipes everything for admins. Narrower resets of only the
+// caller's follows or of old posts are available through flags.
+func handlerReset(s *state, cmd command, user database.User) error {
+	yes := cmd.Bool("yes")
+	olderThan := cmd.String("posts-older-than")
+	ctx := context.Background()
+
+	switch {
+	case cmd.Bool("follows"):
+		if !confirm(s, "Unfollow every feed you follow?", yes) {
+			fmt.Fprintln(s.out, "Aborted")
+			return nil
+		}
+		n, err := s.db.DeleteFeedFollowsForUser(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("could not remove follows: %w", err)
+		}
+		fmt.Fprintf(s.out, "Removed %d follows\n", n)
+
+	case olderThan != "":
+		if user.Role != roleAdmin {
+			return errAdminRequired
+		}
+		age, err := parseExpiry(olderThan)
+		if err != nil {
+			return cmd.usageErrorf("%v", err)
+		}
+		before := time.Now().Add(-age)
+		if !confirm(s, fmt.Sprintf("Delete all posts from before %s?", before.Format(time.DateTime)), yes) {
+			fmt.Fprintln(s.out, "Aborted")
+			return nil
+		}
+		n, err := s.db.DeletePostsOlderThan(ctx, before)
+		if err != nil {
+			return fmt.Errorf("could not delete posts: %w", err)
+		}
+		fmt.Fprintf(s.out, "Deleted %d posts\n", n)
+
+	default:
+		if user.Role != roleAdmin {
+			return errAdminRequired
+		}
+		if !confirm(s, "Delete ALL users, feeds, follows and posts?", yes) {
+			fmt.Fprintln(s.out, "Aborted")
+			return nil
+		}
+		err := s.db.ResetUsers(ctx)
+		if err != nil {
+			return fmt.Errorf("could not reset the app: %w", err)
+		}
+		if err := s.Config.SetSession(user.Name, ""); err != nil {
+			return fmt.Errorf("could not update config file: %w", err)
+		}
+		fmt.Fprintln(s.out, "app reset")
 	}
-	fmt.Println("app reset")
 
 	return nil
 }
 
+var usersCommand = &commandSpec{
+	Name:    "users",
+	Summary: "lists all users, marking the current one",
+	Handler: handlerUsers,
+}
+
 func handlerUsers(s *state, cmd command) error {
 	users, err := s.db.GetUsers(context.Background())
 	if err != nil {
-		return fmt.Errorf("could not get users from db", err)
+		return fmt.Errorf("could not get users from db: %w", err)
 	}
 	if len(users) < 1 {
 		return fmt.Errorf("no users found")
 	}
 
-	currentUser := s.Config.CurrentUserName
+	var currentUser string
+	if current, err := userForSession(context.Background(), s, s.Config.SessionToken()); err == nil {
+		currentUser = current.Name
+	}
 	for _, user := range users {
 		if user.Name == currentUser {
-			fmt.Printf("* %v (current)\n", user.Name)
+			fmt.Fprintf(s.out, "* %v (current)\n", user.Name)
 		} else {
-			fmt.Printf("* %v\n", user.Name)
+			fmt.Fprintf(s.out, "* %v\n", user.Name)
 		}
 
 	}
 	return nil
 }
 
+var browseCommand = &commandSpec{
+	Name:    "browse",
+	Summary: "shows the latest posts from the feeds you follow",
+	Args:    []argSpec{{Name: "limit", Optional: true}},
+	Flags: []flagSpec{
+		boolFlag("plain", "print descriptions as plain text without formatting"),
+		intFlag("width", defaultRenderWidth, "wrap descriptions at this many columns"),
+		boolFlag("all", "also show posts hidden by mute rules"),
+		boolFlag("highlighted", "only show posts flagged by highlight rules"),
+	},
+	Handler: middlewareLoggedIn(handlerBrowse),
+}
+
 func handlerBrowse(s *state, cmd command, user database.User) error {
+	all := cmd.Bool("all")
+	highlighted := cmd.Bool("highlighted")
+
 	limit := int32(2)
 
 	if len(cmd.Args) > 0 {
 		userLimit, err := strconv.Atoi(cmd.Args[0])
-		if err != nil {
-			return fmt.Errorf("invalid limit format: %w", err)
+		if err != nil || userLimit < 1 {
+			return cmd.usageErrorf("invalid limit %q", cmd.Args[0])
 		}
 		limit = int32(userLimit)
 	}
 
-	posts, err := s.db.GetPostsByUserId(context.Background(), database.GetPostsByUserIdParams{
-		UserID: user.ID,
-		Limit:  limit,
+	posts, err := loadFilteredPosts(context.Background(), s, user.ID, limit, postFilter{
+		All:         all,
+		Highlighted: highlighted,
 	})
 	if err != nil {
-		return fmt.Errorf("could not fetch posts for user: %w", err)
+		return err
 	}
 
 	if len(posts) == 0 {
-		fmt.Println("No posts found for your followed feeds.")
+		fmt.Fprintln(s.out, "No posts found for your followed feeds.")
 		return nil
 	}
 
 	for _, post := range posts {
-		fmt.Println("Title:", post.Title.String)
-		fmt.Println("URL:", post.Url)
-		fmt.Println("Description:", post.Description.String)
+		fmt.Fprintln(s.out, "Title:", post.Title.String)
+		fmt.Fprintln(s.out, "URL:", post.Url)
+		if post.Author.Valid {
+			fmt.Fprintln(s.out, "Author:", post.Author.String)
+		}
+		if post.verdict.Highlight != nil {
+			fmt.Fprintln(s.out, "Highlighted:", post.verdict.Highlight)
+		}
+		if post.verdict.Mute != nil {
+			fmt.Fprintln(s.out, "Muted:", post.verdict.Mute)
+		}
+		if cmd.Bool("plain") {
+			fmt.Fprintln(s.out, "Description:", stripHTML(post.Description.String))
+		} else {
+			fmt.Fprintln(s.out, "Description:")
+			fmt.Fprintln(s.out, renderHTML(post.Description.String, cmd.Int("width")))
+		}
 		if post.PublishedAt.Valid {
-			fmt.Println("Published at:", post.PublishedAt.Time.Format(time.RFC3339))
+			fmt.Fprintln(s.out, "Published at:", post.PublishedAt.Time.Format(time.RFC3339))
 		} else {
-			fmt.Println("Published at: unknown")
+			fmt.Fprintln(s.out, "Published at: unknown")
 		}
-		fmt.Println("-------")
+		fmt.Fprintln(s.out, "-------")
 	}
 
 	return nil
 }
 
-func handlerAgg(s *state, cmd command) error {
-	if len(cmd.Args) < 1 {
-		return errors.New("no duration argument provided. Example: 10s or 1m")
-	}
+var aggCommand = &commandSpec{
+	Name:    "agg",
+	Summary: "fetches feeds in a loop, one every period",
+	Details: "The period looks like 10s, 2m or 3h.",
+	Args:    []argSpec{{Name: "period"}},
+	Handler: handlerAgg,
+}
 
+func handlerAgg(s *state, cmd command) error {
 	durationStr := cmd.Args[0]
 	timeBetweenRequests, err := time.ParseDuration(durationStr)
-	if err != nil {
-		return fmt.Errorf("invalid duration string: %v", err)
+	if err != nil || timeBetweenRequests <= 0 {
+		return cmd.usageErrorf("invalid period %q, expected something like 10s, 2m or 3h", durationStr)
 	}
 
-	fmt.Printf("Collecting feeds every %s\n\n", timeBetweenRequests)
+	fmt.Fprintf(s.out, "Collecting feeds every %s\n\n", timeBetweenRequests)
 
 	ticker := time.NewTicker(timeBetweenRequests)
+	webhookTicker := time.NewTicker(webhookPollInterval)
 
 	scrapeFeeds(s)
-	for ; ; <-ticker.C {
-		scrapeFeeds(s)
+	for {
+		deliverWebhooks(s)
+		select {
+		case <-ticker.C:
+			scrapeFeeds(s)
+		case <-webhookTicker.C:
+		}
 	}
+}
 
+var addFeedCommand = &commandSpec{
+	Name:    "addfeed",
+	Summary: "adds a feed to be aggregated and follows it",
+	Args:    []argSpec{{Name: "name"}, {Name: "url"}},
+	Handler: middlewareLoggedIn(handlerAddFeed),
 }
 
 func handlerAddFeed(s *state, cmd command, user database.User) error {
-	if len(cmd.Args) < 2 {
-		return errors.New("too few arguments provided")
-	}
 	name := cmd.Args[0]
-	url := cmd.Args[1]
+	url, err := normalizeFeedURL(cmd.Args[1])
+	if err != nil {
+		return cmd.usageErrorf("%v", err)
+	}
 
 	user_id := user.ID
 
 	now := time.Now()
 
-	feed, err := s.db.GetFeedByURL(context.Background(), url)
+	feed, err := feedByURL(context.Background(), s.db, url)
+	if err == nil && feedURLKey(feed.Url) != feedURLKey(url) {
+		fmt.Fprintf(s.out, "%s has moved to %s\n", url, feed.Url)
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("could not look up feed: %w", err)
+	}
 	if err != nil {
 		feed_id := uuid.New()
 		feed, err = s.db.CreateFeed(
 			context.Background(),
 			database.CreateFeedParams{
-				ID:        feed_id,
-				CreatedAt: now,
-				UpdatedAt: now,
-				Name:      name,
-				Url:       url,
-				UserID:    user_id,
+				ID:           feed_id,
+				CreatedAt:    now,
+				UpdatedAt:    now,
+				Name:         name,
+				Url:          url,
+				UserID:       user_id,
+				CanonicalUrl: sql.NullString{String: feedURLKey(url), Valid: true},
 			},
 		)
-		fmt.Printf("Feed created:\n")
-		fmt.Printf("ID: %s\n", feed.ID)
-		fmt.Printf("Name: %s\n", feed.Name)
-		fmt.Printf("URL: %s\n", feed.Url)
-		fmt.Printf("UserID: %s\n", feed.UserID)
-		fmt.Printf("CreatedAt: %s\n", feed.CreatedAt)
-		fmt.Printf("UpdatedAt: %s\n", feed.UpdatedAt)
+		fmt.Fprintf(s.out, "Feed created:\n")
+		fmt.Fprintf(s.out, "ID: %s\n", feed.ID)
+		fmt.Fprintf(s.out, "Name: %s\n", feed.Name)
+		fmt.Fprintf(s.out, "URL: %s\n", feed.Url)
+		fmt.Fprintf(s.out, "UserID: %s\n", feed.UserID)
+		fmt.Fprintf(s.out, "CreatedAt: %s\n", feed.CreatedAt)
+		fmt.Fprintf(s.out, "UpdatedAt: %s\n", feed.UpdatedAt)
 		if err != nil {
-			return fmt.Errorf("could not create feed: %v", err)
+			return fmt.Errorf("could not create feed: %w", err)
 		}
 
 	}
@@ -417,49 +704,59 @@ func handlerAddFeed(s *state, cmd command, user database.User) error {
 			},
 		)
 		if err != nil {
-			return fmt.Errorf("could not create feed follow record: %v", err)
+			return fmt.Errorf("could not create feed follow record: %w", err)
 		}
-		fmt.Printf("Followed feed with name: %v\n", feed.Name)
+		fmt.Fprintf(s.out, "Followed feed with name: %v\n", feed.Name)
 	} else if err != nil {
-		return fmt.Errorf("could not check if feed follow exists: %v", err)
+		return fmt.Errorf("could not check if feed follow exists: %w", err)
 	} else {
-		fmt.Println("feed is already followed by the user")
+		fmt.Fprintln(s.out, "feed is already followed by the user")
 	}
 
 	return nil
 }
 
+var feedsCommand = &commandSpec{
+	Name:    "feeds",
+	Summary: "lists all feeds and who added them",
+	Handler: handlerFeeds,
+}
+
 func handlerFeeds(s *state, cmd command) error {
 
 	feeds, err := s.db.GetFeedsWithUsers(context.Background())
 	if err != nil {
-		return fmt.Errorf("could not get feeds from handlerFeeds: %v", err)
+		return fmt.Errorf("could not get feeds from handlerFeeds: %w", err)
 	}
 
 	if len(feeds) == 0 {
-		fmt.Println("No feeds found.")
+		fmt.Fprintln(s.out, "No feeds found.")
 		return nil
 	}
 
 	for _, feed := range feeds {
-		fmt.Printf("Name: %v\n", feed.FeedName)
-		fmt.Printf("URL: %v\n", feed.FeedUrl)
-		fmt.Printf("User: %v\n", feed.UserName)
-		fmt.Println("-----")
+		fmt.Fprintf(s.out, "Name: %v\n", feed.FeedName)
+		fmt.Fprintf(s.out, "URL: %v\n", feed.FeedUrl)
+		fmt.Fprintf(s.out, "User: %v\n", feed.UserName)
+		fmt.Fprintln(s.out, "-----")
 	}
 
 	return nil
 }
 
+var followCommand = &commandSpec{
+	Name:    "follow",
+	Summary: "follows a feed someone already added",
+	Args:    []argSpec{{Name: "url", Complete: completeFeedURLs}},
+	Handler: middlewareLoggedIn(handlerFollow),
+}
+
 func handlerFollow(s *state, cmd command, user database.User) error {
-	if len(cmd.Args) < 1 {
-		return errors.New("no arguments provided")
-	}
 	url := cmd.Args[0]
 	feed_follow_id := uuid.New()
 	now := time.Now()
 
-	feed, err := s.db.GetFeedByURL(context.Background(), url)
+	feed, err := feedByURL(context.Background(), s.db, url)
 	if err != nil {
 		return fmt.Errorf("feed not found: %w", err)
 	}
@@ -478,11 +775,17 @@ func handlerFollow(s *state, cmd command, user database.User) error {
 		return fmt.Errorf("failed to create feed follow: %w", err)
 	}
 
-	fmt.Printf("Feed: %v\nUser: %v\n", feed_follows.FeedName, feed_follows.UserName)
+	fmt.Fprintf(s.out, "Feed: %v\nUser: %v\n", feed_follows.FeedName, feed_follows.UserName)
 
 	return nil
 }
 
+var followingCommand = &commandSpec{
+	Name:    "following",
+	Summary: "lists the feeds you follow",
+	Handler: middlewareLoggedIn(handlerFollowing),
+}
+
 func handlerFollowing(s *state, cmd command, user database.User) error {
 
 	following, err := s.db.GetFeedFollowsForUser(context.Background(), user.ID)
@@ -490,30 +793,42 @@ func handlerFollowing(s *state, cmd command, user database.User) error {
 		return fmt.Errorf("feed following for this user not found: %w", err)
 	}
 	if len(following) < 1 {
-		fmt.Println("Empty following")
+		fmt.Fprintln(s.out, "Empty following")
 	}
 
 	for _, follow := range following {
-		fmt.Printf("Feed name: %v\n", follow.FeedName)
+		fmt.Fprintf(s.out, "Feed name: %v\n", follow.FeedName)
 	}
 
 	return nil
 }
 
+var unfollowCommand = &commandSpec{
+	Name:    "unfollow",
+	Summary: "stops following a feed",
+	Args:    []argSpec{{Name: "url", Complete: completeFollowedFeedURLs}},
+	Handler: middlewareLoggedIn(handlerUnfollow),
+}
+
 func handlerUnfollow(s *state, cmd command, user database.User) error {
-	if len(cmd.Args) < 1 {
-		return errors.New("no arguments provided")
+	url := cmd.Args[0]
+	feed, err := feedByURL(context.Background(), s.db, url)
+	if err != nil {
+		return fmt.Errorf("feed not found: %w", err)
 	}
 
-	err := s.db.DeleteFeedFollow(
+	deleted, err := s.db.DeleteFeedFollowByFeedID(
 		context.Background(),
-		database.DeleteFeedFollowParams{
+		database.DeleteFeedFollowByFeedIDParams{
 			UserID: user.ID,
-			Url:    cmd.Args[0],
+			FeedID: feed.ID,
 		},
 	)
 	if err != nil {
-		return fmt.Errorf("error deleting feed follow: %v", err)
+		return fmt.Errorf("error deleting feed follow: %w", err)
+	}
+	if deleted == 0 {
+		return kindErrorf(kindNotFound, "feed %s is not followed by the user", feed.Name)
 	}
 
 	return nil
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/pressly/goose/v3"
+)
+
+// newMigrator reads the migrations embedded for the current backend,
+// along with the ones written in Go. It keeps goose's own version table,
+// so databases set up with the goose CLI carry on where they left off.
+func newMigrator(s *state) (*goose.Provider, error) {
+	if s.conn == nil {
+		return nil, fmt.Errorf("the %s backend has no schema to migrate", s.backend.name)
+	}
+	return goose.NewProvider(s.backend.dialect, s.conn, s.backend.migrations,
+		goose.WithGoMigrations(
+			canonicalURLsMigration(s, canonicalURLsVersion),
+			canonicalURLsMigration(s, canonicalSchemeVersion),
+		),
+	)
+}
+
+// goMigrationNames are what migrations written in Go, which have no file,
+// are listed as.
+var goMigrationNames = map[int64]string{
+	canonicalURLsVersion:   "17_canonical_urls.go",
+	canonicalSchemeVersion: "20_canonical_url_scheme.go",
+}
+
+func migrationName(source *goose.Source) string {
+	if source.Path == "" {
+		return goMigrationNames[source.Version]
+	}
+	return source.Path
+}
+
+// checkSchema refuses to run against a database whose schema does not
+// match the migrations this binary was built with.
+func checkSchema(ctx context.Context, s *state) error {
+	if s.conn == nil {
+		return nil
+	}
+	migrator, err := newMigrator(s)
+	if err != nil {
+		return err
+	}
+	current, err := migrator.GetDBVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("could not read schema version: %w", err)
+	}
+	target := latestVersion(migrator)
+	if current < target {
+		return kindErrorf(kindBackend, "database schema is at version %d but gator needs %d. run gator migrate up", current, target)
+	}
+	if current > target {
+		return kindErrorf(kindBackend, "database schema is at version %d, newer than the %d this gator knows about. upgrade gator", current, target)
+	}
+	return nil
+}
+
+// latestVersion is the newest migration gator knows about. goose keeps the
+// embedded files in directory order, where 10_ sorts before 9_, so its own
+// target version cannot be trusted.
+func latestVersion(migrator *goose.Provider) int64 {
+	var latest int64
+	for _, source := range migrator.ListSources() {
+		latest = max(latest, source.Version)
+	}
+	return latest
+}
+
+var migrateCommand = &commandSpec{
+	Name:    "migrate",
+	Summary: "applies or rolls back schema migrations",
+	Subcommands: []*commandSpec{
+		{
+			Name:    "up",
+			Summary: "applies pending migrations",
+			Flags:   []flagSpec{int64Flag("to", 0, "migrate up to this version instead of the latest")},
+			Handler: handlerMigrateUp,
+		},
+		{
+			Name:    "down",
+			Summary: "rolls back the latest migration, or down to a version",
+			Details: "Asks first since the data in rolled back tables and columns is lost.",
+			Flags: []flagSpec{
+				stringFlag("to", "", "roll back to this version instead of by one; 0 undoes everything"),
+				boolFlag("yes", "do not ask for confirmation"),
+			},
+			Handler: handlerMigrateDown,
+		},
+		{
+			Name:    "status",
+			Summary: "lists migrations and when they were applied",
+			Handler: handlerMigrateStatus,
+		},
+	},
+}
+
+func handlerMigrateUp(s *state, cmd command) error {
+	migrator, err := newMigrator(s)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	var results []*goose.MigrationResult
+	if to := cmd.Int64("to"); to > 0 {
+		results, err = migrator.UpTo(ctx, to)
+	} else {
+		results, err = migrator.Up(ctx)
+	}
+	printMigrationResults(s, results)
+	if err != nil {
+		return fmt.Errorf("migration failed: %w", err)
+	}
+
+	version, err := migrator.GetDBVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("could not read schema version: %w", err)
+	}
+	if len(results) == 0 {
+		fmt.Fprintln(s.out, "Schema is up to date")
+	} else {
+		fmt.Fprintf(s.out, "Schema is at version %d\n", version)
+	}
+	if version < latestVersion(migrator) {
+		return nil
+	}
+	return issueLegacyResets(ctx, s)
+}
+
+// issueLegacyResets prints reset tokens for the accounts from before
+// passwords existed, which can't log in without one. Whoever runs migrate
+// owns the database and hands them out; running it again replaces them.
+func issueLegacyResets(ctx context.Context, s *state) error {
+	users, err := s.db.GetUsersWithoutPassword(ctx)
+	if err != nil {
+		return fmt.Errorf("could not list users without a password: %w", err)
+	}
+	if len(users) == 0 {
+		return nil
+	}
+
+	width := 0
+	for _, user := range users {
+		width = max(width, len(user.Name))
+	}
+	fmt.Fprintln(s.out, "\nThese users have no password and can't log in until they set one with their reset token:")
+	for _, user := range users {
+		token, expires, err := issuePasswordReset(ctx, s, user)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(s.out, "  %-*s  %s  (valid until %s)\n", width, user.Name, token, expires.Format(time.RFC1123))
+	}
+	fmt.Fprintln(s.out, "They log in with: gator login --reset-token <token> <username>")
+	return nil
+}
+
+// handlerMigrateDown rolls back one migration, or down to --to. Rolling
+// back drops tables and columns along with their data, so it asks first.
+func handlerMigrateDown(s *state, cmd command) error {
+	to := cmd.String("to")
+	migrator, err := newMigrator(s)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	current, err := migrator.GetDBVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("could not read schema version: %w", err)
+	}
+	if current == 0 {
+		fmt.Fprintln(s.out, "No migrations to roll back")
+		return nil
+	}
+
+	question := fmt.Sprintf("Roll back migration %d? Data in the tables and columns it added will be lost.", current)
+	var target int64
+	if to != "" {
+		target, err = strconv.ParseInt(to, 10, 64)
+		if err != nil || target < 0 {
+			return cmd.usageErrorf("invalid version %q", to)
+		}
+		if target >= current {
+			fmt.Fprintf(s.out, "Schema is already at version %d\n", current)
+			return nil
+		}
+		question = fmt.Sprintf("Roll back from version %d to %d? Data in the tables and columns those migrations added will be lost.", current, target)
+	}
+	if !confirm(s, question, cmd.Bool("yes")) {
+		fmt.Fprintln(s.out, "Aborted")
+		return nil
+	}
+
+	var results []*goose.MigrationResult
+	if to != "" {
+		results, err = migrator.DownTo(ctx, target)
+	} else {
+		var result *goose.MigrationResult
+		result, err = migrator.Down(ctx)
+		if result != nil {
+			results = append(results, result)
+		}
+	}
+	printMigrationResults(s, results)
+	if err != nil {
+		return fmt.Errorf("rollback failed: %w", err)
+	}
+
+	version, err := migrator.GetDBVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("could not read schema version: %w", err)
+	}
+	fmt.Fprintf(s.out, "Schema is at version %d\n", version)
+	return nil
+}
+
+func handlerMigrateStatus(s *state, cmd command) error {
+	migrator, err := newMigrator(s)
+	if err != nil {
+		return err
+	}
+	statuses, err := migrator.Status(context.Background())
+	if err != nil {
+		return fmt.Errorf("could not read migration status: %w", err)
+	}
+
+	slices.SortFunc(statuses, func(a, b *goose.MigrationStatus) int {
+		return cmp.Compare(a.Source.Version, b.Source.Version)
+	})
+
+	pending := 0
+	for _, status := range statuses {
+		applied := "pending"
+		if status.State == goose.StateApplied {
+			applied = status.AppliedAt.Local().Format(time.DateTime)
+		} else {
+			pending++
+		}
+		fmt.Fprintf(s.out, "* %3d  %-20s %s\n", status.Source.Version, applied, migrationName(status.Source))
+	}
+	if pending > 0 {
+		fmt.Fprintf(s.out, "%d pending, run gator migrate up\n", pending)
+	} else {
+		fmt.Fprintln(s.out, "Schema is up to date")
+	}
+	return nil
+}
+
+func printMigrationResults(s *state, results []*goose.MigrationResult) {
+	for _, result := range results {
+		if result.Error != nil {
+			continue
+		}
+		fmt.Fprintf(s.out, "%s %s (%s)\n", result.Direction, migrationName(result.Source), result.Duration.Round(time.Millisecond))
+	}
+}
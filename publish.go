@@ -0,0 +1,338 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/richardteaman/gator/internal/database"
+)
+
+const (
+	gatorHomepage      = "https://github.com/richardteaman/gator"
+	defaultPublishSize = 50
+)
+
+// timeline is a user's followed posts prepared for export as a feed document.
+type timeline struct {
+	ID      string
+	Title   string
+	Link    string
+	SelfURL string
+	Author  string
+	Posts   []database.GetPostsForUserRow
+}
+
+type atomFeed struct {
+	XMLName   xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Updated   string      `xml:"updated"`
+	Author    atomPerson  `xml:"author"`
+	Generator string      `xml:"generator"`
+	Links     []atomLink  `xml:"link"`
+	Entries   []atomEntry `xml:"entry"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	ID        string     `xml:"id"`
+	Title     string     `xml:"title"`
+	Links     []atomLink `xml:"link"`
+	Published string     `xml:"published,omitempty"`
+	Updated   string     `xml:"updated"`
+	Content   *atomText  `xml:"content,omitempty"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type rssDocument struct {
+	XMLName xml.Name      `xml:"rss"`
+	Version string        `xml:"version,attr"`
+	AtomNS  string        `xml:"xmlns:atom,attr"`
+	Channel rssOutChannel `xml:"channel"`
+}
+
+type rssOutChannel struct {
+	Title         string       `xml:"title"`
+	Link          string       `xml:"link"`
+	Description   string       `xml:"description"`
+	AtomLink      *atomLink    `xml:"atom:link,omitempty"`
+	LastBuildDate string       `xml:"lastBuildDate"`
+	Generator     string       `xml:"generator"`
+	Items         []rssOutItem `xml:"item"`
+}
+
+type rssOutItem struct {
+	Title       string  `xml:"title,omitempty"`
+	Link        string  `xml:"link"`
+	Description string  `xml:"description,omitempty"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+var publishCommand = &commandSpec{
+	Name:    "publish",
+	Summary: "writes your timeline as an Atom or RSS document",
+	Details: "Use - as the file to write to stdout.",
+	Args:    []argSpec{{Name: "file"}},
+	Flags: []flagSpec{
+		stringFlag("format", "atom", "document format: atom or rss"),
+		intFlag("limit", defaultPublishSize, "number of posts to include"),
+		boolFlag("unread", "only include unread posts"),
+		boolFlag("all", "include posts muted by your rules"),
+		boolFlag("highlighted", "only include posts highlighted by your rules"),
+		stringFlag("link", gatorHomepage, "site link advertised by the feed"),
+	},
+	Handler: middlewareLoggedIn(handlerPublish),
+}
+
+func handlerPublish(s *state, cmd command, user database.User) error {
+	format := cmd.String("format")
+	if format != "atom" && format != "rss" {
+		return cmd.usageErrorf("unknown format %q, expected atom or rss", format)
+	}
+	path := cmd.Args[0]
+
+	tl, err := loadTimeline(context.Background(), s, user, int32(cmd.Int("limit")), postFilter{
+		UnreadOnly:  cmd.Bool("unread"),
+		All:         cmd.Bool("all"),
+		Highlighted: cmd.Bool("highlighted"),
+	})
+	if err != nil {
+		return err
+	}
+	tl.Link = cmd.String("link")
+
+	var out io.Writer = s.out
+	if path != "-" {
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("could not create output file: %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	switch format {
+	case "atom":
+		err = writeAtom(out, tl)
+	case "rss":
+		err = writeRSS(out, tl)
+	default:
+		return fmt.Errorf("unknown format %q, expected atom or rss", format)
+	}
+	if err != nil {
+		return fmt.Errorf("could not write feed: %w", err)
+	}
+
+	if path != "-" {
+		fmt.Fprintf(s.out, "Published %d posts to %s\n", len(tl.Posts), path)
+	}
+	return nil
+}
+
+// loadTimeline picks the posts to publish like browse does: the user's
+// rules are the saved filter, leaving out muted posts unless filter.All.
+func loadTimeline(ctx context.Context, s *state, user database.User, limit int32, filter postFilter) (timeline, error) {
+	filtered, err := loadFilteredPosts(ctx, s, user.ID, limit, filter)
+	if err != nil {
+		return timeline{}, err
+	}
+
+	posts := make([]database.GetPostsForUserRow, 0, len(filtered))
+	for _, post := range filtered {
+		posts = append(posts, post.GetPostsForUserRow)
+	}
+	return timeline{
+		ID:     "urn:uuid:" + user.ID.String(),
+		Title:  "gator: " + user.Name,
+		Author: user.Name,
+		Posts:  posts,
+	}, nil
+}
+
+// postDate is the best date we know for a post: its publish date if the
+// source feed gave one, otherwise when we first stored it.
+func postDate(post database.GetPostsForUserRow) time.Time {
+	if post.PublishedAt.Valid {
+		return post.PublishedAt.Time
+	}
+	return post.CreatedAt
+}
+
+func (tl timeline) updated() time.Time {
+	var latest time.Time
+	for _, post := range tl.Posts {
+		if d := postDate(post); d.After(latest) {
+			latest = d
+		}
+	}
+	if latest.IsZero() {
+		return time.Now()
+	}
+	return latest
+}
+
+func writeAtom(w io.Writer, tl timeline) error {
+	doc := atomFeed{
+		ID:        tl.ID,
+		Title:     tl.Title,
+		Updated:   tl.updated().UTC().Format(time.RFC3339),
+		Author:    atomPerson{Name: tl.Author},
+		Generator: "gator",
+		Links:     []atomLink{{Rel: "alternate", Href: tl.Link}},
+	}
+	if tl.SelfURL != "" {
+		doc.Links = append(doc.Links, atomLink{Rel: "self", Type: "application/atom+xml", Href: tl.SelfURL})
+	}
+
+	for _, post := range tl.Posts {
+		entry := atomEntry{
+			ID:      "urn:uuid:" + post.ID.String(),
+			Title:   post.Title.String,
+			Links:   []atomLink{{Rel: "alternate", Href: post.Url}},
+			Updated: postDate(post).UTC().Format(time.RFC3339),
+		}
+		if post.PublishedAt.Valid {
+			entry.Published = post.PublishedAt.Time.UTC().Format(time.RFC3339)
+		}
+		if post.Description.Valid {
+			entry.Content = &atomText{Type: "html", Body: post.Description.String}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+
+	return encodeXML(w, doc)
+}
+
+func writeRSS(w io.Writer, tl timeline) error {
+	doc := rssDocument{
+		Version: "2.0",
+		AtomNS:  "http://www.w3.org/2005/Atom",
+		Channel: rssOutChannel{
+			Title:         tl.Title,
+			Link:          tl.Link,
+			Description:   "Posts from feeds followed by " + tl.Author,
+			LastBuildDate: tl.updated().UTC().Format(time.RFC1123Z),
+			Generator:     "gator",
+		},
+	}
+	if tl.SelfURL != "" {
+		doc.Channel.AtomLink = &atomLink{Rel: "self", Type: "application/rss+xml", Href: tl.SelfURL}
+	}
+
+	for _, post := range tl.Posts {
+		doc.Channel.Items = append(doc.Channel.Items, rssOutItem{
+			Title:       post.Title.String,
+			Link:        post.Url,
+			Description: post.Description.String,
+			GUID:        rssGUID{Value: "urn:uuid:" + post.ID.String()},
+			PubDate:     postDate(post).UTC().Format(time.RFC1123Z),
+		})
+	}
+
+	return encodeXML(w, doc)
+}
+
+func encodeXML(w io.Writer, doc any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// handlePublishedFeed serves a user's timeline as Atom or RSS so other
+// readers can subscribe to it. It shows what they have read and what
+// their rules do, so only they and admins get it; readers pass a
+// read-scoped API token or basic auth.
+func (a *apiServer) handlePublishedFeed(format string) authedHandler {
+	return func(w http.ResponseWriter, r *http.Request, authed database.User) {
+		user := authed
+		if name := r.PathValue("name"); name != authed.Name {
+			if authed.Role != roleAdmin {
+				respondError(w, http.StatusForbidden, "only the user or an admin can read their timeline")
+				return
+			}
+			var err error
+			user, err = a.state.db.GetUser(r.Context(), name)
+			if errors.Is(err, sql.ErrNoRows) {
+				respondError(w, http.StatusNotFound, "user not found")
+				return
+			}
+			if err != nil {
+				respondInternalError(w, "could not fetch user", err)
+				return
+			}
+		}
+
+		limit, _, err := parsePage(r)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		filter, err := parsePostFilter(r)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		tl, err := loadTimeline(r.Context(), a.state, user, limit, filter)
+		if err != nil {
+			respondInternalError(w, "could not load timeline", err)
+			return
+		}
+		tl.Link = gatorHomepage
+		tl.SelfURL = requestURL(r)
+
+		switch format {
+		case "atom":
+			w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+			err = writeAtom(w, tl)
+		default:
+			w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+			err = writeRSS(w, tl)
+		}
+		if err != nil {
+			log.Println("could not write published feed:", err)
+		}
+	}
+}
+
+func requestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + r.URL.RequestURI()
+}
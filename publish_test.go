@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestPublishedFeedEndpoint(t *testing.T) {
+	ts, _ := withPosts(t)
+	ts.run("rule add mute category sponsored", "")
+	api := newAPIClient(ts)
+	// feed readers can hold a read-only token
+	auth := ts.createToken("--scope read reader")
+
+	api.expect(http.StatusUnauthorized, "GET", "/api/users/alice/feed.atom", "", "", nil)
+	resp, body := api.do("GET", "/api/users/alice/feed.atom", auth, "")
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/atom+xml") {
+		t.Fatalf("feed.atom: %s, Content-Type %q", resp.Status, resp.Header.Get("Content-Type"))
+	}
+	var atom atomFeed
+	if err := xml.Unmarshal([]byte(body), &atom); err != nil {
+		t.Fatalf("feed.atom is not valid XML: %v\n%s", err, body)
+	}
+	if len(atom.Entries) != 1 || atom.Entries[0].Title != "Hello gophers" {
+		t.Errorf("feed.atom entries %+v, want only the post that isn't muted", atom.Entries)
+	}
+	self := api.srv.URL + "/api/users/alice/feed.atom"
+	if !strings.Contains(body, `rel="self" type="application/atom+xml" href="`+self+`"`) {
+		t.Errorf("feed.atom doesn't link to itself at %s:\n%s", self, body)
+	}
+
+	resp, body = api.do("GET", "/api/users/alice/feed.rss?all=true&limit=5", basicAuth("alice", testPassword), "")
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/rss+xml") {
+		t.Fatalf("feed.rss: %s, Content-Type %q", resp.Status, resp.Header.Get("Content-Type"))
+	}
+	var rss struct {
+		Version string `xml:"version,attr"`
+		Items   []struct {
+			Title   string `xml:"title"`
+			GUID    string `xml:"guid"`
+			PubDate string `xml:"pubDate"`
+		} `xml:"channel>item"`
+	}
+	if err := xml.Unmarshal([]byte(body), &rss); err != nil {
+		t.Fatalf("feed.rss is not valid XML: %v\n%s", err, body)
+	}
+	if rss.Version != "2.0" || len(rss.Items) != 2 {
+		t.Errorf("feed.rss?all=true: version %q with %d items, want 2.0 with both posts", rss.Version, len(rss.Items))
+	}
+	for _, item := range rss.Items {
+		if !strings.HasPrefix(item.GUID, "urn:uuid:") || item.PubDate == "" {
+			t.Errorf("feed.rss item %+v lacks a guid or date", item)
+		}
+	}
+
+	api.expect(http.StatusOK, "GET", "/api/users/alice/feed.rss?unread=true&highlighted=false", auth, "", nil)
+	api.expect(http.StatusBadRequest, "GET", "/api/users/alice/feed.rss?highlighted=maybe", auth, "", nil)
+	api.expect(http.StatusBadRequest, "GET", "/api/users/alice/feed.atom?limit=0", auth, "", nil)
+
+	// other users' timelines show what they read and mute; admins may look
+	ts.registerUser("bob")
+	api.expect(http.StatusForbidden, "GET", "/api/users/alice/feed.atom", basicAuth("bob", testPassword), "", nil)
+	api.expect(http.StatusOK, "GET", "/api/users/bob/feed.atom", basicAuth("bob", testPassword), "", nil)
+	api.expect(http.StatusOK, "GET", "/api/users/bob/feed.atom", auth, "", nil)
+	api.expect(http.StatusNotFound, "GET", "/api/users/nobody/feed.atom", auth, "", nil)
+}
+
+func TestPublishedFeedEndpointBackendError(t *testing.T) {
+	ts := newSession(t, "sqlite://{dir}/gator.db")
+	ts.run("migrate up", "")
+	ts.registerUser("alice")
+	api := newAPIClient(ts)
+	auth := basicAuth("alice", testPassword)
+	api.expect(http.StatusOK, "GET", "/api/users/alice/feed.atom", auth, "", nil)
+
+	// a database that is gone is not a missing user
+	ts.s.conn.Close()
+	api.expect(http.StatusInternalServerError, "GET", "/api/users/alice/feed.atom", auth, "", nil)
+}
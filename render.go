@@ -0,0 +1,322 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const defaultRenderWidth = 80
+
+// lineBreak marks a <br> inside inline text so it survives whitespace collapsing.
+const lineBreak = "\u2028"
+
+type listState struct {
+	ordered bool
+	counter int
+}
+
+// htmlRenderer turns an HTML fragment into text for the terminal.
+// In plain mode it drops all decoration and wrapping so the output can be piped.
+type htmlRenderer struct {
+	width  int
+	plain  bool
+	out    strings.Builder
+	inline strings.Builder
+	indent []string
+	bullet string
+	lists  []listState
+	links  []string
+	// listStart asks for a blank line before the first item of a top-level list.
+	listStart bool
+}
+
+// renderHTML renders description HTML as wrapped terminal text,
+// with links collected as numbered footnotes.
+func renderHTML(src string, width int) string {
+	r := &htmlRenderer{width: width}
+	return r.render(src)
+}
+
+// stripHTML reduces description HTML to plain text without any markup.
+func stripHTML(src string) string {
+	r := &htmlRenderer{plain: true}
+	return r.render(src)
+}
+
+func (r *htmlRenderer) render(src string) string {
+	nodes, err := html.ParseFragment(strings.NewReader(src), &html.Node{
+		Type:     html.ElementNode,
+		Data:     "body",
+		DataAtom: atom.Body,
+	})
+	if err != nil {
+		return src
+	}
+
+	for _, n := range nodes {
+		r.walk(n)
+	}
+	r.flush()
+
+	if len(r.links) > 0 {
+		r.out.WriteString("\n")
+		for i, link := range r.links {
+			fmt.Fprintf(&r.out, "\n[%d] %s", i+1, link)
+		}
+	}
+
+	return strings.TrimRight(r.out.String(), "\n")
+}
+
+func (r *htmlRenderer) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		r.inline.WriteString(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		r.walkChildren(n)
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Head, atom.Iframe, atom.Noscript, atom.Object, atom.Template:
+		return
+
+	case atom.Br:
+		r.inline.WriteString(lineBreak)
+
+	case atom.Img:
+		alt := strings.TrimSpace(attr(n, "alt"))
+		if !r.plain && alt != "" {
+			r.inline.WriteString("[image: " + alt + "]")
+		}
+
+	case atom.A:
+		href := strings.TrimSpace(attr(n, "href"))
+		r.walkChildren(n)
+		text := strings.TrimSpace(textContent(n))
+		if r.plain || href == "" || strings.HasPrefix(href, "#") || href == text {
+			return
+		}
+		r.links = append(r.links, href)
+		r.inline.WriteString("[" + strconv.Itoa(len(r.links)) + "]")
+
+	case atom.Em, atom.I, atom.Cite:
+		r.wrapInline(n, "_")
+	case atom.Strong, atom.B:
+		r.wrapInline(n, "*")
+	case atom.Code, atom.Kbd, atom.Samp, atom.Tt:
+		r.wrapInline(n, "`")
+
+	case atom.Pre:
+		r.flush()
+		r.writePre(textContent(n))
+
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		r.flush()
+		if !r.plain {
+			level := int(n.Data[1] - '0')
+			r.inline.WriteString(strings.Repeat("#", level) + " ")
+		}
+		r.walkChildren(n)
+		r.flush()
+
+	case atom.Blockquote:
+		r.flush()
+		r.pushIndent("> ")
+		r.walkChildren(n)
+		r.flush()
+		r.popIndent()
+
+	case atom.Ul, atom.Ol:
+		r.flush()
+		r.listStart = len(r.lists) == 0
+		r.lists = append(r.lists, listState{ordered: n.DataAtom == atom.Ol})
+		r.walkChildren(n)
+		r.flush()
+		r.lists = r.lists[:len(r.lists)-1]
+
+	case atom.Li:
+		r.flush()
+		marker := "- "
+		if len(r.lists) > 0 {
+			list := &r.lists[len(r.lists)-1]
+			list.counter++
+			if list.ordered {
+				marker = strconv.Itoa(list.counter) + ". "
+			}
+		}
+		r.bullet = marker
+		r.pushIndent(strings.Repeat(" ", len(marker)))
+		r.walkChildren(n)
+		r.flush()
+		r.popIndent()
+
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Header, atom.Footer,
+		atom.Figure, atom.Figcaption, atom.Table, atom.Tr, atom.Dl, atom.Dt, atom.Dd, atom.Hr:
+		r.flush()
+		r.walkChildren(n)
+		r.flush()
+
+	default:
+		r.walkChildren(n)
+	}
+}
+
+func (r *htmlRenderer) walkChildren(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		r.walk(c)
+	}
+}
+
+func (r *htmlRenderer) wrapInline(n *html.Node, marker string) {
+	if r.plain || strings.TrimSpace(textContent(n)) == "" {
+		r.walkChildren(n)
+		return
+	}
+	r.inline.WriteString(marker)
+	r.walkChildren(n)
+	r.inline.WriteString(marker)
+}
+
+func (r *htmlRenderer) pushIndent(s string) {
+	if r.plain {
+		s = ""
+	}
+	r.indent = append(r.indent, s)
+}
+
+func (r *htmlRenderer) popIndent() {
+	r.indent = r.indent[:len(r.indent)-1]
+}
+
+// startBlock separates a new block from the previous one. List items are
+// kept together, everything else gets a blank line in between.
+func (r *htmlRenderer) startBlock() {
+	tight := r.plain || (len(r.lists) > 0 && !r.listStart)
+	r.listStart = false
+
+	switch {
+	case r.out.Len() == 0:
+	case tight:
+		r.out.WriteString("\n")
+	default:
+		r.out.WriteString("\n\n")
+	}
+}
+
+func (r *htmlRenderer) prefixes() (first, rest string) {
+	rest = strings.Join(r.indent, "")
+	first = rest
+	if r.bullet != "" && len(r.indent) > 0 {
+		first = strings.Join(r.indent[:len(r.indent)-1], "") + r.bullet
+	}
+	return first, rest
+}
+
+func (r *htmlRenderer) flush() {
+	text := r.inline.String()
+	r.inline.Reset()
+
+	var lines []string
+	for _, segment := range strings.Split(text, lineBreak) {
+		words := strings.Fields(segment)
+		if len(words) == 0 {
+			continue
+		}
+		if r.plain {
+			lines = append(lines, strings.Join(words, " "))
+			continue
+		}
+		width := r.width - utf8.RuneCountInString(strings.Join(r.indent, ""))
+		lines = append(lines, wrapWords(words, width)...)
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	first, rest := r.prefixes()
+	r.bullet = ""
+	r.startBlock()
+	for i, line := range lines {
+		if i == 0 {
+			r.out.WriteString(first + line)
+			continue
+		}
+		r.out.WriteString("\n" + rest + line)
+	}
+}
+
+func (r *htmlRenderer) writePre(text string) {
+	text = strings.Trim(text, "\n")
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	prefix := strings.Join(r.indent, "")
+	if !r.plain {
+		prefix += "    "
+	}
+	r.startBlock()
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			r.out.WriteString("\n")
+		}
+		r.out.WriteString(strings.TrimRight(prefix+line, " \t"))
+	}
+}
+
+// wrapWords greedily fills lines up to width runes. Words longer than
+// the width are placed on a line of their own rather than split.
+func wrapWords(words []string, width int) []string {
+	if width < 20 {
+		width = 20
+	}
+
+	var lines []string
+	var line strings.Builder
+	lineLen := 0
+	for _, word := range words {
+		wordLen := utf8.RuneCountInString(word)
+		if lineLen > 0 && lineLen+1+wordLen > width {
+			lines = append(lines, line.String())
+			line.Reset()
+			lineLen = 0
+		}
+		if lineLen > 0 {
+			line.WriteString(" ")
+			lineLen++
+		}
+		line.WriteString(word)
+		lineLen += wordLen
+	}
+	if lineLen > 0 {
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(textContent(c))
+	}
+	return sb.String()
+}
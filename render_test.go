@@ -0,0 +1,103 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestRenderHTML(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"empty", "", ""},
+		{"plain text", "  just   some\n text ", "just some text"},
+		{"paragraphs", "<p>one</p><p>two</p>", "one\n\ntwo"},
+		{"inline markup", "<p>a <b>bold</b> <em>move</em> in <code>go</code></p>", "a *bold* _move_ in `go`"},
+		{"empty inline markup", "<p>a<b> </b>b</p>", "a b"},
+		{"br", "first<br>second<br/>third", "first\nsecond\nthird"},
+		{"headings", "<h1>Title</h1><p>text</p><h3>Sub</h3>", "# Title\n\ntext\n\n### Sub"},
+		{"unordered list", "<p>intro</p><ul><li>a</li><li>b</li></ul><p>after</p>", "intro\n\n- a\n- b\n\nafter"},
+		{"ordered list", "<ol><li>a</li><li>b</li></ol>", "1. a\n2. b"},
+		{"nested list", "<ul><li>a<ol><li>x</li><li>y</li></ol></li><li>b</li></ul>", "- a\n  1. x\n  2. y\n- b"},
+		{"blockquote", "<p>said:</p><blockquote><p>one</p><p>two</p></blockquote>", "said:\n\n> one\n\n> two"},
+		{"pre", "<p>code:</p><pre>\nif x {\n\treturn\n}\n</pre>", "code:\n\n    if x {\n    \treturn\n    }"},
+		{"pre in blockquote", "<blockquote><pre>x := 1</pre></blockquote>", ">     x := 1"},
+		{"blank pre", "<pre>\n  \n</pre>", ""},
+		{"links as footnotes", `<p>see <a href="https://a.example/">this</a> and <a href="https://b.example/">that</a></p>`,
+			"see this[1] and that[2]\n\n[1] https://a.example/\n[2] https://b.example/"},
+		{"link to itself", `<a href="https://a.example/">https://a.example/</a>`, "https://a.example/"},
+		{"fragment link", `<a href="#note">note</a>`, "note"},
+		{"image alt", `<img src="https://a.example/x.png" alt=" A cat "> <img src="https://a.example/y.png">`, "[image: A cat]"},
+		{"hidden content", "<script>alert(1)</script><style>p{}</style>shown", "shown"},
+	}
+	for _, tt := range tests {
+		if got := renderHTML(tt.src, defaultRenderWidth); got != tt.want {
+			t.Errorf("%s: renderHTML(%q) =\n%s\nwant\n%s", tt.name, tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestStripHTML(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		{"<p>one</p><p>two</p>", "one\ntwo"},
+		// list items keep their bullet so they stay apart from the text around them
+		{"<h2>Title</h2><ul><li>a</li></ul><blockquote>quote</blockquote>", "Title\n- a\nquote"},
+		{`<p>a <b>bold</b> <a href="https://a.example/">link</a></p><img alt="cat">`, "a bold link"},
+		{"<pre>x := 1</pre>", "x := 1"},
+	}
+	for _, tt := range tests {
+		if got := stripHTML(tt.src); got != tt.want {
+			t.Errorf("stripHTML(%q) = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestRenderHTMLWidth(t *testing.T) {
+	words := strings.Repeat("gophers dig tunnels ", 20)
+	long := strings.Repeat("x", 70)
+	src := "<p>" + words + "</p><blockquote>" + words + "</blockquote><ul><li>" + words + "</li></ul><p>a " + long + " b</p>"
+
+	for _, width := range []int{40, 60} {
+		got := renderHTML(src, width)
+		for _, line := range strings.Split(got, "\n") {
+			if n := utf8.RuneCountInString(line); n > width && !strings.Contains(line, long) {
+				t.Errorf("width %d: line of %d runes: %q", width, n, line)
+			}
+		}
+		for _, want := range []string{"\n> gophers", "\n- gophers", "\n  gophers", "\n" + long + "\n"} {
+			if !strings.Contains(got, want) {
+				t.Errorf("width %d: output lacks %q:\n%s", width, want, got)
+			}
+		}
+	}
+
+	// very narrow widths still leave room for a few words
+	for _, line := range strings.Split(renderHTML("<p>"+words+"</p>", 5), "\n") {
+		if n := utf8.RuneCountInString(line); n > 20 || n < 10 {
+			t.Errorf("width 5: line of %d runes: %q", n, line)
+		}
+	}
+}
+
+func TestWrapWords(t *testing.T) {
+	tests := []struct {
+		words []string
+		width int
+		want  []string
+	}{
+		{nil, 30, nil},
+		{[]string{"a", "b", "c"}, 30, []string{"a b c"}},
+		{strings.Fields("the quick brown fox jumps over the lazy dog"), 20, []string{"the quick brown fox", "jumps over the lazy", "dog"}},
+		{[]string{"short", strings.Repeat("y", 25), "end"}, 20, []string{"short", strings.Repeat("y", 25), "end"}},
+		{strings.Fields("ä ö ü ß é è ê ë ï î ô û ç ñ å ø æ œ"), 20, []string{"ä ö ü ß é è ê ë ï î", "ô û ç ñ å ø æ œ"}},
+	}
+	for _, tt := range tests {
+		got := wrapWords(tt.words, tt.width)
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("wrapWords(%q, %d) = %q, want %q", tt.words, tt.width, got, tt.want)
+		}
+	}
+}
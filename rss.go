@@ -6,22 +6,43 @@ import (
 	"html"
 	"io"
 	"net/http"
+	"strings"
 )
 
 type RSSFeed struct {
 	Channel struct {
-		Title       string    `xml:"title"`
-		Link        string    `xml:"link"`
-		Description string    `xml:"description"`
-		Item        []RSSItem `xml:"item"`
+		Title string `xml:"title"`
+		// AtomLinks must come before Link so <atom:link> elements are not
+		// taken as the channel's plain <link>.
+		AtomLinks   []AtomLink `xml:"http://www.w3.org/2005/Atom link"`
+		Link        string     `xml:"link"`
+		Description string     `xml:"description"`
+		Item        []RSSItem  `xml:"item"`
 	} `xml:"channel"`
 }
 
+type AtomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+}
+
 type RSSItem struct {
 	Title       string `xml:"title"`
 	Link        string `xml:"link"`
 	Description string `xml:"description"`
 	PubDate     string `xml:"pubDate"`
+	Author      string `xml:"author"`
+	// Creator picks up <dc:creator>, which many feeds use instead of <author>.
+	Creator    string   `xml:"creator"`
+	Categories []string `xml:"category"`
+}
+
+// author is the item's author as given by either <author> or <dc:creator>.
+func (item RSSItem) author() string {
+	if item.Author != "" {
+		return strings.TrimSpace(item.Author)
+	}
+	return strings.TrimSpace(item.Creator)
 }
 
 func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
@@ -44,8 +65,12 @@ func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 		return nil, err
 	}
 
+	return parseFeed(data)
+}
+
+func parseFeed(data []byte) (*RSSFeed, error) {
 	var rssData RSSFeed
-	err = xml.Unmarshal(data, &rssData)
+	err := xml.Unmarshal(data, &rssData)
 	if err != nil {
 		return nil, err
 	}
@@ -60,3 +85,19 @@ func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 
 	return &rssData, nil
 }
+
+// hubLinks returns the WebSub hub and the canonical topic URL advertised by
+// the feed, if any.
+func (f *RSSFeed) hubLinks() (hub, self string) {
+	for _, link := range f.Channel.AtomLinks {
+		switch link.Rel {
+		case "hub":
+			if hub == "" {
+				hub = link.Href
+			}
+		case "self":
+			self = link.Href
+		}
+	}
+	return hub, self
+}
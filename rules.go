@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richardteaman/gator/internal/database"
+)
+
+const (
+	ruleMute      = "mute"
+	ruleHighlight = "highlight"
+)
+
+var ruleMatchTypes = []string{"keyword", "regex", "author", "category"}
+
+// postRule is a stored rule ready for matching.
+type postRule struct {
+	database.PostRule
+	re *regexp.Regexp
+}
+
+// ruleSubject is the part of a post rules are matched against.
+type ruleSubject struct {
+	FeedID      uuid.UUID
+	Title       string
+	Description string
+	Author      string
+	Categories  []string
+}
+
+// ruleVerdict says what a user's rules make of a post. Mute and
+// highlight hold the first matching rule of each kind.
+type ruleVerdict struct {
+	Mute      *postRule
+	Highlight *postRule
+}
+
+var ruleCommand = &commandSpec{
+	Name:    "rule",
+	Summary: "manages the rules that mute or highlight posts",
+	Subcommands: []*commandSpec{
+		{
+			Name:    "add",
+			Summary: "adds a rule",
+			Details: "The action is mute or highlight, the type one of keyword, regex, author or category.",
+			Args: []argSpec{
+				{Name: "action", Complete: completeValues(ruleMute, ruleHighlight)},
+				{Name: "type", Complete: completeValues(ruleMatchTypes...)},
+				{Name: "pattern", Variadic: true},
+			},
+			Flags:   []flagSpec{stringFlag("feed", "", "only apply the rule to this feed")},
+			Handler: middlewareLoggedIn(handlerRuleAdd),
+		},
+		{
+			Name:    "remove",
+			Summary: "deletes a rule",
+			Args:    []argSpec{{Name: "id"}},
+			Handler: middlewareLoggedIn(handlerRuleRemove),
+		},
+	},
+}
+
+func handlerRuleAdd(s *state, cmd command, user database.User) error {
+	feedURL := cmd.String("feed")
+	action, matchType := cmd.Args[0], cmd.Args[1]
+	pattern := strings.TrimSpace(strings.Join(cmd.Args[2:], " "))
+
+	if action != ruleMute && action != ruleHighlight {
+		return cmd.usageErrorf("unknown rule action %q, expected mute or highlight", action)
+	}
+	if !isRuleMatchType(matchType) {
+		return cmd.usageErrorf("unknown rule type %q, expected one of %s", matchType, strings.Join(ruleMatchTypes, ", "))
+	}
+	if pattern == "" {
+		// an empty keyword is in every post
+		return cmd.usageErrorf("rule pattern can't be empty")
+	}
+	if matchType == "regex" {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return cmd.usageErrorf("invalid regex: %v", err)
+		}
+	}
+
+	ctx := context.Background()
+
+	var feedID uuid.NullUUID
+	if feedURL != "" {
+		feed, err := feedByURL(ctx, s.db, feedURL)
+		if err != nil {
+			return fmt.Errorf("feed %s not found: %w", feedURL, err)
+		}
+		feedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+
+	now := time.Now()
+	rule, err := s.db.CreatePostRule(ctx, database.CreatePostRuleParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		UserID:    user.ID,
+		FeedID:    feedID,
+		Action:    action,
+		MatchType: matchType,
+		Pattern:   pattern,
+	})
+	if err != nil {
+		return fmt.Errorf("could not create rule: %w", err)
+	}
+
+	fmt.Fprintf(s.out, "Rule %s added\n", rule.ID)
+	return nil
+}
+
+func handlerRuleRemove(s *state, cmd command, user database.User) error {
+	id, err := uuid.Parse(cmd.Args[0])
+	if err != nil {
+		return cmd.usageErrorf("invalid rule id %q", cmd.Args[0])
+	}
+
+	n, err := s.db.DeletePostRule(context.Background(), database.DeletePostRuleParams{ID: id, UserID: user.ID})
+	if err != nil {
+		return fmt.Errorf("could not remove rule: %w", err)
+	}
+	if n == 0 {
+		return kindErrorf(kindNotFound, "rule %s not found", id)
+	}
+
+	fmt.Fprintf(s.out, "Rule %s removed\n", id)
+	return nil
+}
+
+var rulesCommand = &commandSpec{
+	Name:    "rules",
+	Summary: "lists your rules",
+	Handler: middlewareLoggedIn(handlerRules),
+}
+
+func handlerRules(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+	rules, err := s.db.GetPostRulesForUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("could not list rules: %w", err)
+	}
+	if len(rules) == 0 {
+		fmt.Fprintln(s.out, "No rules")
+	}
+
+	for _, rule := range rules {
+		scope := "all feeds"
+		if rule.FeedID.Valid {
+			if feed, err := s.db.GetFeedByID(ctx, rule.FeedID.UUID); err == nil {
+				scope = feed.Url
+			}
+		}
+		fmt.Fprintf(s.out, "%s  %-9s  %-8s  %q  (%s)\n", rule.ID, rule.Action, rule.MatchType, rule.Pattern, scope)
+	}
+	return nil
+}
+
+func isRuleMatchType(matchType string) bool {
+	for _, t := range ruleMatchTypes {
+		if t == matchType {
+			return true
+		}
+	}
+	return false
+}
+
+// loadPostRules fetches and compiles a user's rules. A regex that no
+// longer compiles is skipped rather than failing the whole listing.
+func loadPostRules(ctx context.Context, s *state, userID uuid.UUID) ([]postRule, error) {
+	stored, err := s.db.GetPostRulesForUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("could not load rules: %w", err)
+	}
+
+	rules := make([]postRule, 0, len(stored))
+	for _, rule := range stored {
+		compiled := postRule{PostRule: rule}
+		if rule.MatchType == "regex" {
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				continue
+			}
+			compiled.re = re
+		}
+		rules = append(rules, compiled)
+	}
+	return rules, nil
+}
+
+// postFilter picks which of a user's posts to show. Posts muted by their
+// rules are left out unless All is set.
+type postFilter struct {
+	UnreadOnly  bool
+	All         bool
+	Highlighted bool
+}
+
+// filteredPost is a post that passed a postFilter, with what the rules
+// made of it.
+type filteredPost struct {
+	database.GetPostsForUserRow
+	verdict ruleVerdict
+}
+
+// filteredPostsPageSize is the fewest posts loadFilteredPosts asks the
+// database for at a time.
+const filteredPostsPageSize = 100
+
+// loadFilteredPosts returns up to limit of the user's newest posts that
+// pass filter.
+func loadFilteredPosts(ctx context.Context, s *state, userID uuid.UUID, limit int32, filter postFilter) ([]filteredPost, error) {
+	rules, err := loadPostRules(ctx, s, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// rules are applied here rather than in SQL, so keep paging until
+	// enough posts survive them. Pages don't shrink with limit, or a broad
+	// mute rule would take a query for every couple of posts.
+	pageSize := max(limit, filteredPostsPageSize)
+	var posts []filteredPost
+	for offset := int32(0); int32(len(posts)) < limit; offset += pageSize {
+		page, err := s.db.GetPostsForUser(ctx, database.GetPostsForUserParams{
+			UserID:     userID,
+			UnreadOnly: filter.UnreadOnly,
+			PageLimit:  pageSize,
+			PageOffset: offset,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not fetch posts for user: %w", err)
+		}
+
+		for _, post := range page {
+			verdict := evaluateRules(rules, ruleSubject{
+				FeedID:      post.FeedID,
+				Title:       post.Title.String,
+				Description: post.Description.String,
+				Author:      post.Author.String,
+				Categories:  splitCategories(post.Categories.String),
+			})
+			if (verdict.Mute != nil && !filter.All) || (filter.Highlighted && verdict.Highlight == nil) {
+				continue
+			}
+			if int32(len(posts)) < limit {
+				posts = append(posts, filteredPost{post, verdict})
+			}
+		}
+		if int32(len(page)) < pageSize {
+			break
+		}
+	}
+	return posts, nil
+}
+
+func evaluateRules(rules []postRule, post ruleSubject) ruleVerdict {
+	var verdict ruleVerdict
+	var text string
+
+	for i := range rules {
+		rule := &rules[i]
+		if rule.FeedID.Valid && rule.FeedID.UUID != post.FeedID {
+			continue
+		}
+		if rule.Action == ruleMute && verdict.Mute != nil || rule.Action == ruleHighlight && verdict.Highlight != nil {
+			continue
+		}
+
+		if text == "" {
+			text = post.Title + "\n" + stripHTML(post.Description)
+		}
+		if !rule.matches(post, text) {
+			continue
+		}
+
+		if rule.Action == ruleMute {
+			verdict.Mute = rule
+		} else {
+			verdict.Highlight = rule
+		}
+	}
+	return verdict
+}
+
+func (r *postRule) matches(post ruleSubject, text string) bool {
+	switch r.MatchType {
+	case "keyword":
+		return strings.Contains(strings.ToLower(text), strings.ToLower(r.Pattern))
+	case "regex":
+		return r.re != nil && r.re.MatchString(text)
+	case "author":
+		return post.Author != "" && strings.Contains(strings.ToLower(post.Author), strings.ToLower(r.Pattern))
+	case "category":
+		for _, category := range post.Categories {
+			if strings.EqualFold(category, r.Pattern) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r *postRule) String() string {
+	return fmt.Sprintf("%s %q", r.MatchType, r.Pattern)
+}
+
+// joinCategories stores a post's categories one per line.
+func joinCategories(categories []string) string {
+	cleaned := make([]string, 0, len(categories))
+	for _, category := range categories {
+		if category = strings.TrimSpace(category); category != "" {
+			cleaned = append(cleaned, category)
+		}
+	}
+	return strings.Join(cleaned, "\n")
+}
+
+func splitCategories(categories string) []string {
+	if categories == "" {
+		return nil
+	}
+	return strings.Split(categories, "\n")
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/richardteaman/gator/internal/database"
+	"github.com/richardteaman/gator/internal/storage"
+)
+
+// countingStore counts the pages of posts loaded through it.
+type countingStore struct {
+	storage.Store
+	pages int
+}
+
+func (c *countingStore) GetPostsForUser(ctx context.Context, arg database.GetPostsForUserParams) ([]database.GetPostsForUserRow, error) {
+	c.pages++
+	return c.Store.GetPostsForUser(ctx, arg)
+}
+
+func TestLoadFilteredPostsPaging(t *testing.T) {
+	ts := newSession(t, "memory:")
+	ts.registerUser("alice")
+	ts.run("addfeed Feed https://example.com/feed.xml", "")
+	ts.run("rule add mute keyword noise", "")
+
+	ctx := context.Background()
+	feed, err := ts.s.db.GetFeedByURL(ctx, "https://example.com/feed.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the first items are stored first and so are the oldest
+	var items []RSSItem
+	for i := range 3 {
+		items = append(items, RSSItem{Title: fmt.Sprintf("signal %d", i), Link: fmt.Sprintf("https://example.com/signal/%d", i)})
+	}
+	for i := range 250 {
+		items = append(items, RSSItem{Title: fmt.Sprintf("noise %d", i), Link: fmt.Sprintf("https://example.com/noise/%d", i)})
+	}
+	storeFeedItems(ctx, ts.s, feed, items)
+	user, err := ts.s.db.GetUser(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := &countingStore{Store: ts.s.db}
+	ts.s.db = store
+	posts, err := loadFilteredPosts(ctx, ts.s, user.ID, 2, postFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 2 || posts[0].Title.String != "signal 2" || posts[1].Title.String != "signal 1" {
+		t.Errorf("posts %+v, want the two newest signals", posts)
+	}
+	if store.pages != 3 {
+		t.Errorf("loaded %d pages of posts for 253 posts, want 3", store.pages)
+	}
+
+	store.pages = 0
+	posts, err = loadFilteredPosts(ctx, ts.s, user.ID, 2, postFilter{All: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 2 || posts[0].verdict.Mute == nil || store.pages != 1 {
+		t.Errorf("with muted posts: %d posts from %d pages, want 2 muted ones from 1", len(posts), store.pages)
+	}
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// allowedTags maps the elements kept in stored post content to the
+// attributes they may carry. Anything else is unwrapped to its children.
+var allowedTags = map[atom.Atom][]string{
+	atom.A:          {"href", "title"},
+	atom.Abbr:       {"title"},
+	atom.B:          nil,
+	atom.Blockquote: {"cite"},
+	atom.Br:         nil,
+	atom.Cite:       nil,
+	atom.Code:       nil,
+	atom.Dd:         nil,
+	atom.Del:        nil,
+	atom.Div:        nil,
+	atom.Dl:         nil,
+	atom.Dt:         nil,
+	atom.Em:         nil,
+	atom.Figcaption: nil,
+	atom.Figure:     nil,
+	atom.H1:         nil,
+	atom.H2:         nil,
+	atom.H3:         nil,
+	atom.H4:         nil,
+	atom.H5:         nil,
+	atom.H6:         nil,
+	atom.Hr:         nil,
+	atom.I:          nil,
+	atom.Img:        {"src", "alt", "title", "width", "height"},
+	atom.Ins:        nil,
+	atom.Kbd:        nil,
+	atom.Li:         nil,
+	atom.Mark:       nil,
+	atom.Ol:         {"start"},
+	atom.P:          nil,
+	atom.Pre:        nil,
+	atom.Q:          {"cite"},
+	atom.S:          nil,
+	atom.Small:      nil,
+	atom.Span:       nil,
+	atom.Strong:     nil,
+	atom.Sub:        nil,
+	atom.Sup:        nil,
+	atom.Table:      nil,
+	atom.Tbody:      nil,
+	atom.Td:         {"colspan", "rowspan"},
+	atom.Tfoot:      nil,
+	atom.Th:         {"colspan", "rowspan"},
+	atom.Thead:      nil,
+	atom.Time:       {"datetime"},
+	atom.Tr:         nil,
+	atom.U:          nil,
+	atom.Ul:         nil,
+}
+
+// droppedTags are removed together with everything inside them.
+var droppedTags = map[atom.Atom]bool{
+	atom.Applet:   true,
+	atom.Base:     true,
+	atom.Button:   true,
+	atom.Embed:    true,
+	atom.Form:     true,
+	atom.Frame:    true,
+	atom.Frameset: true,
+	atom.Head:     true,
+	atom.Iframe:   true,
+	atom.Input:    true,
+	atom.Link:     true,
+	atom.Math:     true,
+	atom.Meta:     true,
+	atom.Noscript: true,
+	atom.Object:   true,
+	atom.Script:   true,
+	atom.Select:   true,
+	atom.Style:    true,
+	atom.Svg:      true,
+	atom.Template: true,
+	atom.Textarea: true,
+	atom.Title:    true,
+}
+
+var urlAttrs = map[string]bool{
+	"href": true,
+	"src":  true,
+	"cite": true,
+}
+
+// sanitizeHTML reduces publisher HTML to a safe allowlist of tags and
+// attributes, resolves relative URLs against base and removes tracking pixels.
+func sanitizeHTML(src string, base string) string {
+	if strings.TrimSpace(src) == "" {
+		return ""
+	}
+
+	baseURL, err := url.Parse(base)
+	if err != nil || !baseURL.IsAbs() {
+		baseURL = nil
+	}
+
+	context := &html.Node{
+		Type:     html.ElementNode,
+		Data:     "body",
+		DataAtom: atom.Body,
+	}
+	nodes, err := html.ParseFragment(strings.NewReader(src), context)
+	if err != nil {
+		return html.EscapeString(src)
+	}
+
+	for _, n := range nodes {
+		context.AppendChild(n)
+	}
+	sanitizeChildren(context, baseURL)
+
+	var sb strings.Builder
+	for c := context.FirstChild; c != nil; c = c.NextSibling {
+		if err := html.Render(&sb, c); err != nil {
+			return html.EscapeString(src)
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+func sanitizeChildren(parent *html.Node, base *url.URL) {
+	c := parent.FirstChild
+	for c != nil {
+		next := c.NextSibling
+
+		switch c.Type {
+		case html.TextNode:
+		case html.ElementNode:
+			next = sanitizeElement(parent, c, base)
+		default:
+			parent.RemoveChild(c)
+		}
+
+		c = next
+	}
+}
+
+// sanitizeElement cleans n in place and returns the node to continue with,
+// which differs from n.NextSibling when n was unwrapped.
+func sanitizeElement(parent, n *html.Node, base *url.URL) *html.Node {
+	next := n.NextSibling
+
+	if droppedTags[n.DataAtom] {
+		parent.RemoveChild(n)
+		return next
+	}
+
+	allowed, ok := allowedTags[n.DataAtom]
+	if !ok {
+		// unwrap: hoist the children in place of n and sanitize them next
+		first := n.FirstChild
+		for n.FirstChild != nil {
+			child := n.FirstChild
+			n.RemoveChild(child)
+			parent.InsertBefore(child, n)
+		}
+		parent.RemoveChild(n)
+		if first != nil {
+			return first
+		}
+		return next
+	}
+
+	if n.DataAtom == atom.Img && isTrackingPixel(n) {
+		parent.RemoveChild(n)
+		return next
+	}
+
+	n.Attr = filterAttrs(n, allowed, base)
+
+	switch n.DataAtom {
+	case atom.Img:
+		if attr(n, "src") == "" {
+			parent.RemoveChild(n)
+			return next
+		}
+	case atom.A:
+		if attr(n, "href") != "" {
+			n.Attr = append(n.Attr, html.Attribute{Key: "rel", Val: "nofollow noopener noreferrer"})
+		}
+	}
+
+	sanitizeChildren(n, base)
+	return next
+}
+
+func filterAttrs(n *html.Node, allowed []string, base *url.URL) []html.Attribute {
+	var attrs []html.Attribute
+	for _, a := range n.Attr {
+		if a.Namespace != "" || !slices.Contains(allowed, a.Key) {
+			continue
+		}
+		if urlAttrs[a.Key] {
+			safe, ok := safeURL(a.Val, base, a.Key == "href")
+			if !ok {
+				continue
+			}
+			a.Val = safe
+		}
+		attrs = append(attrs, a)
+	}
+	return attrs
+}
+
+// safeURL resolves raw against base and only lets web links through;
+// mailto is accepted for hrefs. javascript:, data: and friends are rejected.
+func safeURL(raw string, base *url.URL, isHref bool) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", false
+	}
+	if isHref && strings.HasPrefix(raw, "#") {
+		return raw, true
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", false
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return u.String(), true
+	case "mailto":
+		if isHref {
+			return u.String(), true
+		}
+	}
+	return "", false
+}
+
+// isTrackingPixel reports whether an image is sized or styled to be
+// invisible, which is how newsletters and feed proxies count opens.
+func isTrackingPixel(n *html.Node) bool {
+	width, hasWidth := pixelSize(attr(n, "width"))
+	height, hasHeight := pixelSize(attr(n, "height"))
+
+	for _, decl := range strings.Split(attr(n, "style"), ";") {
+		prop, value, ok := strings.Cut(decl, ":")
+		if !ok {
+			continue
+		}
+		prop = strings.ToLower(strings.TrimSpace(prop))
+		value = strings.ToLower(strings.TrimSpace(value))
+		switch prop {
+		case "width":
+			width, hasWidth = pixelSize(value)
+		case "height":
+			height, hasHeight = pixelSize(value)
+		case "display", "visibility":
+			if value == "none" || value == "hidden" {
+				return true
+			}
+		}
+	}
+
+	if (hasWidth && width <= 1) && (hasHeight && height <= 1) {
+		return true
+	}
+	return (hasWidth && width == 0) || (hasHeight && height == 0)
+}
+
+func pixelSize(value string) (int, bool) {
+	value = strings.TrimSuffix(strings.TrimSpace(value), "px")
+	if value == "" {
+		return 0, false
+	}
+	size, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false
+	}
+	return size, true
+}
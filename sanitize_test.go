@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/richardteaman/gator/internal/database"
+)
+
+func TestSanitizeHTML(t *testing.T) {
+	const base = "https://example.com/posts/1"
+	tests := []struct {
+		name, src, want string
+	}{
+		{"empty", "  ", ""},
+		{"plain text", "just text", "just text"},
+		{"allowed tags", "<p>A <b>bold</b> <em>move</em></p>", "<p>A <b>bold</b> <em>move</em></p>"},
+		{"script dropped with content", `<p>hi</p><script>alert(1)</script>`, "<p>hi</p>"},
+		{"style dropped", `<style>p{}</style><p>x</p>`, "<p>x</p>"},
+		{"iframe dropped", `<iframe src="https://evil.example/"></iframe>ok`, "ok"},
+		{"unknown tags unwrapped", `<custom><b>kept</b></custom>`, "<b>kept</b>"},
+		{"event handlers removed", `<p onclick="alert(1)" class="x">x</p>`, "<p>x</p>"},
+		{"javascript href removed", `<a href="javascript:alert(1)">x</a>`, "<a>x</a>"},
+		{"javascript href with whitespace and case", `<a href=" JaVaScRiPt:alert(1)">x</a>`, "<a>x</a>"},
+		{"data src drops the image", `<img src="data:image/png;base64,AAAA">`, ""},
+		{"relative links resolved", `<a href="../about">about</a>`, `<a href="https://example.com/about" rel="nofollow noopener noreferrer">about</a>`},
+		{"fragment links kept", `<a href="#note">1</a>`, `<a href="#note" rel="nofollow noopener noreferrer">1</a>`},
+		{"mailto href kept", `<a href="mailto:me@example.com">me</a>`, `<a href="mailto:me@example.com" rel="nofollow noopener noreferrer">me</a>`},
+		{"mailto src rejected", `<img src="mailto:me@example.com">`, ""},
+		{"relative image resolved", `<img src="/a.png" alt="A">`, `<img src="https://example.com/a.png" alt="A"/>`},
+		{"tracking pixel removed", `<p>x<img src="https://t.example/p.gif" width="1" height="1"></p>`, "<p>x</p>"},
+		{"hidden image removed", `<img src="https://t.example/p.gif" style="display: none">`, ""},
+		{"comments removed", `a<!-- secret -->b`, "ab"},
+		{"attribute escaping", `<a title="&quot;><script>" href="/x">x</a>`, `<a title="&#34;&gt;&lt;script&gt;" href="https://example.com/x" rel="nofollow noopener noreferrer">x</a>`},
+		{"unbalanced markup", `<p><b>open`, "<p><b>open</b></p>"},
+	}
+	for _, tt := range tests {
+		if got := sanitizeHTML(tt.src, base); got != tt.want {
+			t.Errorf("%s: sanitizeHTML(%q) = %q, want %q", tt.name, tt.src, got, tt.want)
+		}
+	}
+
+	if got, want := sanitizeHTML(`<a href="/x">x</a>`, ""), "<a>x</a>"; got != want {
+		t.Errorf("without a base, relative links can't be resolved and should be dropped: got %q, want %q", got, want)
+	}
+}
+
+func TestSafeURL(t *testing.T) {
+	base, err := url.Parse("https://example.com/feed.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		raw    string
+		isHref bool
+		want   string
+		ok     bool
+	}{
+		{"https://example.com/a", false, "https://example.com/a", true},
+		{"  http://example.com/a  ", false, "http://example.com/a", true},
+		{"/post/1", false, "https://example.com/post/1", true},
+		{"post/1", true, "https://example.com/post/1", true},
+		{"//cdn.example.com/a.png", false, "https://cdn.example.com/a.png", true},
+		{"#top", true, "#top", true},
+		{"#top", false, "https://example.com/feed.xml#top", true},
+		{"mailto:me@example.com", true, "mailto:me@example.com", true},
+		{"mailto:me@example.com", false, "", false},
+		{"", true, "", false},
+		{"javascript:alert(1)", true, "", false},
+		{"JAVASCRIPT:alert(1)", false, "", false},
+		{"data:text/html,<script>alert(1)</script>", true, "", false},
+		{"vbscript:msgbox", true, "", false},
+		{"file:///etc/passwd", false, "", false},
+		{"http://[::1", false, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := safeURL(tt.raw, base, tt.isHref)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("safeURL(%q, isHref=%v) = %q, %v, want %q, %v", tt.raw, tt.isHref, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	if got, ok := safeURL("/relative", nil, false); ok {
+		t.Errorf("safeURL without a base accepted a relative URL as %q", got)
+	}
+}
+
+func TestStoreFeedItemsSkipsUnsafeLinks(t *testing.T) {
+	ts := newSession(t, "memory:")
+	ts.registerUser("alice")
+	ts.run("addfeed Feed https://example.com/feed.xml", "")
+	ctx := context.Background()
+	feed, err := ts.s.db.GetFeedByURL(ctx, "https://example.com/feed.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storeFeedItems(ctx, ts.s, feed, []RSSItem{
+		{Title: "Relative", Link: "/posts/relative"},
+		{Title: "Script", Link: "javascript:alert(document.cookie)"},
+		{Title: "Data", Link: "data:text/html,<script>alert(1)</script>"},
+		{Title: "Mail", Link: "mailto:me@example.com"},
+		{Title: "No link"},
+		{Title: "Absolute", Link: "https://example.org/absolute"},
+	})
+
+	user, err := ts.s.db.GetUser(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	posts, err := ts.s.db.GetPostsByUserId(ctx, database.GetPostsByUserIdParams{UserID: user.ID, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, p := range posts {
+		got[p.Title.String] = p.Url
+	}
+	want := map[string]string{
+		"Relative": "https://example.com/posts/relative",
+		"Absolute": "https://example.org/absolute",
+	}
+	if len(got) != len(want) {
+		t.Errorf("stored posts %v, want %v", got, want)
+	}
+	for title, url := range want {
+		if got[title] != url {
+			t.Errorf("post %q stored with URL %q, want %q", title, got[title], url)
+		}
+	}
+}
@@ -0,0 +1,788 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richardteaman/gator/internal/database"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+	maxRequestBody   = 1 << 20
+)
+
+// apiServer exposes the same operations as the CLI handlers as a JSON REST API.
+type apiServer struct {
+	state *state
+}
+
+type authedHandler func(w http.ResponseWriter, r *http.Request, user database.User)
+
+type apiUser struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+}
+
+type apiFeed struct {
+	ID            uuid.UUID  `json:"id"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	Name          string     `json:"name"`
+	URL           string     `json:"url"`
+	UserID        uuid.UUID  `json:"user_id"`
+	LastFetchedAt *time.Time `json:"last_fetched_at"`
+}
+
+type apiFollow struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	FeedID    uuid.UUID `json:"feed_id"`
+	FeedName  string    `json:"feed_name"`
+}
+
+type apiPost struct {
+	ID          uuid.UUID  `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Title       string     `json:"title"`
+	URL         string     `json:"url"`
+	Description string     `json:"description"`
+	PublishedAt *time.Time `json:"published_at"`
+	FeedID      uuid.UUID  `json:"feed_id"`
+	Read        bool       `json:"read"`
+	// Muted is only ever set with ?all=true
+	Muted       bool `json:"muted"`
+	Highlighted bool `json:"highlighted"`
+}
+
+type apiSession struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	User      apiUser   `json:"user"`
+}
+
+type apiPage[T any] struct {
+	Items      []T    `json:"items"`
+	Limit      int32  `json:"limit"`
+	Offset     int32  `json:"offset"`
+	NextOffset *int32 `json:"next_offset,omitempty"`
+}
+
+var serveCommand = &commandSpec{
+	Name:    "serve",
+	Summary: "starts the JSON REST API and Fever API server",
+	Flags: []flagSpec{
+		stringFlag("addr", ":8080", "address to listen on"),
+		stringFlag("public-url", "", "externally reachable base URL, enables WebSub subscriptions"),
+	},
+	Handler: handlerServe,
+}
+
+func handlerServe(s *state, cmd command) error {
+	addr := cmd.String("addr")
+	publicURL := cmd.String("public-url")
+
+	api := &apiServer{state: s}
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           api.routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if publicURL != "" {
+		go newWebSubSubscriber(s, publicURL).run(ctx)
+	}
+	go runWebhookDeliveries(ctx, s)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+	fmt.Fprintf(s.out, "Serving API on %s\n", addr)
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("server failed: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
+
+func (a *apiServer) routes() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/users", a.handleListUsers)
+	mux.HandleFunc("POST /api/users", a.handleCreateUser)
+	mux.HandleFunc("GET /api/users/{name}", a.handleGetUser)
+	mux.HandleFunc("DELETE /api/users/{name}", a.requireAdmin(a.handleDeleteUser))
+	mux.HandleFunc("GET /api/users/{name}/feed.atom", a.requireUser(scopeRead, a.handlePublishedFeed("atom")))
+	mux.HandleFunc("GET /api/users/{name}/feed.rss", a.requireUser(scopeRead, a.handlePublishedFeed("rss")))
+
+	mux.HandleFunc("POST /api/sessions", a.handleCreateSession)
+	mux.HandleFunc("DELETE /api/sessions", a.handleDeleteSession)
+
+	mux.HandleFunc("GET /api/feeds", a.handleListFeeds)
+	mux.HandleFunc("POST /api/feeds", a.requireUser(scopeFollows, a.handleCreateFeed))
+	mux.HandleFunc("DELETE /api/feeds/{feedID}", a.requireUser(scopeFollows, a.handleDeleteFeed))
+
+	mux.HandleFunc("GET /api/follows", a.requireUser(scopeRead, a.handleListFollows))
+	mux.HandleFunc("POST /api/follows", a.requireUser(scopeFollows, a.handleCreateFollow))
+	mux.HandleFunc("DELETE /api/follows/{feedID}", a.requireUser(scopeFollows, a.handleDeleteFollow))
+
+	mux.HandleFunc("GET /api/posts", a.requireUser(scopeRead, a.handleListPosts))
+	mux.HandleFunc("PUT /api/posts/{postID}/read", a.requireUser(scopeFollows, a.handleMarkRead))
+	mux.HandleFunc("DELETE /api/posts/{postID}/read", a.requireUser(scopeFollows, a.handleMarkUnread))
+
+	mux.HandleFunc("GET /websub/{subID}", a.handleWebSubVerify)
+	mux.HandleFunc("POST /websub/{subID}", a.handleWebSubNotify)
+
+	mux.HandleFunc("/fever", a.handleFever)
+	mux.HandleFunc("/fever/", a.handleFever)
+
+	return mux
+}
+
+// requireUser is the API counterpart of middlewareLoggedIn. Clients send
+// a session token from POST /api/sessions or a personal API token as a
+// bearer token, or their name and password with HTTP basic auth. API
+// tokens must also carry scope.
+func (a *apiServer) requireUser(scope string, handler authedHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			user   database.User
+			scopes = allScopes
+			err    error
+		)
+		if token, ok := bearerToken(r); ok {
+			if strings.HasPrefix(token, apiTokenPrefix) {
+				user, scopes, err = userForAPIToken(r.Context(), a.state, token)
+			} else {
+				user, err = userForSession(r.Context(), a.state, token)
+			}
+		} else if name, password, ok := r.BasicAuth(); ok {
+			user, err = authenticate(r.Context(), a.state, name, password)
+		} else {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="gator", Basic realm="gator"`)
+			respondError(w, http.StatusUnauthorized, "authentication required")
+			return
+		}
+
+		if err != nil {
+			switch {
+			case errors.Is(err, errSessionExpired):
+				respondError(w, http.StatusUnauthorized, "session expired or revoked")
+				return
+			case errors.Is(err, errInvalidToken), errors.Is(err, errInvalidCredentials), errors.Is(err, errNoPassword):
+				respondError(w, http.StatusUnauthorized, err.Error())
+				return
+			}
+			respondInternalError(w, "could not authenticate", err)
+			return
+		}
+		if !scopes.has(scope) {
+			respondError(w, http.StatusForbidden, fmt.Sprintf("token lacks the %s scope", scope))
+			return
+		}
+		handler(w, r, user)
+	}
+}
+
+// requireAdmin limits handler to admins, authenticated with a session,
+// password or API token with the admin scope.
+func (a *apiServer) requireAdmin(handler authedHandler) http.HandlerFunc {
+	return a.requireUser(scopeAdmin, func(w http.ResponseWriter, r *http.Request, user database.User) {
+		if user.Role != roleAdmin {
+			respondError(w, http.StatusForbidden, "admin role required")
+			return
+		}
+		handler(w, r, user)
+	})
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+func (a *apiServer) handleCreateSession(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name     string `json:"name"`
+		Password string `json:"password"`
+	}
+	if err := decodeBody(w, r, &body); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	user, err := authenticate(r.Context(), a.state, body.Name, body.Password)
+	if errors.Is(err, errInvalidCredentials) || errors.Is(err, errNoPassword) {
+		respondError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if err != nil {
+		respondInternalError(w, "could not authenticate", err)
+		return
+	}
+
+	token, expires, err := startSession(r.Context(), a.state, user)
+	if err != nil {
+		respondInternalError(w, "could not create session", err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, apiSession{Token: token, ExpiresAt: expires, User: toAPIUser(user)})
+}
+
+func (a *apiServer) handleDeleteSession(w http.ResponseWriter, r *http.Request) {
+	token, ok := bearerToken(r)
+	if !ok {
+		respondError(w, http.StatusUnauthorized, "bearer token required")
+		return
+	}
+	if err := a.state.db.DeleteSession(r.Context(), hashToken(token)); err != nil {
+		respondInternalError(w, "could not end session", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *apiServer) handleListUsers(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := parsePage(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	users, err := a.state.db.ListUsers(r.Context(), database.ListUsersParams{Limit: limit, Offset: offset})
+	if err != nil {
+		respondInternalError(w, "could not list users", err)
+		return
+	}
+
+	items := make([]apiUser, 0, len(users))
+	for _, user := range users {
+		items = append(items, toAPIUser(user))
+	}
+	respondJSON(w, http.StatusOK, newPage(items, limit, offset))
+}
+
+func (a *apiServer) handleCreateUser(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name     string `json:"name"`
+		Password string `json:"password"`
+	}
+	if err := decodeBody(w, r, &body); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" {
+		respondError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	hash, err := hashPassword(body.Password)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	now := time.Now()
+	user, err := a.state.db.CreateUser(r.Context(), database.CreateUserParams{
+		ID:           uuid.New(),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		Name:         body.Name,
+		PasswordHash: sql.NullString{String: hash, Valid: true},
+	})
+	if isUniqueViolation(err) {
+		respondError(w, http.StatusConflict, "user already exists")
+		return
+	}
+	if err != nil {
+		respondInternalError(w, "could not create user", err)
+		return
+	}
+	if err := promoteIfFirstUser(r.Context(), a.state, &user); err != nil {
+		respondInternalError(w, "could not set role", err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, toAPIUser(user))
+}
+
+func (a *apiServer) handleGetUser(w http.ResponseWriter, r *http.Request) {
+	user, err := a.state.db.GetUser(r.Context(), r.PathValue("name"))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	if err != nil {
+		respondInternalError(w, "could not fetch user", err)
+		return
+	}
+	respondJSON(w, http.StatusOK, toAPIUser(user))
+}
+
+func (a *apiServer) handleDeleteUser(w http.ResponseWriter, r *http.Request, admin database.User) {
+	target, err := a.state.db.GetUser(r.Context(), r.PathValue("name"))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	if err != nil {
+		respondInternalError(w, "could not fetch user", err)
+		return
+	}
+	if err := checkNotLastAdmin(r.Context(), a.state, target); err != nil {
+		respondError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	if _, err := a.state.db.DeleteUser(r.Context(), target.Name); err != nil {
+		respondInternalError(w, "could not delete user", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *apiServer) handleListFeeds(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := parsePage(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	feeds, err := a.state.db.ListFeeds(r.Context(), database.ListFeedsParams{Limit: limit, Offset: offset})
+	if err != nil {
+		respondInternalError(w, "could not list feeds", err)
+		return
+	}
+
+	items := make([]apiFeed, 0, len(feeds))
+	for _, feed := range feeds {
+		items = append(items, toAPIFeed(feed))
+	}
+	respondJSON(w, http.StatusOK, newPage(items, limit, offset))
+}
+
+// handleCreateFeed behaves like addfeed: the feed is created if its URL is
+// new, and the user follows it either way.
+func (a *apiServer) handleCreateFeed(w http.ResponseWriter, r *http.Request, user database.User) {
+	var body struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	}
+	if err := decodeBody(w, r, &body); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if body.Name == "" || body.URL == "" {
+		respondError(w, http.StatusBadRequest, "name and url are required")
+		return
+	}
+	feedURL, err := normalizeFeedURL(body.URL)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+	now := time.Now()
+	status := http.StatusOK
+
+	feed, err := feedByURL(ctx, a.state.db, feedURL)
+	if errors.Is(err, sql.ErrNoRows) {
+		feed, err = a.state.db.CreateFeed(ctx, database.CreateFeedParams{
+			ID:           uuid.New(),
+			CreatedAt:    now,
+			UpdatedAt:    now,
+			Name:         body.Name,
+			Url:          feedURL,
+			UserID:       user.ID,
+			CanonicalUrl: sql.NullString{String: feedURLKey(feedURL), Valid: true},
+		})
+		status = http.StatusCreated
+	}
+	if err != nil {
+		respondInternalError(w, "could not create feed", err)
+		return
+	}
+
+	_, err = a.state.db.CreateFeedFollow(ctx, database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		UserID:    user.ID,
+		FeedID:    feed.ID,
+	})
+	if err != nil && !isUniqueViolation(err) {
+		respondInternalError(w, "could not follow feed", err)
+		return
+	}
+
+	respondJSON(w, status, toAPIFeed(feed))
+}
+
+func (a *apiServer) handleDeleteFeed(w http.ResponseWriter, r *http.Request, user database.User) {
+	feedID, err := uuid.Parse(r.PathValue("feedID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid feed id")
+		return
+	}
+
+	ctx := r.Context()
+	feed, err := a.state.db.GetFeedByID(ctx, feedID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusNotFound, "feed not found")
+		return
+	}
+	if err != nil {
+		respondInternalError(w, "could not look up feed", err)
+		return
+	}
+	if !mayChangeFeed(user, feed) {
+		respondError(w, http.StatusForbidden, errFeedOwnerRequired.Error())
+		return
+	}
+
+	n, err := a.state.db.DeleteFeed(ctx, feedID)
+	if err != nil {
+		respondInternalError(w, "could not delete feed", err)
+		return
+	}
+	if n == 0 {
+		respondError(w, http.StatusNotFound, "feed not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *apiServer) handleListFollows(w http.ResponseWriter, r *http.Request, user database.User) {
+	follows, err := a.state.db.GetFeedFollowsForUser(r.Context(), user.ID)
+	if err != nil {
+		respondInternalError(w, "could not list follows", err)
+		return
+	}
+
+	items := make([]apiFollow, 0, len(follows))
+	for _, follow := range follows {
+		items = append(items, apiFollow{
+			ID:        follow.ID,
+			CreatedAt: follow.CreatedAt,
+			FeedID:    follow.FeedID,
+			FeedName:  follow.FeedName,
+		})
+	}
+	respondJSON(w, http.StatusOK, items)
+}
+
+func (a *apiServer) handleCreateFollow(w http.ResponseWriter, r *http.Request, user database.User) {
+	var body struct {
+		URL string `json:"url"`
+	}
+	if err := decodeBody(w, r, &body); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	feed, err := feedByURL(r.Context(), a.state.db, body.URL)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusNotFound, "feed not found")
+		return
+	}
+	if err != nil {
+		respondInternalError(w, "could not fetch feed", err)
+		return
+	}
+
+	now := time.Now()
+	follow, err := a.state.db.CreateFeedFollow(r.Context(), database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		UserID:    user.ID,
+		FeedID:    feed.ID,
+	})
+	if isUniqueViolation(err) {
+		respondError(w, http.StatusConflict, "feed is already followed")
+		return
+	}
+	if err != nil {
+		respondInternalError(w, "could not follow feed", err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, apiFollow{
+		ID:        follow.ID,
+		CreatedAt: follow.CreatedAt,
+		FeedID:    follow.FeedID,
+		FeedName:  follow.FeedName,
+	})
+}
+
+func (a *apiServer) handleDeleteFollow(w http.ResponseWriter, r *http.Request, user database.User) {
+	feedID, err := uuid.Parse(r.PathValue("feedID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid feed id")
+		return
+	}
+
+	deleted, err := a.state.db.DeleteFeedFollowByFeedID(r.Context(), database.DeleteFeedFollowByFeedIDParams{
+		UserID: user.ID,
+		FeedID: feedID,
+	})
+	if err != nil {
+		respondInternalError(w, "could not unfollow feed", err)
+		return
+	}
+	if deleted == 0 {
+		respondError(w, http.StatusNotFound, "feed is not followed")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *apiServer) handleListPosts(w http.ResponseWriter, r *http.Request, user database.User) {
+	limit, offset, err := parsePage(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter, err := parsePostFilter(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// rules are applied after the query, so the offset counts posts that
+	// pass them
+	posts, err := loadFilteredPosts(r.Context(), a.state, user.ID, offset+limit, filter)
+	if err != nil {
+		respondInternalError(w, "could not list posts", err)
+		return
+	}
+	posts = posts[min(offset, int32(len(posts))):]
+
+	items := make([]apiPost, 0, len(posts))
+	for _, post := range posts {
+		items = append(items, apiPost{
+			ID:          post.ID,
+			CreatedAt:   post.CreatedAt,
+			Title:       post.Title.String,
+			URL:         post.Url,
+			Description: post.Description.String,
+			PublishedAt: nullTimePtr(post.PublishedAt),
+			FeedID:      post.FeedID,
+			Read:        post.Read,
+			Muted:       post.verdict.Mute != nil,
+			Highlighted: post.verdict.Highlight != nil,
+		})
+	}
+	respondJSON(w, http.StatusOK, newPage(items, limit, offset))
+}
+
+func (a *apiServer) handleMarkRead(w http.ResponseWriter, r *http.Request, user database.User) {
+	post, ok := a.lookupPost(w, r, user)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	err := a.state.db.MarkPostRead(r.Context(), database.MarkPostReadParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		UserID:    user.ID,
+		PostID:    post.ID,
+	})
+	if err != nil {
+		respondInternalError(w, "could not mark post read", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *apiServer) handleMarkUnread(w http.ResponseWriter, r *http.Request, user database.User) {
+	post, ok := a.lookupPost(w, r, user)
+	if !ok {
+		return
+	}
+
+	err := a.state.db.MarkPostUnread(r.Context(), database.MarkPostUnreadParams{
+		UserID: user.ID,
+		PostID: post.ID,
+	})
+	if err != nil {
+		respondInternalError(w, "could not mark post unread", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// lookupPost finds the post named in the path among those user can see,
+// the posts of feeds they follow. Other posts are reported missing too, so
+// ids can't be probed.
+func (a *apiServer) lookupPost(w http.ResponseWriter, r *http.Request, user database.User) (database.Post, bool) {
+	postID, err := uuid.Parse(r.PathValue("postID"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "invalid post id")
+		return database.Post{}, false
+	}
+
+	post, err := a.state.db.GetPostByID(r.Context(), postID)
+	if err == nil {
+		_, err = a.state.db.GetFeedFollowForUserAndFeed(r.Context(), database.GetFeedFollowForUserAndFeedParams{
+			UserID: user.ID,
+			FeedID: post.FeedID,
+		})
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusNotFound, "post not found")
+		return database.Post{}, false
+	}
+	if err != nil {
+		respondInternalError(w, "could not fetch post", err)
+		return database.Post{}, false
+	}
+	return post, true
+}
+
+func parsePage(r *http.Request) (limit, offset int32, err error) {
+	limit = defaultPageLimit
+	query := r.URL.Query()
+
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			return 0, 0, errors.New("limit must be a positive integer")
+		}
+		limit = int32(min(n, maxPageLimit))
+	}
+	if raw := query.Get("offset"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			return 0, 0, errors.New("offset must be a non-negative integer")
+		}
+		offset = int32(n)
+	}
+	return limit, offset, nil
+}
+
+// queryBool reads an optional true/false query parameter.
+func queryBool(r *http.Request, name string) (bool, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return false, nil
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s value", name)
+	}
+	return v, nil
+}
+
+// parsePostFilter reads the unread, all and highlighted query parameters
+// that endpoints listing posts share.
+func parsePostFilter(r *http.Request) (postFilter, error) {
+	var filter postFilter
+	var err error
+	for name, value := range map[string]*bool{"unread": &filter.UnreadOnly, "all": &filter.All, "highlighted": &filter.Highlighted} {
+		if *value, err = queryBool(r, name); err != nil {
+			return postFilter{}, err
+		}
+	}
+	return filter, nil
+}
+
+func newPage[T any](items []T, limit, offset int32) apiPage[T] {
+	page := apiPage[T]{Items: items, Limit: limit, Offset: offset}
+	if int32(len(items)) == limit {
+		next := offset + limit
+		page.NextOffset = &next
+	}
+	return page
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBody)
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %v", err)
+	}
+	return nil
+}
+
+func respondJSON(w http.ResponseWriter, status int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		log.Println("could not write response:", err)
+	}
+}
+
+func respondError(w http.ResponseWriter, status int, msg string) {
+	respondJSON(w, status, map[string]string{"error": msg})
+}
+
+func respondInternalError(w http.ResponseWriter, msg string, err error) {
+	log.Printf("%s: %v", msg, err)
+	respondError(w, http.StatusInternalServerError, msg)
+}
+
+func toAPIUser(user database.User) apiUser {
+	return apiUser{
+		ID:        user.ID,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+		Name:      user.Name,
+		Role:      user.Role,
+	}
+}
+
+func toAPIFeed(feed database.Feed) apiFeed {
+	return apiFeed{
+		ID:            feed.ID,
+		CreatedAt:     feed.CreatedAt,
+		UpdatedAt:     feed.UpdatedAt,
+		Name:          feed.Name,
+		URL:           feed.Url,
+		UserID:        feed.UserID,
+		LastFetchedAt: nullTimePtr(feed.LastFetchedAt),
+	}
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
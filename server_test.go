@@ -0,0 +1,279 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// apiClient talks to the API of a session's install, served by httptest.
+type apiClient struct {
+	t   *testing.T
+	srv *httptest.Server
+}
+
+func newAPIClient(ts *session) *apiClient {
+	srv := httptest.NewServer((&apiServer{state: ts.s}).routes())
+	ts.t.Cleanup(srv.Close)
+	return &apiClient{t: ts.t, srv: srv}
+}
+
+// do sends body, JSON unless it is a url.Values-style string, to path
+// with the Authorization header auth and returns the response with its
+// body read.
+func (c *apiClient) do(method, path, auth, body string) (*http.Response, string) {
+	c.t.Helper()
+	req, err := http.NewRequest(method, c.srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	if body != "" && !strings.HasPrefix(body, "{") {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	resp, err := c.srv.Client().Do(req)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return resp, string(b)
+}
+
+// expect sends a request like do and fails the test unless it answers
+// with status, decoding a JSON body into out if given.
+func (c *apiClient) expect(status int, method, path, auth, body string, out any) string {
+	c.t.Helper()
+	resp, got := c.do(method, path, auth, body)
+	if resp.StatusCode != status {
+		c.t.Fatalf("%s %s: status %d, want %d; body %s", method, path, resp.StatusCode, status, got)
+	}
+	if out != nil {
+		if err := json.Unmarshal([]byte(got), out); err != nil {
+			c.t.Fatalf("%s %s: %v in %s", method, path, err, got)
+		}
+	}
+	return got
+}
+
+func (c *apiClient) login(name string) string {
+	c.t.Helper()
+	var session apiSession
+	c.expect(http.StatusCreated, "POST", "/api/sessions", "", `{"name":"`+name+`","password":"`+testPassword+`"}`, &session)
+	return "Bearer " + session.Token
+}
+
+func basicAuth(name, password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(name+":"+password))
+}
+
+var apiTokenPattern = regexp.MustCompile(apiTokenPrefix + `[0-9a-f]+`)
+
+// createToken runs token create for the logged in user and returns the
+// Authorization header for the token it prints.
+func (ts *session) createToken(args string) string {
+	ts.t.Helper()
+	if err := ts.run("token create "+args, ""); err != nil {
+		ts.t.Fatal(err)
+	}
+	tokens := apiTokenPattern.FindAllString(ts.transcript.String(), -1)
+	return "Bearer " + tokens[len(tokens)-1]
+}
+
+func TestAPIAuth(t *testing.T) {
+	ts := newSession(t, "memory:")
+	ts.registerUser("alice")
+	ts.registerUser("bob")
+	readOnly := ts.createToken("--scope read ro")
+	api := newAPIClient(ts)
+
+	resp, _ := api.do("GET", "/api/follows", "", "")
+	if resp.StatusCode != http.StatusUnauthorized || resp.Header.Get("WWW-Authenticate") == "" {
+		t.Errorf("without credentials: status %d, WWW-Authenticate %q", resp.StatusCode, resp.Header.Get("WWW-Authenticate"))
+	}
+	api.expect(http.StatusUnauthorized, "POST", "/api/sessions", "", `{"name":"alice","password":"wrong"}`, nil)
+	api.expect(http.StatusUnauthorized, "GET", "/api/follows", "Bearer not-a-session", "", nil)
+	api.expect(http.StatusUnauthorized, "GET", "/api/follows", "Bearer "+apiTokenPrefix+"0000", "", nil)
+	api.expect(http.StatusBadRequest, "POST", "/api/sessions", "", `{"name":"alice","admin":true}`, nil)
+
+	session := api.login("alice")
+	api.expect(http.StatusOK, "GET", "/api/follows", session, "", nil)
+	api.expect(http.StatusNoContent, "DELETE", "/api/sessions", session, "", nil)
+	api.expect(http.StatusUnauthorized, "GET", "/api/follows", session, "", nil)
+
+	api.expect(http.StatusUnauthorized, "GET", "/api/follows", basicAuth("alice", "wrong"), "", nil)
+	api.expect(http.StatusOK, "GET", "/api/follows", basicAuth("alice", testPassword), "", nil)
+
+	// bob's token only reads
+	api.expect(http.StatusOK, "GET", "/api/posts", readOnly, "", nil)
+	api.expect(http.StatusForbidden, "POST", "/api/follows", readOnly, `{"url":"https://example.com/rss"}`, nil)
+
+	// alice registered first and is the admin
+	bob := api.login("bob")
+	api.expect(http.StatusForbidden, "DELETE", "/api/users/alice", bob, "", nil)
+	api.expect(http.StatusConflict, "DELETE", "/api/users/alice", api.login("alice"), "", nil)
+	api.expect(http.StatusNoContent, "DELETE", "/api/users/bob", api.login("alice"), "", nil)
+	api.expect(http.StatusUnauthorized, "GET", "/api/follows", bob, "", nil)
+}
+
+func TestAPIUsers(t *testing.T) {
+	ts := newSession(t, "memory:")
+	api := newAPIClient(ts)
+
+	var alice apiUser
+	api.expect(http.StatusCreated, "POST", "/api/users", "", `{"name":"alice","password":"`+testPassword+`"}`, &alice)
+	if alice.Name != "alice" || alice.Role != roleAdmin {
+		t.Errorf("first user: %+v, want alice as admin", alice)
+	}
+	api.expect(http.StatusConflict, "POST", "/api/users", "", `{"name":"alice","password":"`+testPassword+`"}`, nil)
+	api.expect(http.StatusBadRequest, "POST", "/api/users", "", `{"name":"bob","password":"short"}`, nil)
+	api.expect(http.StatusBadRequest, "POST", "/api/users", "", `{"name":" ","password":"`+testPassword+`"}`, nil)
+	api.expect(http.StatusCreated, "POST", "/api/users", "", `{"name":"bob","password":"`+testPassword+`"}`, nil)
+
+	var page apiPage[apiUser]
+	api.expect(http.StatusOK, "GET", "/api/users?limit=1", "", "", &page)
+	if len(page.Items) != 1 || page.NextOffset == nil || *page.NextOffset != 1 {
+		t.Errorf("first page of users: %+v", page)
+	}
+	api.expect(http.StatusOK, "GET", "/api/users?limit=1&offset=1", "", "", &page)
+	if len(page.Items) != 1 || page.Items[0].Name != "bob" || page.Items[0].Role != roleUser {
+		t.Errorf("second page of users: %+v", page)
+	}
+	api.expect(http.StatusBadRequest, "GET", "/api/users?limit=0", "", "", nil)
+	api.expect(http.StatusBadRequest, "GET", "/api/users?offset=-1", "", "", nil)
+
+	var bob apiUser
+	api.expect(http.StatusOK, "GET", "/api/users/bob", "", "", &bob)
+	if bob.Name != "bob" {
+		t.Errorf("GET /api/users/bob: %+v", bob)
+	}
+	api.expect(http.StatusNotFound, "GET", "/api/users/nobody", "", "", nil)
+}
+
+func TestAPIPostsRules(t *testing.T) {
+	ts, _ := withPosts(t)
+	ts.run("rule add mute category sponsored", "")
+	ts.run("rule add highlight author ada", "")
+	api := newAPIClient(ts)
+	alice := api.login("alice")
+
+	// muted posts are left out like they are from browse
+	var posts apiPage[apiPost]
+	api.expect(http.StatusOK, "GET", "/api/posts", alice, "", &posts)
+	if len(posts.Items) != 1 || posts.Items[0].Title != "Hello gophers" || !posts.Items[0].Highlighted || posts.Items[0].Muted {
+		t.Errorf("posts: %+v", posts.Items)
+	}
+	api.expect(http.StatusOK, "GET", "/api/posts?highlighted=true", alice, "", &posts)
+	if len(posts.Items) != 1 || !posts.Items[0].Highlighted {
+		t.Errorf("highlighted posts: %+v", posts.Items)
+	}
+
+	api.expect(http.StatusOK, "GET", "/api/posts?all=true", alice, "", &posts)
+	if len(posts.Items) != 2 {
+		t.Fatalf("all posts: %+v", posts.Items)
+	}
+	muted := posts.Items[0]
+	if !muted.Muted {
+		muted = posts.Items[1]
+	}
+	if !muted.Muted || muted.Highlighted {
+		t.Errorf("all posts: %+v, want one muted", posts.Items)
+	}
+
+	// pages count the posts that pass the rules
+	api.expect(http.StatusOK, "GET", "/api/posts?all=true&limit=1&offset=1", alice, "", &posts)
+	if len(posts.Items) != 1 || posts.NextOffset == nil || *posts.NextOffset != 2 {
+		t.Errorf("second page of all posts: %+v", posts)
+	}
+	var last apiPage[apiPost]
+	api.expect(http.StatusOK, "GET", "/api/posts?limit=1&offset=1", alice, "", &last)
+	if len(last.Items) != 0 || last.NextOffset != nil {
+		t.Errorf("second page of posts: %+v", last)
+	}
+	api.expect(http.StatusBadRequest, "GET", "/api/posts?all=maybe", alice, "", nil)
+}
+
+func TestAPIFeedsAndPosts(t *testing.T) {
+	ts, url := withPosts(t)
+	ts.registerUser("bob")
+	api := newAPIClient(ts)
+	alice, bob := api.login("alice"), api.login("bob")
+
+	var feed apiFeed
+	api.expect(http.StatusCreated, "POST", "/api/feeds", bob, `{"name":"Bob","url":"HTTPS://Example.com/bob/"}`, &feed)
+	if feed.URL != "https://example.com/bob/" {
+		t.Errorf("created feed URL %q, want it normalized", feed.URL)
+	}
+	api.expect(http.StatusOK, "POST", "/api/feeds", bob, `{"name":"Bob","url":"https://example.com/bob"}`, nil)
+	api.expect(http.StatusBadRequest, "POST", "/api/feeds", bob, `{"name":"Bob","url":"ftp://example.com/bob"}`, nil)
+	api.expect(http.StatusBadRequest, "POST", "/api/feeds", bob, `{"name":"Bob"}`, nil)
+
+	var feeds apiPage[apiFeed]
+	api.expect(http.StatusOK, "GET", "/api/feeds", "", "", &feeds)
+	if len(feeds.Items) != 2 || feeds.NextOffset != nil {
+		t.Errorf("feeds: %+v", feeds)
+	}
+
+	// bob doesn't follow the test feed, so they can't see or mark its posts
+	var posts apiPage[apiPost]
+	api.expect(http.StatusOK, "GET", "/api/posts", bob, "", &posts)
+	if len(posts.Items) != 0 {
+		t.Errorf("bob sees posts of feeds they don't follow: %+v", posts.Items)
+	}
+	api.expect(http.StatusOK, "GET", "/api/posts", alice, "", &posts)
+	if len(posts.Items) != 2 {
+		t.Fatalf("alice's posts: %+v", posts.Items)
+	}
+	post := posts.Items[0]
+	api.expect(http.StatusNotFound, "PUT", "/api/posts/"+post.ID.String()+"/read", bob, "", nil)
+	api.expect(http.StatusNotFound, "DELETE", "/api/posts/"+post.ID.String()+"/read", bob, "", nil)
+	api.expect(http.StatusNotFound, "PUT", "/api/posts/"+feed.ID.String()+"/read", alice, "", nil)
+	api.expect(http.StatusBadRequest, "PUT", "/api/posts/nope/read", alice, "", nil)
+
+	api.expect(http.StatusNoContent, "PUT", "/api/posts/"+post.ID.String()+"/read", alice, "", nil)
+	api.expect(http.StatusNoContent, "PUT", "/api/posts/"+post.ID.String()+"/read", alice, "", nil)
+	api.expect(http.StatusOK, "GET", "/api/posts?unread=true", alice, "", &posts)
+	if len(posts.Items) != 1 || posts.Items[0].ID == post.ID {
+		t.Errorf("unread posts after marking %s read: %+v", post.ID, posts.Items)
+	}
+	api.expect(http.StatusBadRequest, "GET", "/api/posts?unread=maybe", alice, "", nil)
+	api.expect(http.StatusNoContent, "DELETE", "/api/posts/"+post.ID.String()+"/read", alice, "", nil)
+	api.expect(http.StatusOK, "GET", "/api/posts?unread=1", alice, "", &posts)
+	if len(posts.Items) != 2 {
+		t.Errorf("unread posts after marking %s unread: %+v", post.ID, posts.Items)
+	}
+
+	// following the feed makes its posts visible to bob
+	var follow apiFollow
+	api.expect(http.StatusCreated, "POST", "/api/follows", bob, `{"url":"`+url+`"}`, &follow)
+	api.expect(http.StatusConflict, "POST", "/api/follows", bob, `{"url":"`+url+`"}`, nil)
+	api.expect(http.StatusNotFound, "POST", "/api/follows", bob, `{"url":"https://example.net/missing"}`, nil)
+	var follows []apiFollow
+	api.expect(http.StatusOK, "GET", "/api/follows", bob, "", &follows)
+	if len(follows) != 2 {
+		t.Errorf("bob's follows: %+v", follows)
+	}
+	api.expect(http.StatusNoContent, "PUT", "/api/posts/"+post.ID.String()+"/read", bob, "", nil)
+	api.expect(http.StatusNoContent, "DELETE", "/api/follows/"+follow.FeedID.String(), bob, "", nil)
+	api.expect(http.StatusNotFound, "DELETE", "/api/follows/"+follow.FeedID.String(), bob, "", nil)
+	api.expect(http.StatusNotFound, "PUT", "/api/posts/"+post.ID.String()+"/read", bob, "", nil)
+
+	// feeds are deleted by the user who added them or an admin
+	readOnly := ts.createToken("--scope read ro")
+	api.expect(http.StatusForbidden, "DELETE", "/api/feeds/"+follow.FeedID.String(), bob, "", nil)
+	api.expect(http.StatusForbidden, "DELETE", "/api/feeds/"+feed.ID.String(), readOnly, "", nil)
+	api.expect(http.StatusNoContent, "DELETE", "/api/feeds/"+feed.ID.String(), bob, "", nil)
+	api.expect(http.StatusNotFound, "DELETE", "/api/feeds/"+feed.ID.String(), bob, "", nil)
+	api.expect(http.StatusNoContent, "DELETE", "/api/feeds/"+follow.FeedID.String(), alice, "", nil)
+	api.expect(http.StatusBadRequest, "DELETE", "/api/feeds/nope", alice, "", nil)
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// shellHistoryLimit is how many lines the shell keeps, and saves, for
+// the up and down keys.
+const shellHistoryLimit = 1000
+
+var shellCommand = &commandSpec{
+	Name:    "shell",
+	Summary: "runs commands one after another on a single connection",
+	Details: `Each line is a command as it would follow "gator", with quotes and
+backslashes working like they do in a shell. Blank lines and lines
+starting with # are skipped.
+
+On a terminal it prompts for commands, with line editing, tab completion
+and a history kept next to the config file. "exit" or Ctrl-D leaves.
+
+Given a file, or "-" for standard input, it runs the commands in it and
+stops at the first that fails, unless --keep-going is set. Standard input
+that isn't a terminal is read the same way.`,
+	Args: []argSpec{{Name: "file", Optional: true}},
+	Flags: []flagSpec{
+		boolFlag("keep-going", "run the rest of a script after a command fails"),
+	},
+	Handler: handlerShell,
+}
+
+func handlerShell(s *state, cmd command) error {
+	keepGoing := cmd.Bool("keep-going")
+	if len(cmd.Args) == 0 || cmd.Args[0] == "-" {
+		if len(cmd.Args) == 0 && s.tty != nil {
+			return runInteractiveShell(s)
+		}
+		// password prompts read from the input too, so share its reader
+		return runScript(s, "stdin", s.in, keepGoing)
+	}
+
+	f, err := os.Open(cmd.Args[0])
+	if errors.Is(err, fs.ErrNotExist) {
+		return kindErrorf(kindNotFound, "could not open script: %w", err)
+	}
+	if err != nil {
+		return fmt.Errorf("could not open script: %w", err)
+	}
+	defer f.Close()
+	return runScript(s, cmd.Args[0], bufio.NewReader(f), keepGoing)
+}
+
+// runScript runs the commands read from r, one per line. name is used to
+// point at the line that failed.
+func runScript(s *state, name string, r *bufio.Reader, keepGoing bool) error {
+	failed := 0
+	for lineNo := 1; ; lineNo++ {
+		line, err := r.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("could not read %s: %w", name, err)
+		}
+		if line != "" {
+			done, cmdErr := runShellLine(s, line)
+			if cmdErr != nil {
+				cmdErr = fmt.Errorf("%s:%d: %w", name, lineNo, cmdErr)
+				if !keepGoing {
+					return cmdErr
+				}
+				fmt.Fprintln(s.errOut, cmdErr)
+				failed++
+			}
+			if done {
+				break
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d command(s) in %s failed", failed, name)
+	}
+	return nil
+}
+
+// runInteractiveShell reads commands from the terminal until exit or
+// Ctrl-D. A failing command is reported and the shell carries on.
+func runInteractiveShell(s *state) error {
+	fd := int(s.tty.Fd())
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{s.tty, s.out}, "gator> ")
+
+	history := loadShellHistory(shellHistoryPath(s))
+	t.History = history
+	t.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+		return completeShellLine(s, line, pos)
+	}
+	if width, height, err := term.GetSize(fd); err == nil && width > 0 {
+		t.SetSize(width, height)
+	}
+
+	for {
+		// the terminal is only raw while reading, so commands can prompt
+		// and print as they do outside the shell
+		old, err := term.MakeRaw(fd)
+		if err != nil {
+			return fmt.Errorf("could not set up the terminal: %w", err)
+		}
+		line, err := t.ReadLine()
+		term.Restore(fd, old)
+		if errors.Is(err, io.EOF) {
+			fmt.Fprintln(s.out)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("could not read command: %w", err)
+		}
+
+		done, err := runShellLine(s, line)
+		if err != nil {
+			fmt.Fprintln(s.errOut, "error:", err)
+		}
+		if done {
+			return nil
+		}
+	}
+}
+
+// runShellLine runs the command on line. done is set once the line asks
+// to leave the shell.
+func runShellLine(s *state, line string) (done bool, err error) {
+	words, err := splitWords(line)
+	if err != nil {
+		return false, err
+	}
+	if len(words) == 0 || strings.HasPrefix(words[0], "#") {
+		return false, nil
+	}
+	switch words[0] {
+	case "exit", "quit":
+		return true, nil
+	case "shell":
+		return false, kindErrorf(kindInvalidInput, "already in a shell")
+	}
+	return false, s.commands.run(s, words)
+}
+
+// splitWords splits a line into words at unquoted spaces. Single quotes
+// keep everything up to the next one as it is; inside double quotes, and
+// outside of quotes, a backslash keeps the character after it.
+func splitWords(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+			inWord = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, kindErrorf(kindInvalidInput, "unterminated %c quote", quote)
+	}
+	if escaped {
+		return nil, kindErrorf(kindInvalidInput, "line ends with a backslash")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// completeShellLine completes the word before the cursor with the same
+// candidates the shell completion scripts get: all of the common prefix
+// of the candidates, and a space after it when there is only one.
+func completeShellLine(s *state, line string, pos int) (string, int, bool) {
+	before := line[:pos]
+	words, err := splitWords(before)
+	if err != nil {
+		return "", 0, false
+	}
+	if len(words) == 0 || strings.HasSuffix(before, " ") {
+		words = append(words, "")
+	}
+	current := words[len(words)-1]
+	// a quoted word's text isn't at the end of the line as typed
+	if !strings.HasSuffix(before, current) {
+		return "", 0, false
+	}
+
+	candidates := complete(s, words)
+	if len(candidates) == 0 {
+		return "", 0, false
+	}
+	prefix := candidates[0].Value
+	for _, c := range candidates[1:] {
+		for !strings.HasPrefix(c.Value, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	if len(candidates) == 1 {
+		prefix += " "
+	}
+	if len(prefix) <= len(current) {
+		return "", 0, false
+	}
+	head := before[:len(before)-len(current)] + prefix
+	return head + line[pos:], len(head), true
+}
+
+func shellHistoryPath(s *state) string {
+	if s.Config.Path == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(s.Config.Path), "shell_history")
+}
+
+// shellHistory is the terminal's history, kept in a file so it lasts
+// between sessions. The file is appended to as lines are entered.
+type shellHistory struct {
+	path  string
+	lines []string // oldest first
+}
+
+// loadShellHistory reads the history at path. A missing or unreadable
+// file starts an empty one; with no path nothing is saved.
+func loadShellHistory(path string) *shellHistory {
+	h := &shellHistory{path: path}
+	if path == "" {
+		return h
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return h
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			h.lines = append(h.lines, line)
+		}
+	}
+	if len(h.lines) > shellHistoryLimit {
+		h.lines = h.lines[len(h.lines)-shellHistoryLimit:]
+		h.rewrite()
+	}
+	return h
+}
+
+func (h *shellHistory) Add(line string) {
+	if strings.TrimSpace(line) == "" || h.Len() > 0 && h.At(0) == line {
+		return
+	}
+	h.lines = append(h.lines, line)
+	if len(h.lines) > shellHistoryLimit {
+		h.lines = h.lines[1:]
+	}
+	if h.path == "" {
+		return
+	}
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintln(f, line)
+}
+
+func (h *shellHistory) Len() int {
+	return len(h.lines)
+}
+
+// At returns the entry idx places back, 0 being the latest.
+func (h *shellHistory) At(idx int) string {
+	return h.lines[len(h.lines)-1-idx]
+}
+
+// rewrite replaces the file with the lines kept, so it doesn't grow
+// without bound.
+func (h *shellHistory) rewrite() {
+	data := strings.Join(h.lines, "\n") + "\n"
+	os.WriteFile(h.path, []byte(data), 0o600)
+}
@@ -0,0 +1,32 @@
+-- name: CreateAPIToken :one
+insert into api_tokens (
+    id,
+    created_at,
+    updated_at,
+    user_id,
+    name,
+    token_hash,
+    scopes,
+    expires_at
+) values ($1,$2,$3,$4,$5,$6,$7,$8)
+returning *;
+
+-- name: GetAPITokensForUser :many
+select * from api_tokens
+where user_id = $1
+order by created_at;
+
+-- name: GetAPITokenByHash :one
+select * from api_tokens
+where token_hash = $1
+and (expires_at is null or expires_at > now())
+limit 1;
+
+-- name: TouchAPIToken :exec
+update api_tokens
+set last_used_at = now()
+where id = $1;
+
+-- name: RevokeAPIToken :execrows
+delete from api_tokens
+where user_id = $1 and name = $2;
@@ -43,7 +43,16 @@ SELECT * FROM feed_follows
 WHERE user_id = $1 AND feed_id = $2 
 LIMIT 1;
 
--- name: DeleteFeedFollow :exec
+-- name: DeleteFeedFollowByFeedID :execrows
 delete from feed_follows
-where feed_follows.user_id = $1 
-and feed_id = (select id from feeds where url = $2 limit 1);
\ No newline at end of file
+where user_id = $1 and feed_id = $2;
+
+-- name: DeleteFeedFollowsForUser :execrows
+delete from feed_follows
+where user_id = $1;
+
+-- name: MoveFeedFollows :execrows
+update feed_follows
+set feed_id = @into_feed_id, updated_at = now()
+where feed_id = @from_feed_id
+and user_id not in (select user_id from feed_follows where feed_id = @into_feed_id);
@@ -0,0 +1,18 @@
+-- name: CreateFeedRedirect :exec
+insert into feed_redirects (url, created_at, feed_id)
+values ($1, $2, $3)
+on conflict (url) do update
+set created_at = excluded.created_at, feed_id = excluded.feed_id;
+
+-- name: GetFeedRedirect :one
+select * from feed_redirects
+where url = $1;
+
+-- name: DeleteFeedRedirect :exec
+delete from feed_redirects
+where url = $1;
+
+-- name: MoveFeedRedirects :execrows
+update feed_redirects
+set feed_id = @into_feed_id
+where feed_id = @from_feed_id;
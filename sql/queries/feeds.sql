@@ -5,8 +5,9 @@ insert into feeds (
     updated_at,
     name,
     url,
-    user_id
-) values ( $1,$2,$3,$4,$5,$6)
+    user_id,
+    canonical_url
+) values ( $1,$2,$3,$4,$5,$6,$7)
 returning *;
 
 
@@ -29,12 +30,69 @@ select * from feeds
 where url = $1
 limit 1;
 
+-- name: GetFeedByCanonicalURL :one
+select * from feeds
+where canonical_url = @canonical_url::text;
+
 -- name: MarkFeedFetched :exec
 update feeds 
 set last_fetched_at = now(), updated_at = now()
 where id = $1;
 
 -- name: GetNextFeedToFetch :one
-select * from feeds
+select * from feeds f
+where not exists (
+    select 1 from websub_subscriptions ws
+    where ws.feed_id = f.id
+    and ws.state = 'active'
+    and ws.lease_expires_at > now()
+)
 order by last_fetched_at nulls first,updated_at asc
-limit 1;
\ No newline at end of file
+limit 1;
+
+-- name: ListFeeds :many
+select * from feeds
+order by created_at
+limit $1 offset $2;
+
+-- name: GetFeedByID :one
+select * from feeds
+where id = $1
+limit 1;
+
+-- name: GetFollowedFeeds :many
+select
+    f.id,
+    f.created_at,
+    f.updated_at,
+    f.name,
+    f.url,
+    f.user_id,
+    f.last_fetched_at,
+    f.serial_id,
+    f.canonical_url
+from feeds f
+join feed_follows ff on ff.feed_id = f.id
+where ff.user_id = $1
+order by f.name;
+
+-- name: GetFeedBySerialID :one
+select * from feeds
+where serial_id = $1
+limit 1;
+
+-- name: DeleteFeed :execrows
+delete from feeds
+where id = $1;
+
+-- name: RenameFeed :one
+update feeds
+set name = $2, updated_at = now()
+where id = $1
+returning *;
+
+-- name: SetFeedURL :one
+update feeds
+set url = $2, canonical_url = $3, last_fetched_at = null, updated_at = now()
+where id = $1
+returning *;
@@ -0,0 +1,37 @@
+-- name: MarkPostRead :exec
+insert into post_reads (id, created_at, updated_at, user_id, post_id)
+values ($1,$2,$3,$4,$5)
+on conflict (user_id, post_id) do nothing;
+
+-- name: MarkPostUnread :exec
+delete from post_reads
+where user_id = $1 and post_id = $2;
+
+
+-- name: MarkFeedPostsRead :exec
+insert into post_reads (id, created_at, updated_at, user_id, post_id)
+select gen_random_uuid(), now(), now(), @user_id::uuid, p.id
+from posts p
+where p.feed_id = @feed_id and coalesce(p.published_at, p.created_at) <= @before::timestamp
+on conflict (user_id, post_id) do nothing;
+
+-- name: MarkAllPostsRead :exec
+insert into post_reads (id, created_at, updated_at, user_id, post_id)
+select gen_random_uuid(), now(), now(), ff.user_id, p.id
+from posts p
+join feed_follows ff on ff.feed_id = p.feed_id
+where ff.user_id = @user_id and coalesce(p.published_at, p.created_at) <= @before::timestamp
+on conflict (user_id, post_id) do nothing;
+
+-- name: GetUnreadPostSerialIDs :many
+select p.serial_id from posts p
+join feed_follows ff on ff.feed_id = p.feed_id
+left join post_reads pr on pr.post_id = p.id and pr.user_id = ff.user_id
+where ff.user_id = $1 and pr.id is null
+order by p.serial_id;
+
+-- name: MovePostReads :exec
+update post_reads
+set post_id = @into_post_id, updated_at = now()
+where post_id = @from_post_id
+and user_id not in (select user_id from post_reads where post_id = @into_post_id);
@@ -0,0 +1,26 @@
+-- name: CreatePostRule :one
+insert into post_rules (
+    id,
+    created_at,
+    updated_at,
+    user_id,
+    feed_id,
+    action,
+    match_type,
+    pattern
+) values ($1,$2,$3,$4,$5,$6,$7,$8)
+returning *;
+
+-- name: GetPostRulesForUser :many
+select * from post_rules
+where user_id = $1
+order by created_at;
+
+-- name: DeletePostRule :execrows
+delete from post_rules
+where id = $1 and user_id = $2;
+
+-- name: MoveFeedPostRules :execrows
+update post_rules
+set feed_id = @into_feed_id, updated_at = now()
+where feed_id = @from_feed_id;
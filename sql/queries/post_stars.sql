@@ -0,0 +1,20 @@
+-- name: StarPost :exec
+insert into post_stars (id, created_at, updated_at, user_id, post_id)
+values ($1,$2,$3,$4,$5)
+on conflict (user_id, post_id) do nothing;
+
+-- name: UnstarPost :exec
+delete from post_stars
+where user_id = $1 and post_id = $2;
+
+-- name: GetStarredPostSerialIDs :many
+select p.serial_id from posts p
+join post_stars ps on ps.post_id = p.id
+where ps.user_id = $1
+order by p.serial_id;
+
+-- name: MovePostStars :exec
+update post_stars
+set post_id = @into_post_id, updated_at = now()
+where post_id = @from_post_id
+and user_id not in (select user_id from post_stars where post_id = @into_post_id);
@@ -0,0 +1,51 @@
+-- name: CreateSession :one
+insert into sessions (
+    id,
+    created_at,
+    updated_at,
+    user_id,
+    token_hash,
+    expires_at
+) values ($1,$2,$3,$4,$5,$6)
+returning *;
+
+-- name: GetUserBySessionToken :one
+select
+    u.id,
+    u.created_at,
+    u.updated_at,
+    u.name,
+    u.fever_api_key,
+    u.last_digest_at,
+    u.password_hash,
+    u.role
+from sessions s
+join users u on u.id = s.user_id
+where s.token_hash = $1 and s.expires_at > now()
+limit 1;
+
+-- name: DeleteSession :exec
+delete from sessions
+where token_hash = $1;
+
+-- name: DeleteUserSessions :exec
+delete from sessions
+where user_id = $1;
+
+-- name: DeleteExpiredSessions :exec
+delete from sessions
+where expires_at <= now();
+
+-- name: SetPasswordReset :exec
+insert into password_resets (user_id, created_at, token_hash, expires_at)
+values ($1,$2,$3,$4)
+on conflict (user_id) do update
+set created_at = excluded.created_at, token_hash = excluded.token_hash, expires_at = excluded.expires_at;
+
+-- name: GetPasswordReset :one
+select * from password_resets
+where user_id = $1;
+
+-- name: DeletePasswordReset :exec
+delete from password_resets
+where user_id = $1;
@@ -1,10 +1,11 @@
 -- name: CreateUser :one
-insert into users (id,created_at,updated_at,name)
+insert into users (id,created_at,updated_at,name,password_hash)
 values(
     $1,
     $2,
     $3,
-    $4
+    $4,
+    $5
 )
 returning *;
 
@@ -24,4 +25,47 @@ select * from users;
 -- name: GetUserById :one
 select * from users 
 where id = $1
-limit 1;
\ No newline at end of file
+limit 1;
+
+-- name: GetUsersWithoutPassword :many
+select * from users
+where password_hash is null
+order by created_at;
+
+-- name: ListUsers :many
+select * from users
+order by created_at
+limit $1 offset $2;
+
+-- name: SetFeverAPIKey :exec
+update users
+set fever_api_key = $2, updated_at = now()
+where id = $1;
+
+-- name: GetUserByFeverAPIKey :one
+select * from users
+where fever_api_key = $1
+limit 1;
+
+-- name: SetLastDigestAt :exec
+update users
+set last_digest_at = $2, updated_at = now()
+where id = $1;
+
+-- name: SetUserPassword :exec
+update users
+set password_hash = $2, updated_at = now()
+where id = $1;
+
+-- name: SetUserRole :exec
+update users
+set role = $2, updated_at = now()
+where id = $1;
+
+-- name: CountAdmins :one
+select count(*) from users
+where role = 'admin';
+
+-- name: DeleteUser :execrows
+delete from users
+where name = $1;
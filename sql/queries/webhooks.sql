@@ -0,0 +1,87 @@
+-- name: CreateWebhook :one
+insert into webhooks (
+    id,
+    created_at,
+    updated_at,
+    user_id,
+    url,
+    feed_id,
+    keyword,
+    secret
+) values ($1,$2,$3,$4,$5,$6,$7,$8)
+returning *;
+
+-- name: GetWebhooksForUser :many
+select * from webhooks
+where user_id = $1
+order by created_at;
+
+-- name: GetWebhookForUser :one
+select * from webhooks
+where id = $1 and user_id = $2
+limit 1;
+
+-- name: DeleteWebhook :execrows
+delete from webhooks
+where id = $1 and user_id = $2;
+
+-- name: GetWebhooksForFeed :many
+select
+    w.id,
+    w.created_at,
+    w.updated_at,
+    w.user_id,
+    w.url,
+    w.feed_id,
+    w.keyword,
+    w.secret
+from webhooks w
+join feed_follows ff on ff.user_id = w.user_id and ff.feed_id = @feed_id
+where w.feed_id is null or w.feed_id = @feed_id;
+
+-- name: CreateWebhookDelivery :exec
+insert into webhook_deliveries (
+    id,
+    created_at,
+    webhook_id,
+    post_id,
+    attempt,
+    status_code,
+    error,
+    succeeded
+) values ($1,$2,$3,$4,$5,$6,$7,$8);
+
+-- name: GetWebhookDeliveries :many
+select * from webhook_deliveries
+where webhook_id = $1
+order by created_at desc
+limit $2;
+
+-- name: MoveFeedWebhooks :execrows
+update webhooks
+set feed_id = @into_feed_id, updated_at = now()
+where feed_id = @from_feed_id;
+
+-- name: GetWebhook :one
+select * from webhooks
+where id = $1;
+
+-- name: EnqueueWebhookDelivery :exec
+insert into webhook_queue (id, created_at, webhook_id, post_id, next_attempt_at)
+values ($1,$2,$3,$4,$5)
+on conflict (webhook_id, post_id) do nothing;
+
+-- name: GetDueWebhookDeliveries :many
+select * from webhook_queue
+where next_attempt_at <= @now::timestamp
+order by next_attempt_at
+limit @max_deliveries;
+
+-- name: RescheduleWebhookDelivery :exec
+update webhook_queue
+set attempts = @attempts, next_attempt_at = @next_attempt_at
+where id = @id;
+
+-- name: DeleteQueuedWebhookDelivery :exec
+delete from webhook_queue
+where id = $1;
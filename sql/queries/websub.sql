@@ -0,0 +1,48 @@
+-- name: UpsertWebSubSubscription :exec
+insert into websub_subscriptions (
+    id,
+    created_at,
+    updated_at,
+    feed_id,
+    hub_url,
+    topic_url,
+    secret
+) values ($1,$2,$3,$4,$5,$6,$7)
+on conflict (feed_id) do update
+set hub_url = excluded.hub_url,
+    topic_url = excluded.topic_url,
+    state = 'pending',
+    requested_at = null,
+    updated_at = excluded.updated_at
+where websub_subscriptions.hub_url <> excluded.hub_url
+or websub_subscriptions.topic_url <> excluded.topic_url;
+
+-- name: GetWebSubSubscription :one
+select * from websub_subscriptions
+where id = $1
+limit 1;
+
+-- name: GetWebSubSubscriptionsDue :many
+select * from websub_subscriptions
+where state <> 'denied'
+and (lease_expires_at is null or lease_expires_at < @renew_before::timestamp)
+and (requested_at is null or requested_at < @retry_before::timestamp);
+
+-- name: MarkWebSubRequested :exec
+update websub_subscriptions
+set requested_at = now(), updated_at = now()
+where id = $1;
+
+-- name: ActivateWebSubSubscription :exec
+update websub_subscriptions
+set state = 'active', lease_expires_at = $2, updated_at = now()
+where id = $1;
+
+-- name: SetWebSubState :exec
+update websub_subscriptions
+set state = $2, lease_expires_at = null, updated_at = now()
+where id = $1;
+
+-- name: DeleteWebSubSubscriptionForFeed :exec
+delete from websub_subscriptions
+where feed_id = $1;
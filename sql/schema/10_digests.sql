@@ -0,0 +1,5 @@
+-- +goose Up
+alter table users add column last_digest_at timestamp;
+
+-- +goose Down
+alter table users drop column last_digest_at;
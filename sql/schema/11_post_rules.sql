@@ -0,0 +1,19 @@
+-- +goose Up
+alter table posts add column author text;
+alter table posts add column categories text;
+
+create table post_rules (
+    id UUID primary key,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    user_id UUID not null references users(id) on delete cascade,
+    feed_id UUID references feeds(id) on delete cascade,
+    action text not null check (action in ('mute', 'highlight')),
+    match_type text not null check (match_type in ('keyword', 'regex', 'author', 'category')),
+    pattern text not null
+);
+
+-- +goose Down
+drop table post_rules;
+alter table posts drop column categories;
+alter table posts drop column author;
@@ -0,0 +1,15 @@
+-- +goose Up
+alter table users add column password_hash text;
+
+create table sessions (
+    id UUID primary key,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    user_id UUID not null references users(id) on delete cascade,
+    token_hash text unique not null,
+    expires_at timestamp not null
+);
+
+-- +goose Down
+drop table sessions;
+alter table users drop column password_hash;
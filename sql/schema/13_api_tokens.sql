@@ -0,0 +1,16 @@
+-- +goose Up
+create table api_tokens (
+    id UUID primary key,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    user_id UUID not null references users(id) on delete cascade,
+    name text not null,
+    token_hash text unique not null,
+    scopes text not null,
+    expires_at timestamp,
+    last_used_at timestamp,
+    unique(user_id, name)
+);
+
+-- +goose Down
+drop table api_tokens;
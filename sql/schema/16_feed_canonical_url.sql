@@ -0,0 +1,11 @@
+-- +goose Up
+-- the canonical form of feeds.url that lookups and uniqueness go by. it
+-- takes Go to work out, so migration 17 fills it in for existing feeds;
+-- feeds that turn out to duplicate an older one are left without one
+-- until they are merged
+alter table feeds add column canonical_url text;
+create unique index feeds_canonical_url_key on feeds (canonical_url);
+
+-- +goose Down
+drop index feeds_canonical_url_key;
+alter table feeds drop column canonical_url;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- deliveries still to be attempted, so retries outlive the process that
+-- stored the post. agg sends them and drops each once it succeeds or is
+-- given up on; webhook_deliveries keeps the log of attempts
+create table webhook_queue (
+    id uuid primary key,
+    created_at timestamp not null,
+    webhook_id uuid not null references webhooks(id) on delete cascade,
+    post_id uuid not null references posts(id) on delete cascade,
+    attempts integer not null default 0,
+    next_attempt_at timestamp not null,
+    unique (webhook_id, post_id)
+);
+
+create index webhook_queue_next_attempt_at_idx on webhook_queue (next_attempt_at);
+
+-- +goose Down
+drop table webhook_queue;
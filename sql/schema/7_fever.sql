@@ -0,0 +1,30 @@
+-- +goose Up
+alter table feeds
+add column serial_id bigserial unique;
+
+alter table posts
+add column serial_id bigserial unique;
+
+alter table users
+add column fever_api_key text unique;
+
+create table post_stars (
+    id UUID primary key,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    user_id UUID not null references users(id) on delete cascade,
+    post_id UUID not null references posts(id) on delete cascade,
+    unique(user_id,post_id)
+);
+
+-- +goose Down
+drop table post_stars;
+
+alter table users
+drop column fever_api_key;
+
+alter table posts
+drop column serial_id;
+
+alter table feeds
+drop column serial_id;
@@ -0,0 +1,26 @@
+-- +goose Up
+create table webhooks (
+    id UUID primary key,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    user_id UUID not null references users(id) on delete cascade,
+    url text not null,
+    feed_id UUID references feeds(id) on delete cascade,
+    keyword text,
+    secret text not null
+);
+
+create table webhook_deliveries (
+    id UUID primary key,
+    created_at timestamp not null,
+    webhook_id UUID not null references webhooks(id) on delete cascade,
+    post_id UUID not null references posts(id) on delete cascade,
+    attempt integer not null,
+    status_code integer,
+    error text,
+    succeeded boolean not null
+);
+
+-- +goose Down
+drop table webhook_deliveries;
+drop table webhooks;
@@ -0,0 +1,32 @@
+-- name: CreateAPIToken :one
+insert into api_tokens (
+    id,
+    created_at,
+    updated_at,
+    user_id,
+    name,
+    token_hash,
+    scopes,
+    expires_at
+) values (?,?,?,?,?,?,?,?)
+returning *;
+
+-- name: GetAPITokensForUser :many
+select * from api_tokens
+where user_id = ?
+order by created_at;
+
+-- name: GetAPITokenByHash :one
+select * from api_tokens
+where token_hash = ?
+and (expires_at is null or expires_at > strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+limit 1;
+
+-- name: TouchAPIToken :exec
+update api_tokens
+set last_used_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+where id = ?;
+
+-- name: RevokeAPIToken :execrows
+delete from api_tokens
+where user_id = ? and name = ?;
@@ -0,0 +1,52 @@
+-- name: CreateFeedFollow :one
+insert into feed_follows (
+    id,
+    created_at,
+    updated_at,
+    user_id,
+    feed_id
+) values (
+    ?,?,?,?,?
+)
+returning
+    id,
+    user_id,
+    feed_id,
+    created_at,
+    updated_at,
+    (select name from users where users.id = user_id) as user_name,
+    (select name from feeds where feeds.id = feed_id) as feed_name;
+
+
+-- name: GetFeedFollowsForUser :many
+select
+    feed_follows.id,
+    feed_follows.user_id,
+    feed_follows.feed_id,
+    feed_follows.created_at,
+    feed_follows.updated_at,
+    users.name as user_name,
+    feeds.name as feed_name
+from feed_follows
+join feeds on feeds.id = feed_follows.feed_id
+join users on users.id = feed_follows.user_id
+where feed_follows.user_id = ?;
+
+-- name: GetFeedFollowForUserAndFeed :one
+select * from feed_follows
+where user_id = ? and feed_id = ?
+limit 1;
+
+-- name: DeleteFeedFollowByFeedID :execrows
+delete from feed_follows
+where user_id = ? and feed_id = ?;
+
+-- name: DeleteFeedFollowsForUser :execrows
+delete from feed_follows
+where user_id = ?;
+
+-- name: MoveFeedFollows :execrows
+update feed_follows
+set feed_id = @into_feed_id, updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+where feed_id = @from_feed_id
+and user_id not in (select user_id from feed_follows where feed_id = @into_feed_id);
@@ -0,0 +1,100 @@
+-- name: CreateFeed :one
+insert into feeds (
+    id,
+    created_at,
+    updated_at,
+    name,
+    url,
+    user_id,
+    canonical_url,
+    serial_id
+) values (?,?,?,?,?,?,?, (select coalesce(max(serial_id), 0) + 1 from feeds))
+returning *;
+
+
+-- name: GetFeeds :many
+select * from feeds;
+
+-- name: GetFeedsWithUsers :many
+select
+    f.id as feed_id,
+    f.name as feed_name,
+    f.url as feed_url,
+    u.id as user_id,
+    u.name as user_name,
+    f.last_fetched_at as last_fetched_at
+from feeds f
+join users u on f.user_id = u.id;
+
+-- name: GetFeedByURL :one
+select * from feeds
+where url = ?
+limit 1;
+
+-- name: GetFeedByCanonicalURL :one
+select * from feeds
+where canonical_url = cast(@canonical_url as text);
+
+-- name: MarkFeedFetched :exec
+update feeds
+set last_fetched_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+where id = ?;
+
+-- name: GetNextFeedToFetch :one
+select * from feeds f
+where not exists (
+    select 1 from websub_subscriptions ws
+    where ws.feed_id = f.id
+    and ws.state = 'active'
+    and ws.lease_expires_at > strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+)
+order by last_fetched_at nulls first, updated_at asc
+limit 1;
+
+-- name: ListFeeds :many
+select * from feeds
+order by created_at
+limit ? offset ?;
+
+-- name: GetFeedByID :one
+select * from feeds
+where id = ?
+limit 1;
+
+-- name: GetFollowedFeeds :many
+select
+    f.id,
+    f.created_at,
+    f.updated_at,
+    f.name,
+    f.url,
+    f.user_id,
+    f.last_fetched_at,
+    f.serial_id,
+    f.canonical_url
+from feeds f
+join feed_follows ff on ff.feed_id = f.id
+where ff.user_id = ?
+order by f.name;
+
+-- name: GetFeedBySerialID :one
+select * from feeds
+where serial_id = ?
+limit 1;
+
+-- name: DeleteFeed :execrows
+delete from feeds
+where id = ?;
+
+-- name: RenameFeed :one
+update feeds
+set name = @name, updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+where id = @id
+returning *;
+
+-- name: SetFeedURL :one
+update feeds
+set url = @url, canonical_url = @canonical_url, last_fetched_at = null, updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+where id = @id
+returning *;
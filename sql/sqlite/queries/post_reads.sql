@@ -0,0 +1,45 @@
+-- name: MarkPostRead :exec
+insert into post_reads (id, created_at, updated_at, user_id, post_id)
+values (?,?,?,?,?)
+on conflict (user_id, post_id) do nothing;
+
+-- name: MarkPostUnread :exec
+delete from post_reads
+where user_id = ? and post_id = ?;
+
+
+-- name: MarkFeedPostsRead :exec
+insert into post_reads (id, created_at, updated_at, user_id, post_id)
+select
+    lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' || substr('89ab', 1 + abs(random()) % 4, 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6))),
+    strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    @user_id, p.id
+from posts p
+where p.feed_id = @feed_id and coalesce(p.published_at, p.created_at) <= @before
+on conflict (user_id, post_id) do nothing;
+
+-- name: MarkAllPostsRead :exec
+insert into post_reads (id, created_at, updated_at, user_id, post_id)
+select
+    lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' || substr('89ab', 1 + abs(random()) % 4, 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6))),
+    strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'),
+    ff.user_id, p.id
+from posts p
+join feed_follows ff on ff.feed_id = p.feed_id
+where ff.user_id = @user_id and coalesce(p.published_at, p.created_at) <= @before
+on conflict (user_id, post_id) do nothing;
+
+-- name: GetUnreadPostSerialIDs :many
+select p.serial_id from posts p
+join feed_follows ff on ff.feed_id = p.feed_id
+left join post_reads pr on pr.post_id = p.id and pr.user_id = ff.user_id
+where ff.user_id = ? and pr.id is null
+order by p.serial_id;
+
+-- name: MovePostReads :exec
+update post_reads
+set post_id = @into_post_id, updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+where post_id = @from_post_id
+and user_id not in (select user_id from post_reads where post_id = @into_post_id);
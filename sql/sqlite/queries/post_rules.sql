@@ -0,0 +1,26 @@
+-- name: CreatePostRule :one
+insert into post_rules (
+    id,
+    created_at,
+    updated_at,
+    user_id,
+    feed_id,
+    action,
+    match_type,
+    pattern
+) values (?,?,?,?,?,?,?,?)
+returning *;
+
+-- name: GetPostRulesForUser :many
+select * from post_rules
+where user_id = ?
+order by created_at;
+
+-- name: DeletePostRule :execrows
+delete from post_rules
+where id = ? and user_id = ?;
+
+-- name: MoveFeedPostRules :execrows
+update post_rules
+set feed_id = @into_feed_id, updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+where feed_id = @from_feed_id;
@@ -0,0 +1,20 @@
+-- name: StarPost :exec
+insert into post_stars (id, created_at, updated_at, user_id, post_id)
+values (?,?,?,?,?)
+on conflict (user_id, post_id) do nothing;
+
+-- name: UnstarPost :exec
+delete from post_stars
+where user_id = ? and post_id = ?;
+
+-- name: GetStarredPostSerialIDs :many
+select p.serial_id from posts p
+join post_stars ps on ps.post_id = p.id
+where ps.user_id = ?
+order by p.serial_id;
+
+-- name: MovePostStars :exec
+update post_stars
+set post_id = @into_post_id, updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+where post_id = @from_post_id
+and user_id not in (select user_id from post_stars where post_id = @into_post_id);
@@ -0,0 +1,167 @@
+-- name: CreatePost :one
+insert into posts(
+    id,
+    created_at,
+    updated_at,
+    title,
+    url,
+    description,
+    published_at,
+    feed_id,
+    author,
+    categories,
+    serial_id
+) values (?,?,?,?,?,?,?,?,?,?, (select coalesce(max(serial_id), 0) + 1 from posts))
+returning *;
+
+-- name: GetPostsByUserId :many
+select * from posts p
+join feed_follows ff on ff.feed_id = p.feed_id
+where ff.user_id = ?
+order by p.published_at desc nulls last
+limit ?;
+
+-- name: GetPostsForUser :many
+select
+    p.id,
+    p.created_at,
+    p.updated_at,
+    p.title,
+    p.url,
+    p.description,
+    p.published_at,
+    p.feed_id,
+    p.author,
+    p.categories,
+    cast(pr.id is not null as boolean) as read
+from posts p
+join feed_follows ff on ff.feed_id = p.feed_id
+left join post_reads pr on pr.post_id = p.id and pr.user_id = ff.user_id
+where ff.user_id = @user_id
+and (not cast(@unread_only as boolean) or pr.id is null)
+order by p.published_at desc nulls last, p.created_at desc
+limit @page_limit offset @page_offset;
+
+-- name: GetPostByID :one
+select * from posts
+where id = ?
+limit 1;
+
+
+-- name: GetPostBySerialID :one
+select * from posts
+where serial_id = ?
+limit 1;
+
+-- name: CountPostsForUser :one
+select count(*) from posts p
+join feed_follows ff on ff.feed_id = p.feed_id
+where ff.user_id = ?;
+
+-- name: GetFeverItemsSince :many
+select
+    p.serial_id,
+    f.serial_id as feed_serial_id,
+    p.title,
+    p.url,
+    p.description,
+    p.author,
+    p.published_at,
+    p.created_at,
+    cast(pr.id is not null as boolean) as is_read,
+    cast(ps.id is not null as boolean) as is_saved
+from posts p
+join feeds f on f.id = p.feed_id
+join feed_follows ff on ff.feed_id = p.feed_id
+left join post_reads pr on pr.post_id = p.id and pr.user_id = ff.user_id
+left join post_stars ps on ps.post_id = p.id and ps.user_id = ff.user_id
+where ff.user_id = @user_id and p.serial_id > @since_id
+order by p.serial_id
+limit @page_limit;
+
+-- name: GetFeverItemsBefore :many
+select
+    p.serial_id,
+    f.serial_id as feed_serial_id,
+    p.title,
+    p.url,
+    p.description,
+    p.author,
+    p.published_at,
+    p.created_at,
+    cast(pr.id is not null as boolean) as is_read,
+    cast(ps.id is not null as boolean) as is_saved
+from posts p
+join feeds f on f.id = p.feed_id
+join feed_follows ff on ff.feed_id = p.feed_id
+left join post_reads pr on pr.post_id = p.id and pr.user_id = ff.user_id
+left join post_stars ps on ps.post_id = p.id and ps.user_id = ff.user_id
+where ff.user_id = @user_id and p.serial_id < @max_id
+order by p.serial_id desc
+limit @page_limit;
+
+-- name: GetFeverItem :one
+select
+    p.serial_id,
+    f.serial_id as feed_serial_id,
+    p.title,
+    p.url,
+    p.description,
+    p.author,
+    p.published_at,
+    p.created_at,
+    cast(pr.id is not null as boolean) as is_read,
+    cast(ps.id is not null as boolean) as is_saved
+from posts p
+join feeds f on f.id = p.feed_id
+join feed_follows ff on ff.feed_id = p.feed_id
+left join post_reads pr on pr.post_id = p.id and pr.user_id = ff.user_id
+left join post_stars ps on ps.post_id = p.id and ps.user_id = ff.user_id
+where ff.user_id = @user_id and p.serial_id = @serial_id
+limit 1;
+
+-- name: GetDigestPosts :many
+select
+    p.id,
+    p.title,
+    p.url,
+    p.description,
+    p.published_at,
+    p.created_at,
+    f.name as feed_name,
+    f.url as feed_url
+from posts p
+join feeds f on f.id = p.feed_id
+join feed_follows ff on ff.feed_id = p.feed_id
+left join post_reads pr on pr.post_id = p.id and pr.user_id = ff.user_id
+where ff.user_id = @user_id
+and pr.id is null
+and p.created_at > @since
+order by p.created_at, p.id
+limit @max_posts;
+
+-- name: DeletePostsOlderThan :execrows
+delete from posts
+where coalesce(published_at, created_at) < @before;
+
+-- name: MoveFeedPosts :execrows
+update posts
+set feed_id = @into_feed_id, updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+where feed_id = @from_feed_id;
+
+-- name: GetDuplicatePosts :many
+select f.id as from_post_id, i.id as into_post_id
+from posts f
+join posts i on i.serial_id = (
+    select min(d.serial_id) from posts d
+    where d.feed_id = @into_feed_id
+        and d.title is not distinct from f.title
+        and d.published_at is not distinct from f.published_at
+)
+-- posts with neither a title nor a date have nothing to tell them apart
+where f.feed_id = @from_feed_id and (f.title is not null or f.published_at is not null)
+order by f.serial_id;
+
+-- name: DeletePost :exec
+delete from posts
+where id = ?;
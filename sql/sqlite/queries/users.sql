@@ -0,0 +1,65 @@
+-- name: CreateUser :one
+insert into users (id, created_at, updated_at, name, password_hash)
+values (?,?,?,?,?)
+returning *;
+
+-- name: GetUser :one
+select * from users
+where name = ?
+limit 1;
+
+-- name: ResetUsers :exec
+delete from users;
+
+
+-- name: GetUsers :many
+select * from users;
+
+
+-- name: GetUserById :one
+select * from users
+where id = ?
+limit 1;
+
+-- name: GetUsersWithoutPassword :many
+select * from users
+where password_hash is null
+order by created_at;
+
+-- name: ListUsers :many
+select * from users
+order by created_at
+limit ? offset ?;
+
+-- name: SetFeverAPIKey :exec
+update users
+set fever_api_key = @fever_api_key, updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+where id = @id;
+
+-- name: GetUserByFeverAPIKey :one
+select * from users
+where fever_api_key = ?
+limit 1;
+
+-- name: SetLastDigestAt :exec
+update users
+set last_digest_at = @last_digest_at, updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+where id = @id;
+
+-- name: SetUserPassword :exec
+update users
+set password_hash = @password_hash, updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+where id = @id;
+
+-- name: SetUserRole :exec
+update users
+set role = @role, updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+where id = @id;
+
+-- name: CountAdmins :one
+select count(*) from users
+where role = 'admin';
+
+-- name: DeleteUser :execrows
+delete from users
+where name = ?;
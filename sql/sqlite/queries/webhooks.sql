@@ -0,0 +1,87 @@
+-- name: CreateWebhook :one
+insert into webhooks (
+    id,
+    created_at,
+    updated_at,
+    user_id,
+    url,
+    feed_id,
+    keyword,
+    secret
+) values (?,?,?,?,?,?,?,?)
+returning *;
+
+-- name: GetWebhooksForUser :many
+select * from webhooks
+where user_id = ?
+order by created_at;
+
+-- name: GetWebhookForUser :one
+select * from webhooks
+where id = ? and user_id = ?
+limit 1;
+
+-- name: DeleteWebhook :execrows
+delete from webhooks
+where id = ? and user_id = ?;
+
+-- name: GetWebhooksForFeed :many
+select
+    w.id,
+    w.created_at,
+    w.updated_at,
+    w.user_id,
+    w.url,
+    w.feed_id,
+    w.keyword,
+    w.secret
+from webhooks w
+join feed_follows ff on ff.user_id = w.user_id and ff.feed_id = @feed_id
+where w.feed_id is null or w.feed_id = @feed_id;
+
+-- name: CreateWebhookDelivery :exec
+insert into webhook_deliveries (
+    id,
+    created_at,
+    webhook_id,
+    post_id,
+    attempt,
+    status_code,
+    error,
+    succeeded
+) values (?,?,?,?,?,?,?,?);
+
+-- name: GetWebhookDeliveries :many
+select * from webhook_deliveries
+where webhook_id = ?
+order by created_at desc
+limit ?;
+
+-- name: MoveFeedWebhooks :execrows
+update webhooks
+set feed_id = @into_feed_id, updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+where feed_id = @from_feed_id;
+
+-- name: GetWebhook :one
+select * from webhooks
+where id = ?;
+
+-- name: EnqueueWebhookDelivery :exec
+insert into webhook_queue (id, created_at, webhook_id, post_id, next_attempt_at)
+values (?,?,?,?,?)
+on conflict (webhook_id, post_id) do nothing;
+
+-- name: GetDueWebhookDeliveries :many
+select * from webhook_queue
+where next_attempt_at <= @now
+order by next_attempt_at
+limit @max_deliveries;
+
+-- name: RescheduleWebhookDelivery :exec
+update webhook_queue
+set attempts = @attempts, next_attempt_at = @next_attempt_at
+where id = @id;
+
+-- name: DeleteQueuedWebhookDelivery :exec
+delete from webhook_queue
+where id = ?;
@@ -0,0 +1,9 @@
+-- +goose Up
+alter table users add column role text not null default 'user' check (role in ('user', 'admin'));
+
+-- the first user becomes admin so existing installs are not locked out
+update users set role = 'admin'
+where id = (select id from users order by created_at limit 1);
+
+-- +goose Down
+alter table users drop column role;
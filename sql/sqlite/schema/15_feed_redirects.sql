@@ -0,0 +1,11 @@
+-- +goose Up
+-- URLs of feeds that were merged into another feed or moved, so adding
+-- them again finds the feed they ended up as
+create table feed_redirects (
+    url text primary key,
+    created_at timestamp not null,
+    feed_id uuid not null references feeds(id) on delete cascade
+);
+
+-- +goose Down
+drop table feed_redirects;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- one-time tokens that let a user set a new password without the old one:
+-- issued by an admin, or by migrate for accounts from before passwords
+-- existed, which can't log in otherwise
+create table password_resets (
+    user_id uuid primary key references users(id) on delete cascade,
+    created_at timestamp not null,
+    token_hash text unique not null,
+    expires_at timestamp not null
+);
+
+-- +goose Down
+drop table password_resets;
@@ -0,0 +1,14 @@
+-- +goose Up
+create table posts (
+    id uuid primary key,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    title text,
+    url text unique not null,
+    description text,
+    published_at timestamp,
+    feed_id uuid not null references feeds(id) on delete cascade
+);
+
+-- +goose Down
+drop table posts;
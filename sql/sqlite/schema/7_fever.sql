@@ -0,0 +1,31 @@
+-- +goose Up
+-- SQLite has no sequences and cannot add unique columns, so serial ids are
+-- numbered from the rowid here and by the insert queries afterwards.
+alter table feeds add column serial_id integer not null default 0;
+update feeds set serial_id = rowid;
+create unique index feeds_serial_id_key on feeds(serial_id);
+
+alter table posts add column serial_id integer not null default 0;
+update posts set serial_id = rowid;
+create unique index posts_serial_id_key on posts(serial_id);
+
+alter table users add column fever_api_key text;
+create unique index users_fever_api_key_key on users(fever_api_key);
+
+create table post_stars (
+    id uuid primary key,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    user_id uuid not null references users(id) on delete cascade,
+    post_id uuid not null references posts(id) on delete cascade,
+    unique(user_id, post_id)
+);
+
+-- +goose Down
+drop table post_stars;
+drop index users_fever_api_key_key;
+alter table users drop column fever_api_key;
+drop index posts_serial_id_key;
+alter table posts drop column serial_id;
+drop index feeds_serial_id_key;
+alter table feeds drop column serial_id;
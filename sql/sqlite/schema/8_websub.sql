@@ -0,0 +1,16 @@
+-- +goose Up
+create table websub_subscriptions (
+    id uuid primary key,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    feed_id uuid not null unique references feeds(id) on delete cascade,
+    hub_url text not null,
+    topic_url text not null,
+    secret text not null,
+    state text not null default 'pending',
+    lease_expires_at timestamp,
+    requested_at timestamp
+);
+
+-- +goose Down
+drop table websub_subscriptions;
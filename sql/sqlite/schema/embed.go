@@ -0,0 +1,8 @@
+// Package schema embeds the goose migrations for SQLite databases. They
+// mirror sql/schema version for version.
+package schema
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
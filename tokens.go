@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richardteaman/gator/internal/database"
+)
+
+// apiTokenPrefix tells API tokens apart from session tokens in an
+// Authorization header.
+const apiTokenPrefix = "gat_"
+
+var errInvalidToken = kindErrorf(kindUnauthenticated, "invalid, expired or revoked api token")
+
+const (
+	scopeRead    = "read"
+	scopeFollows = "follows"
+	scopeAdmin   = "admin"
+)
+
+// scopeGrants lists what each scope allows; broader scopes include the
+// narrower ones.
+var scopeGrants = map[string][]string{
+	scopeRead:    {scopeRead},
+	scopeFollows: {scopeRead, scopeFollows},
+	scopeAdmin:   {scopeRead, scopeFollows, scopeAdmin},
+}
+
+// scopeSet is what a request is allowed to do. Sessions and passwords
+// carry every scope; API tokens carry the ones they were minted with.
+type scopeSet map[string]bool
+
+var allScopes = scopeSet{scopeRead: true, scopeFollows: true, scopeAdmin: true}
+
+// scopeNames validates a comma separated scope list and drops duplicates.
+func scopeNames(raw string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || slices.Contains(names, name) {
+			continue
+		}
+		if _, ok := scopeGrants[name]; !ok {
+			return nil, fmt.Errorf("unknown scope %q, expected read, follows or admin", name)
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil, errors.New("at least one scope is required")
+	}
+	return names, nil
+}
+
+func parseScopes(raw string) (scopeSet, error) {
+	names, err := scopeNames(raw)
+	if err != nil {
+		return nil, err
+	}
+	scopes := scopeSet{}
+	for _, name := range names {
+		for _, g := range scopeGrants[name] {
+			scopes[g] = true
+		}
+	}
+	return scopes, nil
+}
+
+func (s scopeSet) has(scope string) bool {
+	return s[scope]
+}
+
+// parseExpiry accepts Go durations plus a "d" suffix for days, since
+// token lifetimes are usually counted in days.
+func parseExpiry(raw string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(raw, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid expiry %q", raw)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid expiry %q", raw)
+	}
+	return d, nil
+}
+
+var tokenCommand = &commandSpec{
+	Name:    "token",
+	Summary: "manages personal API tokens",
+	Subcommands: []*commandSpec{
+		{
+			Name:    "create",
+			Summary: "mints a token for scripts, printed once",
+			Args:    []argSpec{{Name: "name"}},
+			Flags: []flagSpec{
+				stringFlag("scope", scopeRead, "comma separated scopes: read, follows, admin"),
+				stringFlag("expires", "", "lifetime such as 30d or 12h; never expires when empty"),
+			},
+			Handler: middlewareLoggedIn(handlerTokenCreate),
+		},
+		{
+			Name:    "revoke",
+			Summary: "revokes a token",
+			Args:    []argSpec{{Name: "name"}},
+			Handler: middlewareLoggedIn(handlerTokenRevoke),
+		},
+	},
+}
+
+func handlerTokenCreate(s *state, cmd command, user database.User) error {
+	name := cmd.Args[0]
+	expires := cmd.String("expires")
+
+	scopes, err := scopeNames(cmd.String("scope"))
+	if err != nil {
+		return cmd.usageErrorf("%v", err)
+	}
+
+	now := time.Now()
+	var expiresAt sql.NullTime
+	if expires != "" {
+		lifetime, err := parseExpiry(expires)
+		if err != nil {
+			return cmd.usageErrorf("%v", err)
+		}
+		expiresAt = sql.NullTime{Time: now.Add(lifetime), Valid: true}
+	}
+
+	secret, err := randomHex(20)
+	if err != nil {
+		return fmt.Errorf("could not generate token: %w", err)
+	}
+	token := apiTokenPrefix + secret
+
+	_, err = s.db.CreateAPIToken(context.Background(), database.CreateAPITokenParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		UserID:    user.ID,
+		Name:      name,
+		TokenHash: hashToken(token),
+		Scopes:    strings.Join(scopes, ","),
+		ExpiresAt: expiresAt,
+	})
+	if isUniqueViolation(err) {
+		return kindErrorf(kindAlreadyExists, "a token named %q already exists", name)
+	}
+	if err != nil {
+		return fmt.Errorf("could not create token: %w", err)
+	}
+
+	fmt.Fprintf(s.out, "Token %q created. Copy it now, it will not be shown again:\n%s\n", name, token)
+	return nil
+}
+
+func handlerTokenRevoke(s *state, cmd command, user database.User) error {
+	n, err := s.db.RevokeAPIToken(context.Background(), database.RevokeAPITokenParams{
+		UserID: user.ID,
+		Name:   cmd.Args[0],
+	})
+	if err != nil {
+		return fmt.Errorf("could not revoke token: %w", err)
+	}
+	if n == 0 {
+		return kindErrorf(kindNotFound, "token %q not found", cmd.Args[0])
+	}
+
+	fmt.Fprintf(s.out, "Token %q revoked\n", cmd.Args[0])
+	return nil
+}
+
+var tokensCommand = &commandSpec{
+	Name:    "tokens",
+	Summary: "lists your tokens with scopes, expiry and last use",
+	Handler: middlewareLoggedIn(handlerTokens),
+}
+
+func handlerTokens(s *state, cmd command, user database.User) error {
+	tokens, err := s.db.GetAPITokensForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("could not list tokens: %w", err)
+	}
+	if len(tokens) == 0 {
+		fmt.Fprintln(s.out, "No API tokens")
+	}
+
+	for _, token := range tokens {
+		expires := "never"
+		if token.ExpiresAt.Valid {
+			expires = token.ExpiresAt.Time.Format(time.DateOnly)
+			if token.ExpiresAt.Time.Before(time.Now()) {
+				expires += " (expired)"
+			}
+		}
+		used := "never"
+		if token.LastUsedAt.Valid {
+			used = token.LastUsedAt.Time.Format(time.DateTime)
+		}
+		fmt.Fprintf(s.out, "* %s  scopes: %s  created: %s  expires: %s  last used: %s\n",
+			token.Name, token.Scopes, token.CreatedAt.Format(time.DateOnly), expires, used)
+	}
+	return nil
+}
+
+// userForAPIToken resolves a bearer API token to its owner and scopes.
+func userForAPIToken(ctx context.Context, s *state, token string) (database.User, scopeSet, error) {
+	stored, err := s.db.GetAPITokenByHash(ctx, hashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.User{}, nil, errInvalidToken
+	}
+	if err != nil {
+		return database.User{}, nil, fmt.Errorf("could not fetch api token: %w", err)
+	}
+
+	scopes, err := parseScopes(stored.Scopes)
+	if err != nil {
+		return database.User{}, nil, fmt.Errorf("token %s has invalid scopes: %w", stored.Name, err)
+	}
+	user, err := s.db.GetUserById(ctx, stored.UserID)
+	if err != nil {
+		return database.User{}, nil, fmt.Errorf("could not fetch token owner: %w", err)
+	}
+	if err := s.db.TouchAPIToken(ctx, stored.ID); err != nil {
+		return database.User{}, nil, fmt.Errorf("could not update api token: %w", err)
+	}
+	return user, scopes, nil
+}
@@ -0,0 +1,435 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richardteaman/gator/internal/database"
+)
+
+const (
+	webhookEvent      = "post.created"
+	webhookLogDefault = 20
+)
+
+// webhookBackoff is the wait before each delivery attempt. A delivery is
+// given up on once every attempt has failed.
+var webhookBackoff = []time.Duration{0, 10 * time.Second, time.Minute, 5 * time.Minute}
+
+// webhookPollInterval is how often agg and serve look for due deliveries,
+// so retries keep to webhookBackoff however long agg's period is.
+const webhookPollInterval = 5 * time.Second
+
+// webhookBatchSize caps the deliveries attempted in one go.
+const webhookBatchSize = 20
+
+var webhookClient = &http.Client{Timeout: 15 * time.Second}
+
+type webhookPayload struct {
+	Event      string      `json:"event"`
+	WebhookID  uuid.UUID   `json:"webhook_id"`
+	Feed       webhookFeed `json:"feed"`
+	Post       webhookPost `json:"post"`
+	DeliveryAt time.Time   `json:"delivered_at"`
+}
+
+type webhookFeed struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+	URL  string    `json:"url"`
+}
+
+type webhookPost struct {
+	ID          uuid.UUID  `json:"id"`
+	Title       string     `json:"title"`
+	URL         string     `json:"url"`
+	Description string     `json:"description"`
+	PublishedAt *time.Time `json:"published_at"`
+}
+
+var webhookCommand = &commandSpec{
+	Name:    "webhook",
+	Summary: "manages webhooks that receive new posts",
+	Subcommands: []*commandSpec{
+		{
+			Name:    "add",
+			Summary: "POSTs new posts from followed feeds to a URL",
+			Args:    []argSpec{{Name: "url"}},
+			Flags: []flagSpec{
+				stringFlag("feed", "", "only fire for posts from this feed URL"),
+				stringFlag("keyword", "", "only fire for posts mentioning this word"),
+				stringFlag("secret", "", "HMAC secret; generated when empty"),
+			},
+			Handler: middlewareLoggedIn(handlerWebhookAdd),
+		},
+		{
+			Name:    "remove",
+			Summary: "deletes a webhook",
+			Args:    []argSpec{{Name: "id"}},
+			Handler: middlewareLoggedIn(handlerWebhookRemove),
+		},
+		{
+			Name:    "log",
+			Summary: "shows recent delivery attempts",
+			Args:    []argSpec{{Name: "id"}},
+			Flags:   []flagSpec{intFlag("limit", webhookLogDefault, "number of deliveries to show")},
+			Handler: middlewareLoggedIn(handlerWebhookLog),
+		},
+	},
+}
+
+func handlerWebhookAdd(s *state, cmd command, user database.User) error {
+	feedURL := cmd.String("feed")
+	keyword := cmd.String("keyword")
+	secret := cmd.String("secret")
+
+	target := cmd.Args[0]
+	if u, err := url.Parse(target); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return cmd.usageErrorf("invalid webhook URL %q", target)
+	}
+
+	ctx := context.Background()
+
+	var feedID uuid.NullUUID
+	if feedURL != "" {
+		feed, err := feedByURL(ctx, s.db, feedURL)
+		if err != nil {
+			return fmt.Errorf("feed %s not found: %w", feedURL, err)
+		}
+		feedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+
+	generated := secret == ""
+	if generated {
+		var err error
+		if secret, err = randomHex(32); err != nil {
+			return fmt.Errorf("could not generate webhook secret: %w", err)
+		}
+	}
+
+	now := time.Now()
+	hook, err := s.db.CreateWebhook(ctx, database.CreateWebhookParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		UserID:    user.ID,
+		Url:       target,
+		FeedID:    feedID,
+		Keyword:   sql.NullString{String: keyword, Valid: keyword != ""},
+		Secret:    secret,
+	})
+	if err != nil {
+		return fmt.Errorf("could not create webhook: %w", err)
+	}
+
+	fmt.Fprintf(s.out, "Webhook %s added for %s\n", hook.ID, hook.Url)
+	if generated {
+		fmt.Fprintf(s.out, "Secret: %s\n", hook.Secret)
+	}
+	return nil
+}
+
+func handlerWebhookRemove(s *state, cmd command, user database.User) error {
+	id, err := uuid.Parse(cmd.Args[0])
+	if err != nil {
+		return cmd.usageErrorf("invalid webhook id %q", cmd.Args[0])
+	}
+
+	n, err := s.db.DeleteWebhook(context.Background(), database.DeleteWebhookParams{ID: id, UserID: user.ID})
+	if err != nil {
+		return fmt.Errorf("could not remove webhook: %w", err)
+	}
+	if n == 0 {
+		return kindErrorf(kindNotFound, "webhook %s not found", id)
+	}
+
+	fmt.Fprintf(s.out, "Webhook %s removed\n", id)
+	return nil
+}
+
+func handlerWebhookLog(s *state, cmd command, user database.User) error {
+	id, err := uuid.Parse(cmd.Args[0])
+	if err != nil {
+		return cmd.usageErrorf("invalid webhook id %q", cmd.Args[0])
+	}
+
+	ctx := context.Background()
+	hook, err := s.db.GetWebhookForUser(ctx, database.GetWebhookForUserParams{ID: id, UserID: user.ID})
+	if err != nil {
+		return fmt.Errorf("webhook %s not found: %w", id, err)
+	}
+
+	deliveries, err := s.db.GetWebhookDeliveries(ctx, database.GetWebhookDeliveriesParams{
+		WebhookID: hook.ID,
+		Limit:     int32(cmd.Int("limit")),
+	})
+	if err != nil {
+		return fmt.Errorf("could not load deliveries: %w", err)
+	}
+	if len(deliveries) == 0 {
+		fmt.Fprintln(s.out, "No deliveries yet")
+	}
+
+	for _, d := range deliveries {
+		result := "ok"
+		if !d.Succeeded {
+			result = "failed"
+		}
+		status := "-"
+		if d.StatusCode.Valid {
+			status = fmt.Sprint(d.StatusCode.Int32)
+		}
+		fmt.Fprintf(s.out, "%s  post %s  attempt %d  %s  %s", d.CreatedAt.Format(time.RFC3339), d.PostID, d.Attempt, status, result)
+		if d.Error.Valid {
+			fmt.Fprintf(s.out, "  %s", d.Error.String)
+		}
+		fmt.Fprintln(s.out)
+	}
+	return nil
+}
+
+var webhooksCommand = &commandSpec{
+	Name:    "webhooks",
+	Summary: "lists your webhooks",
+	Handler: middlewareLoggedIn(handlerWebhooks),
+}
+
+func handlerWebhooks(s *state, cmd command, user database.User) error {
+	hooks, err := s.db.GetWebhooksForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("could not list webhooks: %w", err)
+	}
+	if len(hooks) == 0 {
+		fmt.Fprintln(s.out, "No webhooks")
+	}
+
+	for _, hook := range hooks {
+		fmt.Fprintf(s.out, "%s  %s\n", hook.ID, hook.Url)
+		if hook.FeedID.Valid {
+			if feed, err := s.db.GetFeedByID(context.Background(), hook.FeedID.UUID); err == nil {
+				fmt.Fprintf(s.out, "    feed:    %s\n", feed.Url)
+			}
+		}
+		if hook.Keyword.Valid {
+			fmt.Fprintf(s.out, "    keyword: %s\n", hook.Keyword.String)
+		}
+	}
+	return nil
+}
+
+// notifyWebhooks queues delivery of a newly stored post to every webhook
+// whose owner follows the feed and whose filters match. agg sends queued
+// deliveries, so slow receivers never hold up scraping and retries survive
+// the process that stored the post. Posts stored by a feed's first fetch
+// are its backlog rather than news and aren't sent.
+func notifyWebhooks(ctx context.Context, s *state, feed database.Feed, post database.Post) {
+	if !feed.LastFetchedAt.Valid {
+		return
+	}
+	hooks, err := s.db.GetWebhooksForFeed(ctx, feed.ID)
+	if err != nil {
+		fmt.Fprintln(s.errOut, "Could not load webhooks:", err)
+		return
+	}
+
+	now := time.Now()
+	for _, hook := range hooks {
+		if !webhookMatches(hook, post) {
+			continue
+		}
+		err := s.db.EnqueueWebhookDelivery(ctx, database.EnqueueWebhookDeliveryParams{
+			ID:            uuid.New(),
+			CreatedAt:     now,
+			WebhookID:     hook.ID,
+			PostID:        post.ID,
+			NextAttemptAt: now.Add(webhookBackoff[0]),
+		})
+		if err != nil {
+			fmt.Fprintln(s.errOut, "Could not queue webhook delivery:", err)
+		}
+	}
+}
+
+func webhookMatches(hook database.Webhook, post database.Post) bool {
+	if !hook.Keyword.Valid {
+		return true
+	}
+	keyword := strings.ToLower(hook.Keyword.String)
+	text := strings.ToLower(post.Title.String + " " + stripHTML(post.Description.String))
+	return strings.Contains(text, keyword)
+}
+
+// runWebhookDeliveries delivers due webhooks every webhookPollInterval
+// until ctx is done. serve runs it so posts pushed over WebSub are sent
+// without an agg running.
+func runWebhookDeliveries(ctx context.Context, s *state) {
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		deliverWebhooks(s)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverWebhooks makes the next attempt of every queued delivery that is
+// due. A delivery leaves the queue once it succeeds, fails in a way that
+// isn't worth retrying or has used up webhookBackoff, whether its attempts
+// reached the receiver or not.
+func deliverWebhooks(s *state) {
+	ctx := context.Background()
+
+	due, err := s.db.GetDueWebhookDeliveries(ctx, database.GetDueWebhookDeliveriesParams{
+		Now:           time.Now(),
+		MaxDeliveries: webhookBatchSize,
+	})
+	if err != nil {
+		fmt.Fprintln(s.errOut, "Error loading webhook deliveries:", err)
+		return
+	}
+
+	for _, queued := range due {
+		done, err := deliverWebhook(ctx, s, queued)
+		if err != nil {
+			// an attempt that couldn't be made counts too, or a post that
+			// can't be loaded would be tried every poll forever
+			fmt.Fprintln(s.errOut, "Error delivering webhook:", err)
+			done = int(queued.Attempts)+1 >= len(webhookBackoff)
+		}
+		if done {
+			err = s.db.DeleteQueuedWebhookDelivery(ctx, queued.ID)
+		} else {
+			err = s.db.RescheduleWebhookDelivery(ctx, database.RescheduleWebhookDeliveryParams{
+				Attempts:      queued.Attempts + 1,
+				NextAttemptAt: time.Now().Add(webhookBackoff[queued.Attempts+1]),
+				ID:            queued.ID,
+			})
+		}
+		if err != nil {
+			fmt.Fprintln(s.errOut, "Error updating webhook queue:", err)
+		}
+	}
+}
+
+// deliverWebhook makes one attempt at a queued delivery and records it in
+// the delivery log. It reports whether the delivery is finished, delivered
+// or given up on; an error means the attempt couldn't be made at all.
+func deliverWebhook(ctx context.Context, s *state, queued database.WebhookQueue) (bool, error) {
+	hook, err := s.db.GetWebhook(ctx, queued.WebhookID)
+	if err != nil {
+		return false, fmt.Errorf("could not load webhook %s: %w", queued.WebhookID, err)
+	}
+	post, err := s.db.GetPostByID(ctx, queued.PostID)
+	if err != nil {
+		return false, fmt.Errorf("could not load post %s: %w", queued.PostID, err)
+	}
+	feed, err := s.db.GetFeedByID(ctx, post.FeedID)
+	if err != nil {
+		return false, fmt.Errorf("could not load feed %s: %w", post.FeedID, err)
+	}
+
+	body, err := json.Marshal(webhookPayload{
+		Event:      webhookEvent,
+		WebhookID:  hook.ID,
+		DeliveryAt: time.Now().UTC(),
+		Feed:       webhookFeed{ID: feed.ID, Name: feed.Name, URL: feed.Url},
+		Post: webhookPost{
+			ID:          post.ID,
+			Title:       post.Title.String,
+			URL:         post.Url,
+			Description: post.Description.String,
+			PublishedAt: nullTimePtr(post.PublishedAt),
+		},
+	})
+	if err != nil {
+		return false, fmt.Errorf("could not encode webhook payload: %w", err)
+	}
+
+	deliveryID := uuid.New()
+	status, err := postWebhook(ctx, hook, deliveryID, body)
+
+	attempt := queued.Attempts + 1
+	delivery := database.CreateWebhookDeliveryParams{
+		ID:         deliveryID,
+		CreatedAt:  time.Now(),
+		WebhookID:  hook.ID,
+		PostID:     post.ID,
+		Attempt:    attempt,
+		StatusCode: sql.NullInt32{Int32: int32(status), Valid: status != 0},
+		Succeeded:  err == nil,
+	}
+	if err != nil {
+		delivery.Error = sql.NullString{String: err.Error(), Valid: true}
+	}
+	if logErr := s.db.CreateWebhookDelivery(ctx, delivery); logErr != nil {
+		fmt.Fprintln(s.errOut, "Could not record webhook delivery:", logErr)
+	}
+
+	if err == nil || !retryableStatus(status) {
+		return true, nil
+	}
+	if int(attempt) >= len(webhookBackoff) {
+		fmt.Fprintf(s.errOut, "Giving up on webhook %s for post %s\n", hook.Url, post.ID)
+		return true, nil
+	}
+	return false, nil
+}
+
+// postWebhook sends one delivery attempt. The body is signed with the
+// webhook's secret so receivers can verify it came from us.
+func postWebhook(ctx context.Context, hook database.Webhook, deliveryID uuid.UUID, body []byte) (int, error) {
+	mac := hmac.New(sha256.New, []byte(hook.Secret))
+	mac.Write(body)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gator")
+	req.Header.Set("X-Gator-Event", webhookEvent)
+	req.Header.Set("X-Gator-Delivery", deliveryID.String())
+	req.Header.Set("X-Gator-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+	res, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("receiver responded %s", res.Status)
+	}
+	return res.StatusCode, nil
+}
+
+// retryableStatus reports whether a failed attempt is worth repeating.
+// Network errors (status 0) and server-side failures are; client errors
+// other than timeouts and rate limiting will not fix themselves.
+func retryableStatus(status int) bool {
+	switch {
+	case status == 0, status >= 500:
+		return true
+	case status == http.StatusRequestTimeout, status == http.StatusTooManyRequests:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richardteaman/gator/internal/database"
+	"github.com/richardteaman/gator/internal/storage"
+)
+
+// webhookRequest is a request received by webhookReceiver.
+type webhookRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// webhookReceiver answers each delivery with the next of statuses, or the
+// last one once they run out, and records what it got.
+type webhookReceiver struct {
+	srv      *httptest.Server
+	statuses []int
+	requests []webhookRequest
+}
+
+func newWebhookReceiver(t *testing.T) *webhookReceiver {
+	rcv := &webhookReceiver{statuses: []int{http.StatusOK}}
+	rcv.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		rcv.requests = append(rcv.requests, webhookRequest{header: r.Header, body: body})
+		status := rcv.statuses[0]
+		if len(rcv.statuses) > 1 {
+			rcv.statuses = rcv.statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(rcv.srv.Close)
+	return rcv
+}
+
+func TestWebhooks(t *testing.T) {
+	// retries are due right away so the test doesn't wait for them
+	saved := webhookBackoff
+	webhookBackoff = []time.Duration{0, 0, 0}
+	t.Cleanup(func() { webhookBackoff = saved })
+
+	for name, dbURL := range map[string]string{"memory": "memory:", "sqlite": "sqlite://{dir}/gator.db"} {
+		t.Run(name, func(t *testing.T) {
+			items := []string{"backlog"}
+			publisher := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `<?xml version="1.0"?><rss version="2.0"><channel><title>Hooked</title>`)
+				for _, title := range items {
+					fmt.Fprintf(w, "<item><title>%s</title><link>https://example.com/%s</link></item>", title, url.PathEscape(title))
+				}
+				fmt.Fprint(w, "</channel></rss>")
+			}))
+			t.Cleanup(publisher.Close)
+			rcv := newWebhookReceiver(t)
+
+			ts := newSession(t, dbURL)
+			if dbURL != "memory:" {
+				ts.run("migrate up", "")
+			}
+			ts.registerUser("alice")
+			ts.run("addfeed Hooked "+publisher.URL+"/feed.xml", "")
+			ts.run("webhook add --secret s3cret "+rcv.srv.URL+"/hook", "")
+
+			ctx := context.Background()
+			queued := func() []database.WebhookQueue {
+				t.Helper()
+				due, err := ts.s.db.GetDueWebhookDeliveries(ctx, database.GetDueWebhookDeliveriesParams{Now: time.Now(), MaxDeliveries: 10})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return due
+			}
+			publish := func(title string) {
+				t.Helper()
+				items = append(items, title)
+				ts.call("fetch the feed", scrapeFeeds)
+				if n := len(queued()); n != 1 {
+					t.Fatalf("%d deliveries queued for %q, want 1", n, title)
+				}
+			}
+
+			// the first fetch stores what the feed already had, which isn't news
+			ts.call("fetch the feed", scrapeFeeds)
+			if n := len(queued()); n != 0 {
+				t.Errorf("first fetch queued %d deliveries, want none", n)
+			}
+
+			// a failed attempt stays queued for the next run of agg
+			rcv.statuses = []int{http.StatusInternalServerError, http.StatusOK}
+			publish("fresh")
+			deliverWebhooks(ts.s)
+			if len(rcv.requests) != 1 {
+				t.Fatalf("receiver got %d requests, want 1", len(rcv.requests))
+			}
+			if due := queued(); len(due) != 1 || due[0].Attempts != 1 {
+				t.Fatalf("queue after a failed attempt: %+v", due)
+			}
+			deliverWebhooks(ts.s)
+			if len(rcv.requests) != 2 || len(queued()) != 0 {
+				t.Fatalf("after the retry: %d requests, %d still queued", len(rcv.requests), len(queued()))
+			}
+
+			req := rcv.requests[1]
+			mac := hmac.New(sha256.New, []byte("s3cret"))
+			mac.Write(req.body)
+			if got, want := req.header.Get("X-Gator-Signature"), "sha256="+hex.EncodeToString(mac.Sum(nil)); got != want {
+				t.Errorf("signature %q, want %q", got, want)
+			}
+			if req.header.Get("X-Gator-Event") != webhookEvent || req.header.Get("Content-Type") != "application/json" {
+				t.Errorf("headers %v", req.header)
+			}
+			var payload webhookPayload
+			if err := json.Unmarshal(req.body, &payload); err != nil {
+				t.Fatalf("payload %s: %v", req.body, err)
+			}
+			if payload.Post.Title != "fresh" || payload.Post.URL != "https://example.com/fresh" || payload.Feed.Name != "Hooked" {
+				t.Errorf("payload %+v", payload)
+			}
+			if payload.Post.PublishedAt != nil {
+				t.Errorf("post without a pubDate sent as published at %v", payload.Post.PublishedAt)
+			}
+
+			// a receiver that keeps failing is given up on after the last attempt
+			rcv.requests = nil
+			rcv.statuses = []int{http.StatusServiceUnavailable}
+			publish("doomed")
+			for range len(webhookBackoff) + 1 {
+				deliverWebhooks(ts.s)
+			}
+			if len(rcv.requests) != len(webhookBackoff) || len(queued()) != 0 {
+				t.Errorf("failing receiver: %d requests, %d still queued; want %d and none", len(rcv.requests), len(queued()), len(webhookBackoff))
+			}
+
+			// a rejection isn't worth retrying
+			rcv.requests = nil
+			rcv.statuses = []int{http.StatusBadRequest}
+			publish("rejected")
+			deliverWebhooks(ts.s)
+			if len(rcv.requests) != 1 || len(queued()) != 0 {
+				t.Errorf("rejecting receiver: %d requests, %d still queued; want 1 and none", len(rcv.requests), len(queued()))
+			}
+
+			// a delivery that can't even be attempted is dropped after as many tries
+			rcv.requests = nil
+			rcv.statuses = []int{http.StatusOK}
+			publish("unloadable")
+			db := ts.s.db
+			ts.s.db = failingPostStore{db}
+			for i := range len(webhookBackoff) {
+				if due := queued(); len(due) != 1 || int(due[0].Attempts) != i {
+					t.Fatalf("queue before try %d: %+v", i+1, due)
+				}
+				deliverWebhooks(ts.s)
+			}
+			ts.s.db = db
+			if got := strings.Count(ts.output.String(), "post table on fire"); got != len(webhookBackoff) {
+				t.Errorf("%d errors reported for the unloadable post, want %d:\n%s", got, len(webhookBackoff), ts.output.String())
+			}
+			ts.output.Reset()
+			if len(rcv.requests) != 0 || len(queued()) != 0 {
+				t.Errorf("unloadable post: %d requests, %d still queued; want none", len(rcv.requests), len(queued()))
+			}
+
+			ts.run("webhooks", "")
+			id := strings.Fields(strings.SplitAfter(ts.transcript.String(), "$ gator webhooks\n")[1])[0]
+			ts.run("webhook log --limit 10 "+id, "")
+			if got := strings.Count(ts.transcript.String(), "  failed"); got != 5 {
+				t.Errorf("webhook log shows %d failed attempts, want 5:\n%s", got, ts.transcript.String())
+			}
+		})
+	}
+}
+
+// failingPostStore is a store whose posts can't be loaded.
+type failingPostStore struct {
+	storage.Store
+}
+
+func (failingPostStore) GetPostByID(context.Context, uuid.UUID) (database.Post, error) {
+	return database.Post{}, errors.New("post table on fire")
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richardteaman/gator/internal/database"
+)
+
+const (
+	websubLeaseSeconds = 10 * 24 * 60 * 60
+	// websubRenewMargin is how long before a lease expires we ask the hub again.
+	websubRenewMargin = time.Hour
+	// websubRetryAfter stops us from hammering hubs that never verify.
+	websubRetryAfter = 15 * time.Minute
+	websubCheckEvery = time.Minute
+	maxNotifyBody    = 5 << 20
+)
+
+// websubSubscriber keeps hub subscriptions alive while the server runs.
+// Feeds without an active lease are still picked up by agg, so an expired
+// or failed subscription simply falls back to polling.
+type websubSubscriber struct {
+	state       *state
+	callbackURL string
+	client      *http.Client
+}
+
+// recordWebSubHub remembers that feed advertises a hub so that server mode
+// can subscribe to it. topic falls back to the URL we poll.
+func recordWebSubHub(ctx context.Context, s *state, feed database.Feed, hub, topic string) {
+	if topic == "" {
+		topic = feed.Url
+	}
+
+	secret, err := randomHex(32)
+	if err != nil {
+		fmt.Fprintln(s.errOut, "Could not generate websub secret:", err)
+		return
+	}
+
+	now := time.Now()
+	err = s.db.UpsertWebSubSubscription(ctx, database.UpsertWebSubSubscriptionParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		FeedID:    feed.ID,
+		HubUrl:    hub,
+		TopicUrl:  topic,
+		Secret:    secret,
+	})
+	if err != nil {
+		fmt.Fprintln(s.errOut, "Could not record websub hub:", err)
+	}
+}
+
+func newWebSubSubscriber(s *state, publicURL string) *websubSubscriber {
+	return &websubSubscriber{
+		state:       s,
+		callbackURL: strings.TrimRight(publicURL, "/") + "/websub/",
+		client:      &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (ws *websubSubscriber) run(ctx context.Context) {
+	ticker := time.NewTicker(websubCheckEvery)
+	defer ticker.Stop()
+
+	for {
+		ws.subscribeDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (ws *websubSubscriber) subscribeDue(ctx context.Context) {
+	now := time.Now()
+	subs, err := ws.state.db.GetWebSubSubscriptionsDue(ctx, database.GetWebSubSubscriptionsDueParams{
+		RenewBefore: now.Add(websubRenewMargin),
+		RetryBefore: now.Add(-websubRetryAfter),
+	})
+	if err != nil {
+		fmt.Fprintln(ws.state.errOut, "Could not load websub subscriptions:", err)
+		return
+	}
+
+	for _, sub := range subs {
+		if err := ws.subscribe(ctx, sub); err != nil {
+			fmt.Fprintf(ws.state.errOut, "WebSub subscribe to %s failed: %v\n", sub.HubUrl, err)
+		}
+		if err := ws.state.db.MarkWebSubRequested(ctx, sub.ID); err != nil {
+			fmt.Fprintln(ws.state.errOut, "Could not mark websub request:", err)
+		}
+	}
+}
+
+// subscribe sends a subscription request; the hub confirms asynchronously
+// through handleWebSubVerify.
+func (ws *websubSubscriber) subscribe(ctx context.Context, sub database.WebsubSubscription) error {
+	form := url.Values{
+		"hub.mode":          {"subscribe"},
+		"hub.topic":         {sub.TopicUrl},
+		"hub.callback":      {ws.callbackURL + sub.ID.String()},
+		"hub.secret":        {sub.Secret},
+		"hub.lease_seconds": {strconv.Itoa(websubLeaseSeconds)},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.HubUrl, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", "gator")
+
+	res, err := ws.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("hub responded %s: %s", res.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// handleWebSubVerify answers the hub's verification of intent and records
+// the granted lease.
+func (a *apiServer) handleWebSubVerify(w http.ResponseWriter, r *http.Request) {
+	sub, ok := a.lookupWebSub(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	if query.Get("hub.topic") != sub.TopicUrl {
+		http.Error(w, "topic mismatch", http.StatusNotFound)
+		return
+	}
+
+	ctx := r.Context()
+	switch query.Get("hub.mode") {
+	case "subscribe":
+		lease, err := strconv.Atoi(query.Get("hub.lease_seconds"))
+		if err != nil || lease <= 0 {
+			lease = websubLeaseSeconds
+		}
+		err = a.state.db.ActivateWebSubSubscription(ctx, database.ActivateWebSubSubscriptionParams{
+			ID:             sub.ID,
+			LeaseExpiresAt: sql.NullTime{Time: time.Now().Add(time.Duration(lease) * time.Second), Valid: true},
+		})
+		if err != nil {
+			respondInternalError(w, "could not activate websub subscription", err)
+			return
+		}
+
+	case "unsubscribe":
+		// only an unsubscribe gator asked for is confirmed; anyone who
+		// knows the callback URL could otherwise end the subscription
+		if sub.State != "unsubscribing" {
+			http.Error(w, "no unsubscribe requested", http.StatusNotFound)
+			return
+		}
+		if err := a.state.db.SetWebSubState(ctx, database.SetWebSubStateParams{ID: sub.ID, State: "unsubscribed"}); err != nil {
+			respondInternalError(w, "could not update websub subscription", err)
+			return
+		}
+
+	case "denied":
+		fmt.Fprintf(a.state.errOut, "WebSub hub denied subscription for %s: %s\n", sub.TopicUrl, query.Get("hub.reason"))
+		if err := a.state.db.SetWebSubState(ctx, database.SetWebSubStateParams{ID: sub.ID, State: "denied"}); err != nil {
+			respondInternalError(w, "could not update websub subscription", err)
+			return
+		}
+
+	default:
+		http.Error(w, "unknown hub.mode", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, query.Get("hub.challenge"))
+}
+
+// handleWebSubNotify accepts content distribution from the hub. Payloads
+// with a bad signature are acknowledged but dropped, as the spec requires.
+func (a *apiServer) handleWebSubNotify(w http.ResponseWriter, r *http.Request) {
+	sub, ok := a.lookupWebSub(w, r)
+	if !ok {
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxNotifyBody))
+	if err != nil {
+		http.Error(w, "could not read body", http.StatusBadRequest)
+		return
+	}
+
+	if !validHubSignature(sub.Secret, r.Header.Get("X-Hub-Signature"), body) {
+		fmt.Fprintf(a.state.errOut, "Dropping websub notification for %s: invalid signature\n", sub.TopicUrl)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	ctx := r.Context()
+	feed, err := a.state.db.GetFeedByID(ctx, sub.FeedID)
+	if err != nil {
+		respondInternalError(w, "could not load feed", err)
+		return
+	}
+
+	rssFeed, err := parseFeed(body)
+	if err != nil {
+		fmt.Fprintf(a.state.errOut, "Could not parse websub notification for %s: %v\n", sub.TopicUrl, err)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	storeFeedItems(ctx, a.state, feed, rssFeed.Channel.Item)
+	if err := a.state.db.MarkFeedFetched(ctx, feed.ID); err != nil {
+		fmt.Fprintln(a.state.errOut, "Could not mark feed fetched:", err)
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (a *apiServer) lookupWebSub(w http.ResponseWriter, r *http.Request) (database.WebsubSubscription, bool) {
+	id, err := uuid.Parse(r.PathValue("subID"))
+	if err != nil {
+		http.Error(w, "unknown subscription", http.StatusNotFound)
+		return database.WebsubSubscription{}, false
+	}
+
+	sub, err := a.state.db.GetWebSubSubscription(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "unknown subscription", http.StatusNotFound)
+		return database.WebsubSubscription{}, false
+	}
+	if err != nil {
+		respondInternalError(w, "could not load websub subscription", err)
+		return database.WebsubSubscription{}, false
+	}
+	return sub, true
+}
+
+// validHubSignature checks an X-Hub-Signature header of the form
+// "method=hexdigest" against an HMAC of body keyed with secret.
+func validHubSignature(secret, header string, body []byte) bool {
+	method, digest, ok := strings.Cut(header, "=")
+	if !ok {
+		return false
+	}
+
+	var newHash func() hash.Hash
+	switch strings.ToLower(method) {
+	case "sha1":
+		newHash = sha1.New
+	case "sha256":
+		newHash = sha256.New
+	case "sha384":
+		newHash = sha512.New384
+	case "sha512":
+		newHash = sha512.New
+	default:
+		return false
+	}
+
+	expected, err := hex.DecodeString(digest)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/richardteaman/gator/internal/database"
+)
+
+func TestValidHubSignature(t *testing.T) {
+	body := []byte("<rss/>")
+	sign := func(secret string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"sha256=" + sign("s3cret"), true},
+		{"SHA256=" + sign("s3cret"), true},
+		{"sha256=" + sign("other"), false},
+		{"sha256=" + strings.ToUpper(sign("s3cret")), true},
+		{"sha256=nothex", false},
+		{"sha256", false},
+		{"", false},
+		{"md5=" + sign("s3cret"), false},
+		{"sha1=da39a3ee5e6b4b0d3255bfef95601890afd80709", false},
+	}
+	for _, tt := range tests {
+		if got := validHubSignature("s3cret", tt.header, body); got != tt.want {
+			t.Errorf("validHubSignature(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+// websubFeed is an RSS feed advertising hub, with the items given.
+func websubFeed(hub, self string, items ...string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<?xml version="1.0"?>
+<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom"><channel>
+<title>Pushed</title>
+<atom:link rel="hub" href="%s"/>
+<atom:link rel="self" href="%s"/>
+<link>https://example.com/</link>
+`, hub, self)
+	for _, title := range items {
+		fmt.Fprintf(&sb, "<item><title>%s</title><link>https://example.com/%s</link></item>\n", title, url.PathEscape(title))
+	}
+	sb.WriteString("</channel></rss>")
+	return sb.String()
+}
+
+func TestWebSub(t *testing.T) {
+	// the hub records subscription requests for the test to confirm;
+	// subscribeDue sends them before it returns
+	var requests []url.Values
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+		requests = append(requests, r.PostForm)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(hub.Close)
+
+	topic := "https://example.com/pushed.xml"
+	publisher := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, websubFeed(hub.URL, topic, "polled"))
+	}))
+	t.Cleanup(publisher.Close)
+
+	ts := newSession(t, "memory:")
+	ts.registerUser("alice")
+	ts.run("addfeed Pushed "+publisher.URL+"/feed.xml", "")
+	ts.call("fetch the feed", scrapeFeeds)
+	api := newAPIClient(ts)
+
+	ctx := context.Background()
+	subscriber := newWebSubSubscriber(ts.s, api.srv.URL+"/")
+	subscriber.subscribeDue(ctx)
+	if len(requests) != 1 {
+		t.Fatalf("hub got %d subscription requests, want 1", len(requests))
+	}
+	req := requests[0]
+	if req.Get("hub.mode") != "subscribe" || req.Get("hub.topic") != topic || req.Get("hub.secret") == "" {
+		t.Fatalf("subscription request %v", req)
+	}
+	callback, err := url.Parse(req.Get("hub.callback"))
+	if err != nil || !strings.HasPrefix(req.Get("hub.callback"), api.srv.URL+"/websub/") {
+		t.Fatalf("callback %q is not on the server", req.Get("hub.callback"))
+	}
+	secret := req.Get("hub.secret")
+
+	// not confirmed yet, so it is asked again only after websubRetryAfter
+	subscriber.subscribeDue(ctx)
+	if len(requests) != 1 {
+		t.Errorf("unconfirmed subscription requested again right away")
+	}
+
+	verify := func(query url.Values) (*http.Response, string) {
+		return api.do("GET", callback.Path+"?"+query.Encode(), "", "")
+	}
+	if resp, _ := verify(url.Values{"hub.mode": {"subscribe"}, "hub.topic": {"https://example.com/other.xml"}, "hub.challenge": {"c1"}}); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("verification for another topic: %s, want 404", resp.Status)
+	}
+	if resp, _ := api.do("GET", "/websub/"+uuid.NewString()+"?hub.challenge=c", "", ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("verification for an unknown subscription: %s, want 404", resp.Status)
+	}
+	if resp, _ := verify(url.Values{"hub.mode": {"bogus"}, "hub.topic": {topic}}); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("verification with an unknown mode: %s, want 400", resp.Status)
+	}
+	resp, body := verify(url.Values{"hub.mode": {"subscribe"}, "hub.topic": {topic}, "hub.challenge": {"c2"}, "hub.lease_seconds": {"86400"}})
+	if resp.StatusCode != http.StatusOK || body != "c2" {
+		t.Fatalf("verification: %s %q, want 200 echoing the challenge", resp.Status, body)
+	}
+	sub, err := ts.s.db.GetWebSubSubscription(ctx, uuid.MustParse(strings.TrimPrefix(callback.Path, "/websub/")))
+	if err != nil || sub.State != "active" || !sub.LeaseExpiresAt.Valid {
+		t.Fatalf("subscription after verification: %+v, %v", sub, err)
+	}
+
+	notify := func(payload, signature string) {
+		t.Helper()
+		req, err := http.NewRequest("POST", api.srv.URL+callback.Path, strings.NewReader(payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if signature != "" {
+			req.Header.Set("X-Hub-Signature", signature)
+		}
+		resp, err := api.srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusAccepted {
+			t.Errorf("notification: %s, want 202", resp.Status)
+		}
+	}
+	sign := func(payload string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(payload))
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	forged := websubFeed(hub.URL, topic, "forged")
+	notify(forged, "")
+	notify(forged, sign(forged+" "))
+	pushed := websubFeed(hub.URL, topic, "pushed")
+	notify(pushed, sign(pushed))
+	notify("not xml", sign("not xml"))
+
+	user, err := ts.s.db.GetUser(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	posts, err := ts.s.db.GetPostsForUser(ctx, database.GetPostsForUserParams{UserID: user.ID, PageLimit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for _, p := range posts {
+		titles = append(titles, p.Title.String)
+	}
+	slices.Sort(titles)
+	if strings.Join(titles, ",") != "polled,pushed" {
+		t.Errorf("stored posts %v, want the polled and the correctly signed one", titles)
+	}
+
+	// an active lease isn't renewed until it is about to expire
+	subscriber.subscribeDue(ctx)
+	if len(requests) != 1 {
+		t.Errorf("active subscription requested again")
+	}
+
+	// gator never asked to unsubscribe, so the hub isn't told it may
+	resp, body = verify(url.Values{"hub.mode": {"unsubscribe"}, "hub.topic": {topic}, "hub.challenge": {"c3"}})
+	if resp.StatusCode != http.StatusNotFound || body == "c3" {
+		t.Errorf("unrequested unsubscribe: %s %q, want 404", resp.Status, body)
+	}
+	if sub, err := ts.s.db.GetWebSubSubscription(ctx, sub.ID); err != nil || sub.State != "active" {
+		t.Errorf("subscription after an unrequested unsubscribe: %+v, %v", sub, err)
+	}
+	if err := ts.s.db.SetWebSubState(ctx, database.SetWebSubStateParams{ID: sub.ID, State: "unsubscribing"}); err != nil {
+		t.Fatal(err)
+	}
+	resp, body = verify(url.Values{"hub.mode": {"unsubscribe"}, "hub.topic": {topic}, "hub.challenge": {"c4"}})
+	if resp.StatusCode != http.StatusOK || body != "c4" {
+		t.Errorf("requested unsubscribe: %s %q, want 200 echoing the challenge", resp.Status, body)
+	}
+	if sub, err := ts.s.db.GetWebSubSubscription(ctx, sub.ID); err != nil || sub.State != "unsubscribed" {
+		t.Errorf("subscription after unsubscribing: %+v, %v", sub, err)
+	}
+
+	resp, body = verify(url.Values{"hub.mode": {"denied"}, "hub.topic": {topic}, "hub.reason": {"no"}})
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("denial: %s %q", resp.Status, body)
+	}
+	if sub, err := ts.s.db.GetWebSubSubscription(ctx, sub.ID); err != nil || sub.State != "denied" {
+		t.Errorf("subscription after denial: %+v, %v", sub, err)
+	}
+}